@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"hls-key-server-go/internal/pkg/audit"
+)
+
+// Exit codes of the audit subcommand
+const (
+	auditExitOK     = 0
+	auditExitBroken = 1
+	auditExitUsage  = 2
+)
+
+const auditUsage = `Usage:
+  hls-key-server audit verify [--file path | files...] [--public-key file] [--checkpoint-every n] [--from time] [--to time] [--json]
+  hls-key-server audit keygen [--out prefix]
+`
+
+// runAudit dispatches the audit subcommands and returns the process exit code
+func runAudit(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, auditUsage)
+		return auditExitUsage
+	}
+
+	var err error
+	code := auditExitOK
+	switch args[0] {
+	case "verify":
+		code, err = auditVerify(args[1:], stdout)
+	case "keygen":
+		err = auditKeygen(args[1:], stdout)
+	default:
+		err = fmt.Errorf("unknown audit command %q", args[0])
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "audit %s: %v\n", args[0], err)
+		if errors.Is(err, pflag.ErrHelp) {
+			return auditExitOK
+		}
+		fmt.Fprint(stderr, auditUsage)
+		return auditExitUsage
+	}
+	return code
+}
+
+// auditVerify validates the hash chain and checkpoints and prints the
+// disclosure summary. It returns auditExitBroken when verification fails,
+// including, with --public-key, a chain that checkpoints do not seal.
+func auditVerify(args []string, stdout io.Writer) (int, error) {
+	fs := pflag.NewFlagSet("audit verify", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.String("file", "./logs/audit.jsonl", "Active audit file; rotated siblings are included")
+	pubKeyFile := fs.String("public-key", "", "PEM Ed25519 public key to verify checkpoint signatures")
+	every := fs.Int("checkpoint-every", 1000, "audit.checkpoint-every the files were written with; 0 skips the unsealed tail check")
+	fromFlag := fs.String("from", "", "Start of the disclosure summary (RFC 3339 or YYYY-MM-DD), inclusive")
+	toFlag := fs.String("to", "", "End of the disclosure summary (RFC 3339 or YYYY-MM-DD), exclusive")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return auditExitUsage, err
+	}
+
+	opts := audit.VerifyOptions{CheckpointEvery: *every}
+	var err error
+	if *every < 0 {
+		return auditExitUsage, errors.New("--checkpoint-every must not be negative")
+	}
+	if opts.From, err = parseReportTime(*fromFlag); err != nil {
+		return auditExitUsage, fmt.Errorf("--from: %w", err)
+	}
+	if opts.To, err = parseReportTime(*toFlag); err != nil {
+		return auditExitUsage, fmt.Errorf("--to: %w", err)
+	}
+	if *pubKeyFile != "" {
+		if opts.PublicKey, err = audit.LoadPublicKey(*pubKeyFile); err != nil {
+			return auditExitUsage, err
+		}
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		if files, err = audit.Files(*file); err != nil {
+			return auditExitUsage, err
+		}
+		if len(files) == 0 {
+			return auditExitUsage, fmt.Errorf("no audit files found for %s", *file)
+		}
+	}
+
+	report, err := audit.Verify(files, opts)
+	if err != nil {
+		return auditExitUsage, err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return auditExitUsage, err
+		}
+	} else {
+		printReport(stdout, report, opts.PublicKey)
+	}
+
+	if !report.OK() {
+		return auditExitBroken, nil
+	}
+	return auditExitOK, nil
+}
+
+func printReport(w io.Writer, r *audit.Report, pub ed25519.PublicKey) {
+	fmt.Fprintf(w, "files:        %d\n", len(r.Files))
+	fmt.Fprintf(w, "records:      %d (seq %d-%d)\n", r.Records, r.FirstSeq, r.LastSeq)
+	if r.FirstSeq > 1 {
+		fmt.Fprintf(w, "note:         chain starts at seq %d; earlier files were rotated away\n", r.FirstSeq)
+	}
+	if r.SignaturesChecked {
+		fmt.Fprintf(w, "checkpoints:  %d verified with key %s (last at seq %d)\n", r.Checkpoints, audit.KeyID(pub), r.LastCheckpointSeq)
+	} else {
+		fmt.Fprintf(w, "checkpoints:  %d (signatures not checked, pass --public-key)\n", r.Checkpoints)
+	}
+	if r.Unsealed > 0 {
+		fmt.Fprintf(w, "unsealed:     %d records after the last checkpoint\n", r.Unsealed)
+	}
+
+	if r.Broken != nil {
+		fmt.Fprintf(w, "status:       BROKEN at %s:%d (seq %d): %s\n", r.Broken.File, r.Broken.Line, r.Broken.Seq, r.Broken.Reason)
+	} else {
+		fmt.Fprintln(w, "status:       OK")
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Key disclosures:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tSUBJECT\tTENANT\tCOUNT\tFIRST\tLAST")
+	for _, d := range r.Disclosures {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			d.KeyID, d.Subject, d.Tenant, d.Count,
+			d.First.Format(time.RFC3339), d.Last.Format(time.RFC3339),
+		)
+	}
+	_ = tw.Flush()
+}
+
+// auditKeygen writes a new Ed25519 key pair for checkpoint signing
+func auditKeygen(args []string, stdout io.Writer) error {
+	fs := pflag.NewFlagSet("audit keygen", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	out := fs.String("out", "audit-signing", "Output path prefix; writes <prefix>.key and <prefix>.pub")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	privPEM, pubPEM, err := audit.GenerateKeyPEM()
+	if err != nil {
+		return err
+	}
+	// O_EXCL: never overwrite an existing signing key
+	f, err := os.OpenFile(*out+".key", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(privPEM); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out+".pub", pubPEM, 0o644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "wrote %s.key (set audit.signing-key-file) and %s.pub (pass to audit verify --public-key)\n", *out, *out)
+	return nil
+}
+
+// parseReportTime accepts RFC 3339 timestamps or plain dates (UTC midnight)
+func parseReportTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
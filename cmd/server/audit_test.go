@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/pkg/audit"
+)
+
+func TestRunAudit_KeygenAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "signing")
+
+	var stdout, stderr bytes.Buffer
+	if code := runAudit([]string{"keygen", "--out", prefix}, &stdout, &stderr); code != auditExitOK {
+		t.Fatalf("keygen exit = %d, stderr = %s", code, stderr.String())
+	}
+	// Existing keys are never overwritten
+	if code := runAudit([]string{"keygen", "--out", prefix}, &stdout, &stderr); code != auditExitUsage {
+		t.Errorf("second keygen exit = %d, want %d", code, auditExitUsage)
+	}
+
+	priv, err := audit.LoadPrivateKey(prefix + ".key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "audit.jsonl")
+	sink, err := audit.NewFileSink(path, 0, 0, audit.WithCheckpoints(priv, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := audit.New(zap.NewNop(), sink)
+	l.Record(t.Context(), audit.Event{Type: audit.TypeKeyFetch, Outcome: audit.OutcomeSuccess, Subject: "alice", KeyID: "stream.key"})
+	_ = l.Close()
+
+	stdout.Reset()
+	code := runAudit([]string{"verify", "--file", path, "--public-key", prefix + ".pub"}, &stdout, &stderr)
+	if code != auditExitOK {
+		t.Fatalf("verify exit = %d, stdout = %s, stderr = %s", code, stdout.String(), stderr.String())
+	}
+	for _, want := range []string{"status:       OK", "stream.key", "alice"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("verify output missing %q:\n%s", want, stdout.String())
+		}
+	}
+
+	// Tamper with the record
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, bytes.Replace(data, []byte("alice"), []byte("eve"), 1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := runAudit([]string{"verify", "--file", path}, &stdout, &stderr); code != auditExitBroken {
+		t.Errorf("verify tampered exit = %d, want %d", code, auditExitBroken)
+	}
+	if !strings.Contains(stdout.String(), "BROKEN") {
+		t.Errorf("verify output missing BROKEN:\n%s", stdout.String())
+	}
+
+	// A log no checkpoint signs fails verification with a public key
+	unsigned := filepath.Join(dir, "unsigned.jsonl")
+	sink, err = audit.NewFileSink(unsigned, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = sink.Write(audit.Event{Type: audit.TypeKeyFetch, Outcome: audit.OutcomeSuccess, Subject: "alice", KeyID: "stream.key"})
+	_ = sink.Close()
+	stdout.Reset()
+	if code := runAudit([]string{"verify", "--file", unsigned, "--public-key", prefix + ".pub"}, &stdout, &stderr); code != auditExitBroken {
+		t.Errorf("verify unsigned exit = %d, want %d", code, auditExitBroken)
+	}
+}
+
+func TestRunAudit_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{nil, {"bogus"}, {"verify", "--from", "yesterday"}, {"verify", "--checkpoint-every", "-1"}} {
+		if code := runAudit(args, &stdout, &stderr); code != auditExitUsage {
+			t.Errorf("runAudit(%v) exit = %d, want %d", args, code, auditExitUsage)
+		}
+	}
+}
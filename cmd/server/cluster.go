@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/tlsutil"
+	"hls-key-server-go/internal/repository"
+)
+
+// startCluster joins the Raft cluster, replicating key writes into local.
+// The node and its peer certificate reloader are tied to lc.
+func startCluster(cfg configs.Cluster, local repository.LocalKeyStore, lc *lifecycle, logger *zap.Logger) (*repository.ReplicatedKeyRepository, error) {
+	var peerTLS *tls.Config
+	if cfg.TLS.Enabled() {
+		reloader, err := tlsutil.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, logger)
+		if err != nil {
+			return nil, fmt.Errorf("load cluster certificate: %w", err)
+		}
+		peerTLS, err = tlsutil.PeerConfig(cfg.TLS, reloader)
+		if err != nil {
+			return nil, fmt.Errorf("build cluster tls config: %w", err)
+		}
+		lc.Go("cluster-tls-reloader", func(ctx context.Context) {
+			reloader.Run(ctx, time.Minute)
+		})
+	}
+
+	repo, err := repository.NewReplicatedKeyRepository(local, cfg, peerTLS,
+		repository.WithReplicationLogger(logger),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("start cluster node: %w", err)
+	}
+	lc.OnStop("cluster", func(context.Context) error {
+		return repo.Close()
+	})
+
+	logger.Info("cluster node started",
+		zap.String("node_id", cfg.NodeID),
+		zap.String("bind_addr", cfg.BindAddr),
+		zap.Bool("bootstrap", cfg.Bootstrap),
+		zap.Bool("tls", peerTLS != nil),
+	)
+	return repo, nil
+}
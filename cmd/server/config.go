@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+
+	"hls-key-server-go/internal/configs"
+)
+
+// Exit codes of the config subcommand
+const (
+	configExitOK      = 0
+	configExitInvalid = 1
+	configExitUsage   = 2
+)
+
+const configUsage = `Usage:
+  hls-key-server config check [--config file] [--json]
+  hls-key-server config env
+`
+
+// runConfig dispatches the config subcommands and returns the process exit code
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, configUsage)
+		return configExitUsage
+	}
+
+	var err error
+	code := configExitOK
+	switch args[0] {
+	case "check":
+		code, err = configCheck(args[1:], stdout)
+	case "env":
+		err = configEnv(args[1:], stdout)
+	default:
+		err = fmt.Errorf("unknown config command %q", args[0])
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "config %s: %v\n", args[0], err)
+		if errors.Is(err, pflag.ErrHelp) {
+			return configExitOK
+		}
+		fmt.Fprint(stderr, configUsage)
+		return configExitUsage
+	}
+	return code
+}
+
+// configCheckReport is the --json output of config check
+type configCheckReport struct {
+	File     string   `json:"file,omitempty"`
+	Mode     string   `json:"mode"`
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+// configCheck loads and validates the configuration without starting the
+// server. It returns configExitInvalid when validation fails.
+func configCheck(args []string, stdout io.Writer) (int, error) {
+	fs := pflag.NewFlagSet("config check", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.StringP("config", "c", "", "Path to configuration file (default: config.yaml in ./config or .)")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return configExitUsage, err
+	}
+
+	path, err := configs.FindFile(*file)
+	if err != nil {
+		return configExitUsage, err
+	}
+	cfg, err := configs.Load(configs.Sources{File: path, Env: os.LookupEnv})
+	if err != nil {
+		return configExitUsage, err
+	}
+	errs, warnings := cfg.Check()
+
+	report := configCheckReport{
+		File:     path,
+		Mode:     cfg.App.Mode,
+		Valid:    len(errs) == 0,
+		Errors:   make([]string, 0, len(errs)),
+		Warnings: make([]string, 0, len(warnings)),
+	}
+	for _, e := range errs {
+		report.Errors = append(report.Errors, e.Error())
+	}
+	for _, w := range warnings {
+		report.Warnings = append(report.Warnings, w.Error())
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return configExitUsage, err
+		}
+	} else {
+		for _, e := range report.Errors {
+			fmt.Fprintf(stdout, "ERROR   %s\n", e)
+		}
+		for _, w := range report.Warnings {
+			fmt.Fprintf(stdout, "WARNING %s\n", w)
+		}
+		if report.Valid {
+			fmt.Fprintf(stdout, "configuration is valid (mode %s, %d warnings)\n", report.Mode, len(report.Warnings))
+		} else {
+			fmt.Fprintf(stdout, "configuration is invalid: %d errors, %d warnings\n", len(report.Errors), len(report.Warnings))
+		}
+	}
+
+	if !report.Valid {
+		return configExitInvalid, nil
+	}
+	return configExitOK, nil
+}
+
+// configEnv lists the environment variable overriding each config key
+func configEnv(args []string, stdout io.Writer) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVARIABLE\tSET")
+	for _, key := range configs.Keys() {
+		name := configs.EnvVar(key)
+		set := ""
+		if _, ok := os.LookupEnv(name); ok {
+			set = "env"
+		}
+		if _, ok := os.LookupEnv(name + configs.FileEnvSuffix); ok {
+			set = "file"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, name, set)
+	}
+	return w.Flush()
+}
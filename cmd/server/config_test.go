@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConfig_Check(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	weak := write("weak.yaml", `
+app:
+  mode: "production"
+jwt:
+  secretkey: "secret"
+  expire: 0
+  user: "player"
+  header-key: "X-Player-Key"
+  header-value: "`+strings.Repeat("h", 16)+`"
+  iss: "hls-key-server"
+  aud: "hls-key-api"
+metric:
+  user: "admin"
+  password: "password"
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := runConfig([]string{"check", "--config", weak, "--json"}, &stdout, &stderr); code != configExitInvalid {
+		t.Fatalf("check exit = %d, want %d; stderr = %s", code, configExitInvalid, stderr.String())
+	}
+	var report configCheckReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("failed to unmarshal report: %v\n%s", err, stdout.String())
+	}
+	want := []string{"jwt.secretkey", "jwt.expire", "metric.password", "metric"}
+	if len(report.Errors) != len(want) {
+		t.Fatalf("errors = %v, want paths %v", report.Errors, want)
+	}
+	for i, path := range want {
+		if !strings.HasPrefix(report.Errors[i], path+":") {
+			t.Errorf("error %d = %q, want path %s", i, report.Errors[i], path)
+		}
+	}
+
+	// The same values only warn outside production
+	dev := write("dev.yaml", strings.Replace(mustRead(t, weak), `mode: "production"`, `mode: "development"`, 1))
+	stdout.Reset()
+	if code := runConfig([]string{"check", "-c", dev}, &stdout, &stderr); code != configExitInvalid {
+		t.Errorf("dev check exit = %d, want %d (expire is always invalid)", code, configExitInvalid)
+	}
+	if !strings.Contains(stdout.String(), "WARNING metric.password") {
+		t.Errorf("dev check output missing warning:\n%s", stdout.String())
+	}
+
+	if code := runConfig([]string{"check", "--config", filepath.Join(dir, "missing.yaml")}, &stdout, &stderr); code != configExitUsage {
+		t.Errorf("missing file exit = %d, want %d", code, configExitUsage)
+	}
+	if code := runConfig([]string{"lint"}, &stdout, &stderr); code != configExitUsage {
+		t.Errorf("unknown command exit = %d, want %d", code, configExitUsage)
+	}
+}
+
+func mustRead(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
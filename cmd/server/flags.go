@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/spf13/pflag"
+
+	"hls-key-server-go/internal/configs"
+)
+
+// newServerFlags defines the server command line. Flags named after a
+// config key override the file and environment.
+func newServerFlags(errorHandling pflag.ErrorHandling) *pflag.FlagSet {
+	fs := pflag.NewFlagSet("hls-key-server", errorHandling)
+	fs.StringP("config", "c", "", "Path to configuration file (default: config.yaml in ./config or .)")
+	fs.String("app.port", "", "Listen port, overrides app.port")
+	fs.String("app.mode", "", "Run mode (development, debug, release or production), overrides app.mode")
+	fs.String("log.level", "", "Log level (debug, info, warn or error), overrides log.level")
+	return fs
+}
+
+// loadServerConfig loads and validates the configuration named by parsed
+// server flags. The returned sources reload the same layers later.
+func loadServerConfig(fs *pflag.FlagSet, env configs.LookupFunc) (*configs.Config, configs.Sources, error) {
+	path, err := fs.GetString("config")
+	if err != nil {
+		return nil, configs.Sources{}, err
+	}
+	file, err := configs.FindFile(path)
+	if err != nil {
+		return nil, configs.Sources{}, err
+	}
+
+	src := configs.Sources{File: file, Env: env, Flags: fs}
+	cfg, err := configs.Load(src)
+	if err != nil {
+		return nil, configs.Sources{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, configs.Sources{}, err
+	}
+	return cfg, src, nil
+}
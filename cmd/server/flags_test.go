@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestLoadServerConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, reloadBaseConfig)
+	env := func(name string) (string, bool) {
+		if name == "HLSKEY_APP_PORT" {
+			return "8443", true
+		}
+		return "", false
+	}
+
+	fs := newServerFlags(pflag.ContinueOnError)
+	if err := fs.Parse([]string{"-c", path, "--log.level", "warn"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, src, err := loadServerConfig(fs, env)
+	if err != nil {
+		t.Fatalf("loadServerConfig() error = %v", err)
+	}
+	if src.File != path {
+		t.Errorf("sources.File = %q, want %q", src.File, path)
+	}
+	if cfg.App.Port != "8443" || cfg.Log.Level != "warn" {
+		t.Errorf("app.port = %q, log.level = %q; want env and flag values", cfg.App.Port, cfg.Log.Level)
+	}
+
+	// A flag beats the environment
+	fs = newServerFlags(pflag.ContinueOnError)
+	if err := fs.Parse([]string{"-c", path, "--app.port", "9443"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _, err = loadServerConfig(fs, env); err != nil || cfg.App.Port != "9443" {
+		t.Errorf("app.port = %q, %v; want flag value", cfg.App.Port, err)
+	}
+
+	// Invalid values are rejected
+	fs = newServerFlags(pflag.ContinueOnError)
+	if err := fs.Parse([]string{"-c", path, "--log.level", "loud"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadServerConfig(fs, env); err == nil {
+		t.Error("loadServerConfig() accepted an invalid log level")
+	}
+
+	fs = newServerFlags(pflag.ContinueOnError)
+	if err := fs.Parse([]string{"-c", filepath.Join(t.TempDir(), "missing.yaml")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadServerConfig(fs, os.LookupEnv); err == nil {
+		t.Error("loadServerConfig() accepted a missing file")
+	}
+}
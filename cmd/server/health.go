@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hls-key-server-go/internal/pkg/health"
+	"hls-key-server-go/internal/repository"
+	"hls-key-server-go/internal/service"
+)
+
+// probePaths are polled by orchestrators and kept out of access logs and traces
+var probePaths = []string{"/healthz", "/livez", "/readyz", "/startupz"}
+
+var errNoKeys = errors.New("no keys loaded")
+
+// buildHealthChecker registers the readiness checks for the key store
+func buildHealthChecker(hlsService *service.HLSService, keyRepo repository.KeyRepository) *health.Checker {
+	checker := health.New(2 * time.Second)
+
+	checker.AddReadiness("keys", func(ctx context.Context) error {
+		if len(keyRepo.List(ctx)) == 0 {
+			return errNoKeys
+		}
+		return nil
+	})
+	checker.AddReadiness("key_reload", func(context.Context) error {
+		return hlsService.LastReloadError()
+	})
+	if hc, ok := keyRepo.(repository.HealthChecker); ok {
+		checker.AddReadiness("key_store", hc.HealthCheck)
+	}
+
+	return checker
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/handler"
+	"hls-key-server-go/internal/pkg/health"
+	"hls-key-server-go/internal/repository"
+	"hls-key-server-go/internal/service"
+)
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keyDir := filepath.Join(t.TempDir(), "keys")
+	repo, err := repository.NewFileKeyRepository(keyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hlsService := service.NewHLSService(repo, zap.NewNop())
+	checker := buildHealthChecker(hlsService, repo)
+	checker.MarkStarted()
+
+	h := handler.NewHealthHandler(checker, zap.NewNop())
+	router := gin.New()
+	router.GET("/readyz", h.Readyz)
+
+	probe := func() (int, health.Report) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var report health.Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		return w.Code, report
+	}
+
+	// No keys yet
+	if code, report := probe(); code != http.StatusServiceUnavailable || report.Checks["keys"].Status != health.StatusFail {
+		t.Errorf("empty key dir: status %d, report %+v", code, report)
+	}
+
+	if err := os.WriteFile(filepath.Join(keyDir, "stream.key"), []byte("0123456789abcdef"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hlsService.ReloadKeys(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if code, report := probe(); code != http.StatusOK {
+		t.Errorf("with keys: status %d, report %+v", code, report)
+	}
+
+	// Key directory disappears: the reload fails but cached keys remain
+	if err := os.RemoveAll(keyDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hlsService.ReloadKeys(t.Context()); err == nil {
+		t.Fatal("ReloadKeys() expected error for missing directory")
+	}
+	code, report := probe()
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("missing key dir: status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	for _, name := range []string{"key_reload", "key_store"} {
+		if report.Checks[name].Status != health.StatusFail {
+			t.Errorf("check %s = %+v, want fail", name, report.Checks[name])
+		}
+	}
+	if report.Checks["keys"].Status != health.StatusOK {
+		t.Errorf("keys check = %+v, want ok", report.Checks["keys"])
+	}
+
+	checker.SetDraining()
+	if _, report := probe(); report.Checks["draining"].Status != health.StatusFail {
+		t.Errorf("draining check = %+v, want fail", report.Checks["draining"])
+	}
+}
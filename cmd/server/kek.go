@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/hsm"
+)
+
+// Exit codes of the kek subcommand
+const (
+	kekExitOK     = 0
+	kekExitFailed = 1
+	kekExitUsage  = 2
+)
+
+const kekUsage = `Usage:
+  hls-key-server kek wrap [--config file] [--out file] [key-file]
+`
+
+// runKEK dispatches the kek subcommands and returns the process exit code
+func runKEK(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, kekUsage)
+		return kekExitUsage
+	}
+
+	var err error
+	code := kekExitOK
+	switch args[0] {
+	case "wrap":
+		code, err = kekWrap(args[1:], stdin, stdout)
+	default:
+		code, err = kekExitUsage, fmt.Errorf("unknown kek command %q", args[0])
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "kek %s: %v\n", args[0], err)
+		if errors.Is(err, pflag.ErrHelp) {
+			return kekExitOK
+		}
+		if code == kekExitUsage {
+			fmt.Fprint(stderr, kekUsage)
+		}
+		return code
+	}
+	return code
+}
+
+// kekWrap wraps a plaintext content key, read from key-file or stdin, with
+// the HSM KEK in keys.kek and writes the result for the key store
+func kekWrap(args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	fs := pflag.NewFlagSet("kek wrap", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.StringP("config", "c", "", "Path to configuration file (default: config.yaml in ./config or .)")
+	out := fs.StringP("out", "o", "", "Write the wrapped key to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return kekExitUsage, err
+	}
+	if fs.NArg() > 1 {
+		return kekExitUsage, errors.New("at most one key file")
+	}
+
+	path, err := configs.FindFile(*file)
+	if err != nil {
+		return kekExitUsage, err
+	}
+	cfg, err := configs.Load(configs.Sources{File: path, Env: os.LookupEnv})
+	if err != nil {
+		return kekExitUsage, err
+	}
+	if cfg.Keys.KEK.Provider != "pkcs11" {
+		return kekExitUsage, errors.New("keys.kek.provider is not pkcs11")
+	}
+
+	var key []byte
+	if fs.NArg() == 1 {
+		key, err = os.ReadFile(fs.Arg(0))
+	} else {
+		key, err = io.ReadAll(stdin)
+	}
+	if err != nil {
+		return kekExitFailed, fmt.Errorf("read key: %w", err)
+	}
+	if len(key) == 0 {
+		return kekExitFailed, errors.New("key is empty")
+	}
+
+	kek, err := hsm.OpenPKCS11(cfg.Keys.KEK.PKCS11, zap.NewNop())
+	if err != nil {
+		return kekExitFailed, err
+	}
+	defer kek.Close()
+
+	wrapped, err := kek.Wrap(context.Background(), key)
+	if err != nil {
+		return kekExitFailed, err
+	}
+	if *out != "" {
+		// Same mode as keys written through the admin API
+		if err := os.WriteFile(*out, wrapped, 0o600); err != nil {
+			return kekExitFailed, err
+		}
+		return kekExitOK, nil
+	}
+	_, err = stdout.Write(wrapped)
+	if err != nil {
+		return kekExitFailed, err
+	}
+	return kekExitOK, nil
+}
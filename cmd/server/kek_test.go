@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunKEK_Usage(t *testing.T) {
+	dir := t.TempDir()
+	noKEK := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(noKEK, []byte("keys:\n  dir: ./keys\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "no command", args: nil, wantCode: kekExitUsage},
+		{name: "unknown command", args: []string{"unwrap"}, wantCode: kekExitUsage, wantErr: `unknown kek command "unwrap"`},
+		{name: "no provider", args: []string{"wrap", "--config", noKEK}, wantCode: kekExitUsage, wantErr: "keys.kek.provider is not pkcs11"},
+		{name: "two files", args: []string{"wrap", "--config", noKEK, "a.key", "b.key"}, wantCode: kekExitUsage, wantErr: "at most one key file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runKEK(tt.args, strings.NewReader(""), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("exit = %d, want %d; stderr = %s", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) || !strings.Contains(stderr.String(), "Usage:") {
+				t.Errorf("stderr = %q, want %q and usage", stderr.String(), tt.wantErr)
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("stdout = %q, want empty", stdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/hsm"
+	"hls-key-server-go/internal/pkg/tlsutil"
+	"hls-key-server-go/internal/repository"
+	"hls-key-server-go/internal/service"
+)
+
+// startVaultKeys creates the Vault key repository and keeps its token
+// renewed until lc stops
+func startVaultKeys(cfg configs.KeyStoreVault, lc *lifecycle, logger *zap.Logger) (*repository.VaultKeyRepository, error) {
+	tlsConfig, err := tlsutil.ClientConfig(cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("vault tls: %w", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	repo, err := repository.NewVaultKeyRepository(cfg,
+		repository.WithVaultLogger(logger),
+		repository.WithVaultHTTPClient(&http.Client{Transport: transport, Timeout: cfg.Timeout}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("init key repository: %w", err)
+	}
+	lc.Go("vault-token", repo.Run)
+
+	logger.Info("loading keys from vault",
+		zap.String("address", cfg.Address),
+		zap.String("path", cfg.Mount+"/"+cfg.Path),
+		zap.Bool("transit", cfg.Transit.Key != ""),
+	)
+	return repo, nil
+}
+
+// startKEK opens the HSM holding the key-encryption key and serves the keys
+// of store unwrapped with it. The HSM session is closed when lc stops.
+func startKEK(cfg configs.KeyStoreKEK, store repository.KeyRepository, lc *lifecycle, logger *zap.Logger) (*repository.WrappedKeyRepository, *hsm.PKCS11, error) {
+	kek, err := hsm.OpenPKCS11(cfg.PKCS11, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open hsm: %w", err)
+	}
+	lc.OnStop("hsm", func(context.Context) error {
+		return kek.Close()
+	})
+
+	opts := []repository.WrappedKeyOption{repository.WithWrappedLogger(logger)}
+	if cfg.Unwrap == "get" {
+		opts = append(opts, repository.WithUnwrapOnGet())
+	}
+	repo, err := repository.NewWrappedKeyRepository(store, kek, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init key repository: %w", err)
+	}
+
+	logger.Info("content keys wrapped under hsm kek",
+		zap.String("module", cfg.PKCS11.Module),
+		zap.String("key_label", cfg.PKCS11.KeyLabel),
+		zap.String("unwrap", cfg.Unwrap),
+	)
+	return repo, kek, nil
+}
+
+// refreshKeys reloads keys every interval until ctx is done, picking up keys
+// added to the backing store without a SIGHUP. Failures keep the loaded keys
+// and fail readiness until a reload succeeds.
+func refreshKeys(ctx context.Context, hlsService *service.HLSService, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := hlsService.ReloadKeys(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("periodic key reload failed", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lifecycle starts background workers and stops them, along with other
+// registered shutdown hooks, in reverse registration order
+type lifecycle struct {
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	hooks []stopHook
+	once  sync.Once
+	err   error
+}
+
+type stopHook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+func newLifecycle(logger *zap.Logger) *lifecycle {
+	return &lifecycle{logger: logger}
+}
+
+// Go runs a worker until it is stopped; stopping cancels its context and
+// waits for it to return
+func (l *lifecycle) Go(name string, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(ctx)
+	}()
+
+	l.OnStop(name, func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return fmt.Errorf("worker did not exit: %w", stopCtx.Err())
+		}
+	})
+}
+
+// OnStop registers a hook run during Stop
+func (l *lifecycle) OnStop(name string, stop func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, stopHook{name: name, stop: stop})
+}
+
+// Stop runs every hook once, newest first, even if earlier ones fail.
+// Later calls return the first call's result.
+func (l *lifecycle) Stop(ctx context.Context) error {
+	l.once.Do(func() {
+		l.mu.Lock()
+		hooks := append([]stopHook(nil), l.hooks...)
+		l.mu.Unlock()
+
+		var errs []error
+		for i := len(hooks) - 1; i >= 0; i-- {
+			h := hooks[i]
+			start := time.Now()
+			if err := h.stop(ctx); err != nil {
+				l.logger.Error("failed to stop component", zap.String("component", h.name), zap.Error(err))
+				errs = append(errs, fmt.Errorf("stop %s: %w", h.name, err))
+				continue
+			}
+			l.logger.Debug("component stopped",
+				zap.String("component", h.name),
+				zap.Duration("took", time.Since(start)),
+			)
+		}
+		l.err = errors.Join(errs...)
+	})
+	return l.err
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/health"
+)
+
+func TestLifecycle_StopOrder(t *testing.T) {
+	lc := newLifecycle(zap.NewNop())
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+
+	lc.OnStop("tracing", func(context.Context) error {
+		record("tracing")
+		return nil
+	})
+	lc.OnStop("audit", func(context.Context) error {
+		record("audit")
+		return errors.New("flush failed")
+	})
+	lc.Go("sweeper", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		record("sweeper")
+	})
+
+	err := lc.Stop(context.Background())
+	if err == nil {
+		t.Fatal("Stop() expected the audit error")
+	}
+	if want := []string{"sweeper", "audit", "tracing"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("stop order = %v, want %v", order, want)
+	}
+	if again := lc.Stop(context.Background()); again != err {
+		t.Errorf("second Stop() = %v, want %v", again, err)
+	}
+	if len(order) != 3 {
+		t.Errorf("hooks ran again on second Stop(): %v", order)
+	}
+}
+
+func TestLifecycle_StuckWorker(t *testing.T) {
+	lc := newLifecycle(zap.NewNop())
+	release := make(chan struct{})
+	defer close(release)
+	lc.Go("stuck", func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := lc.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestShutdown_DrainsInFlightRequests(t *testing.T) {
+	checker := health.New(time.Second)
+	checker.MarkStarted()
+
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "key")
+	})}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		inFlight <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	lc := newLifecycle(zap.NewNop())
+	workerStopped := make(chan struct{})
+	lc.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+
+	cfg := configs.Shutdown{PreStopDelay: 30 * time.Millisecond, DrainTimeout: time.Second, StopTimeout: time.Second}
+	done := make(chan error, 1)
+	go func() { done <- shutdown(cfg, make(chan os.Signal), checker, server, nil, lc, zap.NewNop()) }()
+
+	// Not ready while still serving during the pre-stop delay
+	time.Sleep(10 * time.Millisecond)
+	if checker.Ready(context.Background()).OK() {
+		t.Error("Ready() during pre-stop delay, want draining")
+	}
+	select {
+	case <-workerStopped:
+		t.Error("worker stopped before requests drained")
+	default:
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+	if r := <-inFlight; r.err != nil || r.body != "key" {
+		t.Errorf("in-flight request = %q, %v", r.body, r.err)
+	}
+	select {
+	case <-workerStopped:
+	default:
+		t.Error("worker not stopped after shutdown")
+	}
+}
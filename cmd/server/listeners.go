@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+
+	"hls-key-server-go/internal/configs"
+)
+
+// newHTTPServer creates the TCP listener serving HTTP/1.1, HTTP/2 over TLS
+// and, when enabled, HTTP/2 cleartext
+func newHTTPServer(cfg *configs.Config, handler http.Handler) *http.Server {
+	l := cfg.Listener
+	server := &http.Server{
+		Addr:              ":" + cfg.App.Port,
+		Handler:           handler,
+		ReadTimeout:       l.ReadTimeout,
+		ReadHeaderTimeout: l.ReadHeaderTimeout,
+		WriteTimeout:      l.WriteTimeout,
+		IdleTimeout:       l.IdleTimeout,
+		MaxHeaderBytes:    l.MaxHeaderBytes,
+	}
+
+	if l.H2C {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		server.Protocols = protocols
+	}
+
+	return server
+}
+
+// newHTTP3Server creates the QUIC listener. HTTP/3 always runs over TLS,
+// so tlsConfig must be the config of the TCP listener.
+func newHTTP3Server(cfg *configs.Config, handler http.Handler, tlsConfig *tls.Config) (*http3.Server, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("http3 requires tls.enable")
+	}
+
+	l := cfg.Listener
+	port := l.HTTP3.Port
+	if port == "" {
+		port = cfg.App.Port
+	}
+
+	return &http3.Server{
+		Addr:           ":" + port,
+		Handler:        withDeadlines(handler, l.ReadTimeout, l.WriteTimeout),
+		TLSConfig:      tlsConfig,
+		MaxHeaderBytes: l.MaxHeaderBytes,
+		IdleTimeout:    l.IdleTimeout,
+		QUICConfig: &quic.Config{
+			MaxIdleTimeout: l.IdleTimeout,
+		},
+	}, nil
+}
+
+// withDeadlines applies per-request read/write deadlines for servers that,
+// unlike net/http, have no listener-wide timeouts
+func withDeadlines(next http.Handler, read, write time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		now := time.Now()
+		if read > 0 {
+			_ = rc.SetReadDeadline(now.Add(read))
+		}
+		if write > 0 {
+			_ = rc.SetWriteDeadline(now.Add(write))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withAltSvc advertises the HTTP/3 endpoint on TCP responses
+func withAltSvc(next http.Handler, port string) http.Handler {
+	value := `h3=":` + port + `"; ma=` + strconv.Itoa(int((24 * time.Hour).Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", value)
+		next.ServeHTTP(w, r)
+	})
+}
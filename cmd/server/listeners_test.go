@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"hls-key-server-go/internal/configs"
+)
+
+func TestNewHTTPServer_H2C(t *testing.T) {
+	cfg := &configs.Config{
+		App: configs.AppConf{Port: "0"},
+		Listener: configs.Listener{
+			ReadTimeout:    5 * time.Second,
+			WriteTimeout:   5 * time.Second,
+			IdleTimeout:    30 * time.Second,
+			MaxHeaderBytes: 8 << 10,
+			H2C:            true,
+		},
+	}
+
+	server := newHTTPServer(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	if server.ReadTimeout != 5*time.Second || server.IdleTimeout != 30*time.Second || server.MaxHeaderBytes != 8<<10 {
+		t.Fatalf("listener settings not applied: %+v", server)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(ln) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	resp, err := client.Get("http://" + ln.Addr().String() + "/")
+	if err != nil {
+		t.Fatalf("h2c request error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("proto = %s, want HTTP/2", resp.Proto)
+	}
+}
+
+func TestNewHTTP3Server(t *testing.T) {
+	cfg := &configs.Config{
+		App:      configs.AppConf{Port: "8443"},
+		Listener: configs.Listener{HTTP3: configs.HTTP3{Enable: true}},
+	}
+
+	if _, err := newHTTP3Server(cfg, http.NotFoundHandler(), nil); err == nil {
+		t.Error("expected error without tls config")
+	}
+
+	server, err := newHTTP3Server(cfg, http.NotFoundHandler(), &tls.Config{MinVersion: tls.VersionTLS13})
+	if err != nil {
+		t.Fatalf("newHTTP3Server() error = %v", err)
+	}
+	if server.Addr != ":8443" {
+		t.Errorf("Addr = %q, want :8443 (app.port fallback)", server.Addr)
+	}
+}
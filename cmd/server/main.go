@@ -25,14 +25,21 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
@@ -41,58 +48,263 @@ import (
 	"hls-key-server-go/internal/configs"
 	"hls-key-server-go/internal/handler"
 	"hls-key-server-go/internal/handler/middleware"
+	"hls-key-server-go/internal/pkg/audit"
+	"hls-key-server-go/internal/pkg/health"
+	"hls-key-server-go/internal/pkg/hsm"
+	"hls-key-server-go/internal/pkg/keysync"
+	applogger "hls-key-server-go/internal/pkg/logger"
 	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/tlsutil"
+	"hls-key-server-go/internal/pkg/tracing"
 	"hls-key-server-go/internal/repository"
+	"hls-key-server-go/internal/routes"
 	v1 "hls-key-server-go/internal/routes/api/v1"
 	"hls-key-server-go/internal/service"
 )
 
 func main() {
+	// Subcommands run without loading the server configuration
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "audit":
+			os.Exit(runAudit(os.Args[2:], os.Stdout, os.Stderr))
+		case "config":
+			os.Exit(runConfig(os.Args[2:], os.Stdout, os.Stderr))
+		case "kek":
+			os.Exit(runKEK(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		}
+	}
+
 	if err := run(); err != nil {
 		log.Fatalf("Application failed: %v", err)
 	}
 }
 func run() error {
 	// Load configuration
-	cfg, err := configs.LoadConfig()
+	flags := newServerFlags(pflag.ExitOnError)
+	_ = flags.Parse(os.Args[1:])
+	cfg, sources, err := loadServerConfig(flags, os.LookupEnv)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
 
 	// Initialize logger
-	logger, err := initLogger(cfg.App.Mode)
+	appLogger, err := initLogger(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 	defer func() {
-		// Ignore sync errors on stdout/stderr which are expected
-		_ = logger.Sync()
+		_ = appLogger.Close()
 	}()
+	logger := appLogger.Logger
+	zap.RedirectStdLog(logger)
+	logger.Info("config loaded", zap.String("file", sources.File))
+
+	// Weak values are rejected in production; elsewhere they are only logged
+	_, warnings := cfg.Check()
+	for _, w := range warnings {
+		logger.Warn("weak configuration value", zap.String("field", w.Path), zap.String("problem", w.Message))
+	}
 
 	// Initialize metrics
 	metrics.Init(cfg.App.Version, cfg.App.Mode)
 
-	// Initialize repository
-	keyRepo, err := repository.NewFileKeyRepository("./keys")
+	// Workers and flushers stop in reverse registration order; this also
+	// runs when startup fails part way
+	lc := newLifecycle(logger)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.StopTimeout)
+		defer cancel()
+		_ = lc.Stop(ctx)
+	}()
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.App.Version)
 	if err != nil {
-		return fmt.Errorf("init key repository: %w", err)
+		return fmt.Errorf("init tracing: %w", err)
+	}
+	lc.OnStop("tracing", shutdownTracing)
+
+	// Initialize audit log
+	var auditor *audit.Logger
+	if cfg.Audit.Enable {
+		var sinkOpts []audit.FileSinkOption
+		if cfg.Audit.SigningKeyFile != "" {
+			signer, err := audit.LoadPrivateKey(cfg.Audit.SigningKeyFile)
+			if err != nil {
+				return fmt.Errorf("load audit signing key: %w", err)
+			}
+			sinkOpts = append(sinkOpts, audit.WithCheckpoints(signer, cfg.Audit.CheckpointEvery))
+		}
+		sink, err := audit.NewFileSink(cfg.Audit.File, int64(cfg.Audit.MaxSizeMB)<<20, cfg.Audit.MaxBackups, sinkOpts...)
+		if err != nil {
+			return fmt.Errorf("init audit log: %w", err)
+		}
+		auditor = audit.New(logger, sink)
+		lc.OnStop("audit", func(context.Context) error {
+			return auditor.Close()
+		})
+		logger.Info("audit log enabled", zap.String("file", cfg.Audit.File))
+	}
+
+	// Origin and replicas share sync.secret for pull-based key sync
+	var syncCodec *keysync.Codec
+	if cfg.Sync.Export || cfg.Sync.Replica() {
+		syncCodec, err = keysync.NewCodec(cfg.Sync.Secret)
+		if err != nil {
+			return fmt.Errorf("init key sync: %w", err)
+		}
+	}
+
+	// Initialize repository
+	var keyRepo repository.KeyRepository
+	adminOpts := []handler.AdminHandlerOption{handler.WithLogLevels(appLogger.Levels)}
+	if cfg.Sync.Replica() {
+		// Replicas do not own keys, so the admin API cannot write them
+		keyRepo, err = startReplica(cfg.Sync, syncCodec, lc, appLogger.Component("sync"))
+		if err != nil {
+			return err
+		}
+	} else if cfg.Keys.Backend == "s3" {
+		// The bucket and Vault are written by packagers, not through the
+		// admin API
+		keyRepo, err = repository.NewS3KeyRepository(cfg.Keys.S3,
+			repository.WithS3Logger(appLogger.Component("repository")),
+		)
+		if err != nil {
+			return fmt.Errorf("init key repository: %w", err)
+		}
+	} else if cfg.Keys.Backend == "vault" {
+		keyRepo, err = startVaultKeys(cfg.Keys.Vault, lc, appLogger.Component("repository"))
+		if err != nil {
+			return err
+		}
+	} else {
+		fileOpts := []repository.FileKeyOption{repository.WithKeyLogger(appLogger.Component("repository"))}
+		if cfg.Keys.Cache.Lazy() {
+			fileOpts = append(fileOpts, repository.WithLazyLoading(cfg.Keys.Cache.Size, cfg.Keys.Cache.TTL))
+			logger.Info("key files read on demand",
+				zap.Int("cache_size", cfg.Keys.Cache.Size),
+				zap.Duration("cache_ttl", cfg.Keys.Cache.TTL),
+			)
+		}
+		fileRepo, err := repository.NewFileKeyRepository(cfg.Keys.Dir, fileOpts...)
+		if err != nil {
+			return fmt.Errorf("init key repository: %w", err)
+		}
+		keyRepo = fileRepo
+		var keyWriter repository.KeyWriter = fileRepo
+
+		// In cluster mode writes go through the Raft log; reads stay local
+		if cfg.Cluster.Enable {
+			cluster, err := startCluster(cfg.Cluster, fileRepo, lc, appLogger.Component("cluster"))
+			if err != nil {
+				return err
+			}
+			keyRepo, keyWriter = cluster, cluster
+			adminOpts = append(adminOpts, handler.WithCluster(cluster))
+		}
+		// Wrapped keys are provisioned with the kek subcommand, not
+		// written as plaintext through the admin API
+		if cfg.Keys.KEK.Provider == "" {
+			adminOpts = append(adminOpts, handler.WithKeyWriter(keyWriter))
+		}
+	}
+
+	var kek *hsm.PKCS11
+	if cfg.Keys.KEK.Provider == "pkcs11" {
+		keyRepo, kek, err = startKEK(cfg.Keys.KEK, keyRepo, lc, appLogger.Component("repository"))
+		if err != nil {
+			return err
+		}
 	}
 
 	logger.Info("keys loaded",
 		zap.Int("count", len(keyRepo.List(context.Background()))),
 	)
 
+	// Component loggers can be raised to debug at runtime via the admin API
+	hlsLogger := appLogger.Component("hls")
+	authLogger := appLogger.Component("auth")
+
 	// Initialize services
-	hlsService := service.NewHLSService(keyRepo, logger)
-	authService := service.NewAuthService(&cfg.JwtSecret, logger)
+	var hlsOpts []service.HLSOption
+	if cfg.Session.Enable {
+		if !cfg.JwtSecret.Enable {
+			logger.Warn("session limits require jwt.enable; key fetches carry no principal")
+		}
+		sessionService := service.NewSessionService(repository.NewMemorySessionStore(), cfg.Session, hlsLogger)
+		hlsOpts = append(hlsOpts, service.WithSessionLimits(sessionService))
+		lc.Go("session-sweeper", func(ctx context.Context) {
+			sessionService.Run(ctx, time.Minute)
+		})
+	}
+	// Settings swapped on config reload are read through snapshots
+	jwtConfig := configs.NewSnapshot(cfg.JwtSecret)
+	metricConfig := configs.NewSnapshot(cfg.Metric)
+	adminConfig := configs.NewSnapshot(cfg.Admin)
+
+	hlsService := service.NewHLSService(keyRepo, hlsLogger, hlsOpts...)
+	// Replicas pull on their own schedule
+	if cfg.Keys.RefreshInterval > 0 && !cfg.Sync.Replica() {
+		lc.Go("key-refresh", func(ctx context.Context) {
+			refreshKeys(ctx, hlsService, cfg.Keys.RefreshInterval, hlsLogger)
+		})
+	}
+	authService := service.NewAuthService(jwtConfig, authLogger, service.WithSessionRenewal(cfg.Session.IdleTimeout))
+	lockoutService := service.NewLockoutService(cfg.Lockout, authLogger)
 
 	// Initialize handlers
-	hlsHandler := handler.NewHLSHandler(hlsService, logger)
-	authHandler := handler.NewAuthHandler(authService, &cfg.JwtSecret, logger)
-	metricsHandler := handler.NewMetricsHandler(cfg, logger)
+	hlsHandler := handler.NewHLSHandler(hlsService, auditor, hlsLogger)
+	authHandler := handler.NewAuthHandler(authService, lockoutService, auditor, jwtConfig, authLogger)
+	metricsHandler := handler.NewMetricsHandler(metricConfig, logger)
+	healthChecker := buildHealthChecker(hlsService, keyRepo)
+	if kek != nil {
+		healthChecker.AddReadiness("hsm", kek.HealthCheck)
+	}
+	healthHandler := handler.NewHealthHandler(healthChecker, logger)
+	adminHandler := handler.NewAdminHandler(adminConfig, lockoutService, auditor, logger, adminOpts...)
+	var syncHandler *handler.SyncHandler
+	if cfg.Sync.Export {
+		syncLogger := appLogger.Component("sync")
+		syncHandler = handler.NewSyncHandler(service.NewExportService(keyRepo, syncLogger), syncCodec, auditor, syncLogger)
+		logger.Info("key export enabled", zap.String("path", repository.ExportPath))
+	}
+
+	// CORS, hotlink, rate limit and IP policies are rebuilt on reload
+	policies, err := newPolicies(cfg, hlsHandler, logger)
+	if err != nil {
+		return fmt.Errorf("build policies: %w", err)
+	}
+	if cfg.IPPolicy.Enable {
+		logger.Info("ip policy enabled", zap.Int("rules", len(cfg.IPPolicy.Rules)))
+	}
+	reloader := &configReloader{
+		sources:  sources,
+		jwt:      jwtConfig,
+		metric:   metricConfig,
+		admin:    adminConfig,
+		policies: policies,
+		levels:   appLogger.Levels,
+		auditor:  auditor,
+		logger:   logger,
+		current:  cfg,
+	}
+	if cfg.Reload.Watch {
+		watcher, err := newConfigWatcher(sources.File)
+		if err != nil {
+			return err
+		}
+		lc.Go("config-watcher", func(ctx context.Context) {
+			watchConfig(ctx, watcher, sources.File, cfg.Reload.Debounce, func() {
+				_ = reloader.Reload(ctx, "watch")
+			}, logger)
+		})
+		logger.Info("watching config file", zap.String("file", sources.File))
+	}
 
 	// Generate test token for development
-	if cfg.App.Mode != "production" {
+	if cfg.IsDevelopment() {
 		token, err := authService.GenerateToken(context.Background(), "test-user")
 		if err != nil {
 			logger.Error("Failed to generate test token", zap.Error(err))
@@ -109,28 +321,78 @@ func run() error {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	// Build per-route middleware chains (JWT auth, rate limiting)
+	routeMiddlewares, err := buildRouteMiddlewares(cfg, authService, auditor, policies, logger)
+	if err != nil {
+		return fmt.Errorf("build route middlewares: %w", err)
+	}
+
 	// Create router using new architecture
-	router := setupRouter(cfg, hlsHandler, authHandler, metricsHandler)
+	router, err := setupRouter(cfg, jwtConfig, logger, policies, hlsHandler, authHandler, metricsHandler, adminHandler, syncHandler, healthHandler, routeMiddlewares)
+	if err != nil {
+		return fmt.Errorf("setup router: %w", err)
+	}
+
+	// Create HTTP listeners
+	server := newHTTPServer(cfg, router)
 
-	// Create HTTP server
-	serverAddr := ":" + cfg.App.Port
-	server := &http.Server{
-		Addr:              serverAddr,
-		Handler:           router,
-		ReadTimeout:       15 * time.Second,
-		ReadHeaderTimeout: 10 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		IdleTimeout:       60 * time.Second,
+	if cfg.TLS.Enable {
+		reloader, err := tlsutil.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, logger)
+		if err != nil {
+			return fmt.Errorf("load tls certificate: %w", err)
+		}
+		tlsConfig, err := tlsutil.ServerConfig(cfg.TLS, reloader)
+		if err != nil {
+			return fmt.Errorf("build tls config: %w", err)
+		}
+		server.TLSConfig = tlsConfig
+		lc.Go("tls-reloader", func(ctx context.Context) {
+			reloader.Run(ctx, cfg.TLS.ReloadInterval)
+		})
 	}
 
-	// Start server in goroutine
+	var h3Server *http3.Server
+	if cfg.Listener.HTTP3.Enable {
+		h3Server, err = newHTTP3Server(cfg, router, server.TLSConfig)
+		if err != nil {
+			return fmt.Errorf("init http3 listener: %w", err)
+		}
+		if cfg.Listener.HTTP3.AdvertiseAltSvc {
+			_, port, _ := net.SplitHostPort(h3Server.Addr)
+			server.Handler = withAltSvc(server.Handler, port)
+		}
+	}
+
+	// Start listeners in goroutines
 	go func() {
-		logger.Info("server starting", zap.String("addr", serverAddr))
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Info("server starting",
+			zap.String("addr", server.Addr),
+			zap.Bool("tls", cfg.TLS.Enable),
+			zap.Bool("h2c", cfg.Listener.H2C),
+		)
+		var err error
+		if cfg.TLS.Enable {
+			// Certificates come from TLSConfig.GetCertificate
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatal("server listen error", zap.Error(err))
 		}
 	}()
 
+	if h3Server != nil {
+		go func() {
+			logger.Info("http3 server starting", zap.String("addr", h3Server.Addr))
+			if err := h3Server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("http3 listen error", zap.Error(err))
+			}
+		}()
+	}
+
+	healthChecker.MarkStarted()
+
 	// Setup signal handling
 	quit := make(chan os.Signal, 1)
 	reload := make(chan os.Signal, 1)
@@ -141,73 +403,175 @@ func run() error {
 	for {
 		select {
 		case <-reload:
-			// Graceful reload: reload keys without stopping server
+			// Graceful reload: reload keys and config without stopping server
 			logger.Info("received SIGHUP, reloading keys...")
-			if err := hlsService.ReloadKeys(context.Background()); err != nil {
+			event := audit.Event{Type: audit.TypeKeyReload, Details: map[string]string{"trigger": "SIGHUP"}}
+			if unreadable, err := hlsService.ReloadKeys(context.Background()); err != nil {
 				logger.Error("failed to reload keys", zap.Error(err))
+				event.Outcome = audit.OutcomeFailure
+				event.Reason = err.Error()
 			} else {
-				logger.Info("keys reloaded successfully",
-					zap.Int("count", len(keyRepo.List(context.Background()))),
-				)
+				count := len(keyRepo.List(context.Background()))
+				logger.Info("keys reloaded successfully", zap.Int("count", count), zap.Int("unreadable", unreadable))
+				event.Outcome = audit.OutcomeSuccess
+				event.Details["count"] = strconv.Itoa(count)
+				event.Details["unreadable"] = strconv.Itoa(unreadable)
 			}
+			auditor.Record(context.Background(), event)
+
+			// Rejected reloads are logged and audited; the server keeps the
+			// settings it has
+			logger.Info("reloading config", zap.String("file", sources.File))
+			_ = reloader.Reload(context.Background(), "SIGHUP")
 		case <-quit:
-			// Graceful shutdown
-			logger.Info("shutting down server...")
+			return shutdown(cfg.Shutdown, quit, healthChecker, server, h3Server, lc, logger)
+		}
+	}
+}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
+// shutdown drains the server in phases: readiness fails first, listeners
+// keep serving for the pre-stop delay (cut short by a second signal), then
+// stop accepting and drain in-flight requests, and finally workers stop
+func shutdown(cfg configs.Shutdown, quit <-chan os.Signal, checker *health.Checker, server *http.Server, h3Server *http3.Server, lc *lifecycle, logger *zap.Logger) error {
+	logger.Info("shutting down server...",
+		zap.Duration("pre_stop_delay", cfg.PreStopDelay),
+		zap.Duration("drain_timeout", cfg.DrainTimeout),
+	)
+	checker.SetDraining()
 
-			if err := server.Shutdown(ctx); err != nil {
-				return fmt.Errorf("server forced to shutdown: %w", err)
+	if cfg.PreStopDelay > 0 {
+		timer := time.NewTimer(cfg.PreStopDelay)
+		select {
+		case <-timer.C:
+		case <-quit:
+			timer.Stop()
+			logger.Warn("second signal received, skipping pre-stop delay")
+		}
+	}
+
+	logger.Info("closing listeners and draining requests")
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer cancelDrain()
+
+	var wg sync.WaitGroup
+	if h3Server != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h3Server.Shutdown(drainCtx); err != nil {
+				logger.Warn("http3 server forced to shutdown", zap.Error(err))
+				_ = h3Server.Close()
 			}
+		}()
+	}
+	drainErr := server.Shutdown(drainCtx)
+	if drainErr != nil {
+		logger.Warn("drain timeout exceeded, closing remaining connections", zap.Error(drainErr))
+		_ = server.Close()
+	}
+	wg.Wait()
 
-			logger.Info("server exited")
-			return nil
-		}
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), cfg.StopTimeout)
+	defer cancelStop()
+	stopErr := lc.Stop(stopCtx)
+
+	if drainErr != nil {
+		return fmt.Errorf("server forced to shutdown: %w", drainErr)
 	}
+	if stopErr != nil {
+		return stopErr
+	}
+	logger.Info("server exited")
+	return nil
 }
 
-func initLogger(mode string) (*zap.Logger, error) {
-	var logger *zap.Logger
-	var err error
-
-	if strings.EqualFold(mode, "production") {
-		logger, err = zap.NewProduction()
-	} else {
-		config := zap.NewDevelopmentConfig()
-		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-		logger, err = config.Build()
+// initLogger builds the application logger from cfg.Log; level and encoding
+// default to debug/console, or info/json in production mode
+func initLogger(cfg *configs.Config) (*applogger.Logger, error) {
+	level, err := rootLogLevel(cfg)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "parse log level")
+	}
+	logCfg := applogger.Config{
+		Level:      level,
+		Encoding:   "console",
+		OutputPath: cfg.Log.Output,
+		MaxSizeMB:  cfg.Log.MaxSizeMB,
+		MaxAgeDays: cfg.Log.MaxAgeDays,
+		MaxBackups: cfg.Log.MaxBackups,
+		Compress:   cfg.Log.Compress,
+	}
+	if strings.EqualFold(cfg.App.Mode, "production") {
+		logCfg.Encoding = "json"
+	}
+	if cfg.Log.Encoding != "" {
+		logCfg.Encoding = cfg.Log.Encoding
+	}
+	if cfg.Log.Output == "file" {
+		logCfg.OutputPath = filepath.Join(cfg.App.LogPath, cfg.App.LogFile)
+	}
+	if cfg.Log.Sampling.Enable {
+		logCfg.Sampling = &zap.SamplingConfig{
+			Initial:    cfg.Log.Sampling.Initial,
+			Thereafter: cfg.Log.Sampling.Thereafter,
+		}
 	}
 
+	logger, err := applogger.New(logCfg)
 	if err != nil {
 		return nil, apperrors.Wrap(err, "create logger")
 	}
-
 	return logger, nil
 }
 
 // setupRouter creates and configures the Gin router with new handlers
-func setupRouter(cfg *configs.Config, hlsHandler *handler.HLSHandler, authHandler *handler.AuthHandler, metricsHandler *handler.MetricsHandler) *gin.Engine {
+func setupRouter(cfg *configs.Config, jwtConfig *configs.Snapshot[configs.JwtSecret], logger *zap.Logger, policies *policies, hlsHandler *handler.HLSHandler, authHandler *handler.AuthHandler, metricsHandler *handler.MetricsHandler, adminHandler *handler.AdminHandler, syncHandler *handler.SyncHandler, healthHandler *handler.HealthHandler, routeMiddlewares v1.RouteMiddlewares) (*gin.Engine, error) {
 	// Create Gin instance
 	router := gin.New()
 
+	// Only honor forwarding headers from configured proxies
+	if err := router.SetTrustedProxies(cfg.Network.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("set trusted proxies: %w", err)
+	}
+	if len(cfg.Network.RemoteIPHeaders) > 0 {
+		router.RemoteIPHeaders = cfg.Network.RemoteIPHeaders
+	}
+
 	// Setup middleware
-	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
+	if cfg.Tracing.Enable {
+		untraced := map[string]bool{"/api/v1/metrics": true}
+		for _, path := range probePaths {
+			untraced[path] = true
+		}
+		router.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+			return !untraced[r.URL.Path]
+		})))
+	}
+	router.Use(middleware.RequestID())
+	router.Use(middleware.GinzapWithConfig(logger, &middleware.Config{
+		SkipPaths:    cfg.Log.SkipPaths,
+		DefaultLevel: zapcore.InfoLevel,
+		Category:     "access",
+	}))
+	router.Use(middleware.RecoveryWithZap(logger, true, func() []string {
+		return []string{jwtConfig.Load().HeaderKey}
+	}))
 	router.Use(middleware.PrometheusMiddleware())
+	router.Use(policies.cors.Handler())
 	router.Use(middleware.Timeout(30 * time.Second)) // Add request timeout
 
 	// API v1 routes
 	v1Group := router.Group("/api/v1")
-	routeGroups := v1.GetRouteGroups(hlsHandler, authHandler, metricsHandler)
+	routeGroups := v1.GetRouteGroups(hlsHandler, authHandler, metricsHandler, adminHandler, syncHandler, routeMiddlewares)
 	for _, routeGroup := range routeGroups {
 		routeGroup.RegisterRoutes(v1Group)
 	}
 
-	// Health check
-	router.GET("/healthz", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	// Health checks and probes
+	router.GET("/healthz", routes.HealthCheck)
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/startupz", healthHandler.Startupz)
 
-	return router
+	return router, nil
 }
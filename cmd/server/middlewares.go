@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/handler"
+	"hls-key-server-go/internal/handler/middleware"
+	"hls-key-server-go/internal/pkg/audit"
+	"hls-key-server-go/internal/pkg/certauth"
+	"hls-key-server-go/internal/pkg/ippolicy"
+	"hls-key-server-go/internal/pkg/ratelimit"
+	v1 "hls-key-server-go/internal/routes/api/v1"
+	"hls-key-server-go/internal/service"
+)
+
+// buildRouteMiddlewares assembles JWT authentication and rate limiting chains from config.
+// Hotlink and rate limit checks go through the reloadable slots in p.
+func buildRouteMiddlewares(cfg *configs.Config, authService *service.AuthService, auditor *audit.Logger, p *policies, logger *zap.Logger) (v1.RouteMiddlewares, error) {
+	var mw v1.RouteMiddlewares
+
+	// Audit requests rejected by any middleware below
+	if auditor != nil {
+		mw.Auth = append(mw.Auth, middleware.AuditDenials(auditor))
+		mw.HLS = append(mw.HLS, middleware.AuditDenials(auditor))
+	}
+
+	if cfg.TLS.Enable && len(cfg.TLS.Principals) > 0 {
+		mapper, err := certauth.New(cfg.TLS.Principals)
+		if err != nil {
+			return mw, fmt.Errorf("init client certificate principals: %w", err)
+		}
+		mw.HLS = append(mw.HLS, middleware.ClientCertAuth(mapper, logger))
+	}
+
+	if cfg.JwtSecret.Enable {
+		mw.HLS = append(mw.HLS, middleware.JWTAuth(authService))
+	}
+
+	mw.Key = append(mw.Key, p.hotlink.Handler())
+
+	if p.store != nil {
+		mw.Auth = append(mw.Auth, p.authLimit.Handler())
+		mw.Key = append(mw.Key, p.keyLimit.Handler())
+	}
+
+	return mw, nil
+}
+
+// policies holds the middleware and IP policy rebuilt on config reload.
+// The slots are registered even when a policy is disabled so a reload can
+// turn it on; only the rate limit store is fixed at startup.
+type policies struct {
+	cors      *middleware.Swappable
+	hotlink   *middleware.Swappable
+	authLimit *middleware.Swappable
+	keyLimit  *middleware.Swappable
+	hls       *handler.HLSHandler
+	// store is nil when rate limiting is disabled
+	store  ratelimit.Store
+	logger *zap.Logger
+}
+
+// policySet is one configuration's compiled policies; nil entries are disabled
+type policySet struct {
+	cors      gin.HandlerFunc
+	hotlink   gin.HandlerFunc
+	authLimit gin.HandlerFunc
+	keyLimit  gin.HandlerFunc
+	ipPolicy  *ippolicy.Engine
+}
+
+// newPolicies creates the rate limit store and applies the policies in cfg
+func newPolicies(cfg *configs.Config, hls *handler.HLSHandler, logger *zap.Logger) (*policies, error) {
+	p := &policies{
+		cors:      middleware.NewSwappable(nil),
+		hotlink:   middleware.NewSwappable(nil),
+		authLimit: middleware.NewSwappable(nil),
+		keyLimit:  middleware.NewSwappable(nil),
+		hls:       hls,
+		logger:    logger,
+	}
+
+	if cfg.RateLimit.Enable {
+		store, err := newRateLimitStore(cfg.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		p.store = store
+		logger.Info("rate limiting enabled",
+			zap.String("backend", cfg.RateLimit.Backend),
+		)
+	}
+
+	set, err := p.build(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.apply(set)
+	return p, nil
+}
+
+// build compiles every policy in cfg without applying any, so a reload with
+// one invalid policy leaves all of them unchanged
+func (p *policies) build(cfg *configs.Config) (policySet, error) {
+	var set policySet
+
+	if cfg.CORS.Enable {
+		corsConfig, err := buildCORSConfig(cfg)
+		if err != nil {
+			return set, err
+		}
+		set.cors = middleware.CORS(corsConfig)
+	}
+
+	if cfg.Hotlink.Enable {
+		hotlink, err := buildHotlinkConfig(cfg, p.logger)
+		if err != nil {
+			return set, err
+		}
+		set.hotlink = middleware.Hotlink(hotlink)
+	}
+
+	if p.store != nil {
+		var err error
+		set.authLimit, err = newRateLimitMiddleware("auth", cfg.RateLimit.Auth, p.store, p.logger)
+		if err != nil {
+			return set, err
+		}
+		set.keyLimit, err = newRateLimitMiddleware("key", cfg.RateLimit.Key, p.store, p.logger)
+		if err != nil {
+			return set, err
+		}
+	}
+
+	if cfg.IPPolicy.Enable {
+		engine, err := ippolicy.New(cfg.IPPolicy.Rules)
+		if err != nil {
+			return set, fmt.Errorf("init ip policy: %w", err)
+		}
+		set.ipPolicy = engine
+	}
+
+	return set, nil
+}
+
+// apply swaps in a compiled policy set
+func (p *policies) apply(set policySet) {
+	p.cors.Store(set.cors)
+	p.hotlink.Store(set.hotlink)
+	p.authLimit.Store(set.authLimit)
+	p.keyLimit.Store(set.keyLimit)
+	p.hls.SetIPPolicy(set.ipPolicy)
+}
+
+func newRateLimitStore(cfg configs.RateLimit) (ratelimit.Store, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return ratelimit.NewMemoryStore(), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		return ratelimit.NewRedisStore(client, cfg.Redis.Prefix), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+}
+
+func newRateLimitMiddleware(name string, policy configs.RateLimitPolicy, store ratelimit.Store, logger *zap.Logger) (gin.HandlerFunc, error) {
+	keyFunc, err := middleware.KeyFuncByName(policy.By)
+	if err != nil {
+		return nil, fmt.Errorf("%s rate limit: %w", name, err)
+	}
+
+	return middleware.RateLimit(&middleware.RateLimitConfig{
+		Name:    name,
+		Store:   store,
+		Policy:  ratelimit.Policy{Rate: policy.Rate, Burst: policy.Burst},
+		KeyFunc: keyFunc,
+		Logger:  logger,
+	}), nil
+}
+
+// buildHotlinkConfig compiles the default and per-tenant embedding domain allow-lists
+func buildHotlinkConfig(cfg *configs.Config, logger *zap.Logger) (*middleware.HotlinkConfig, error) {
+	def, err := middleware.NewOriginMatcher(cfg.Hotlink.Domains)
+	if err != nil {
+		return nil, fmt.Errorf("hotlink domains: %w", err)
+	}
+
+	tenants := make(map[string]*middleware.OriginMatcher, len(cfg.Hotlink.Tenants))
+	for _, t := range cfg.Hotlink.Tenants {
+		m, err := middleware.NewOriginMatcher(t.Domains)
+		if err != nil {
+			return nil, fmt.Errorf("hotlink domains for tenant %s: %w", t.Tenant, err)
+		}
+		tenants[t.Tenant] = m
+	}
+
+	return &middleware.HotlinkConfig{
+		Default:    def,
+		Tenants:    tenants,
+		AllowEmpty: cfg.Hotlink.AllowEmpty,
+		Logger:     logger,
+	}, nil
+}
+
+// buildCORSConfig derives the CORS policy from the union of all hotlink allow-lists
+func buildCORSConfig(cfg *configs.Config) (*middleware.CORSConfig, error) {
+	domains := append([]string{}, cfg.Hotlink.Domains...)
+	for _, t := range cfg.Hotlink.Tenants {
+		domains = append(domains, t.Domains...)
+	}
+
+	origins, err := middleware.NewOriginMatcher(domains)
+	if err != nil {
+		return nil, fmt.Errorf("cors origins: %w", err)
+	}
+	// Reflecting every origin with credentials lets any site read keys
+	// with the viewer's cookies
+	if origins.AllowsAny() && cfg.CORS.AllowCredentials {
+		return nil, errors.New(`cors origins: "*" is not allowed with cors.allow-credentials`)
+	}
+
+	return &middleware.CORSConfig{
+		Origins:          origins,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		AllowMethods:     cfg.CORS.AllowMethods,
+		AllowHeaders:     cfg.CORS.AllowHeaders,
+		ExposeHeaders:    cfg.CORS.ExposeHeaders,
+		MaxAge:           cfg.CORS.MaxAge,
+	}, nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/audit"
+	applogger "hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/metrics"
+)
+
+// reloadablePrefixes are the settings a reload applies in place; a change
+// to anything else is rejected until the server restarts
+var reloadablePrefixes = []string{
+	"jwt.",
+	"metric.",
+	"admin.",
+	"ratelimit.auth.",
+	"ratelimit.key.",
+	"cors.",
+	"hotlink.",
+	"ippolicy.",
+	"log.level",
+}
+
+// errRestartRequired rejects a reload that changes restart-only settings
+var errRestartRequired = errors.New("settings require a restart")
+
+// reloadable reports whether a changed key can be applied without a restart
+func reloadable(key string) bool {
+	// Turning JWT off or on changes which middleware the routes carry
+	if key == "jwt.enable" {
+		return false
+	}
+	for _, prefix := range reloadablePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// configReloader re-reads the config file and swaps in its reloadable
+// settings. A reload is applied whole or not at all.
+type configReloader struct {
+	// sources are re-read on every reload, so flags keep overriding the file
+	sources  configs.Sources
+	jwt      *configs.Snapshot[configs.JwtSecret]
+	metric   *configs.Snapshot[configs.Metric]
+	admin    *configs.Snapshot[configs.Admin]
+	policies *policies
+	levels   *applogger.Levels
+	auditor  *audit.Logger
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	current *configs.Config
+}
+
+// Reload re-reads the config file and applies it; trigger is recorded in
+// the audit log (e.g. SIGHUP or watch)
+func (r *configReloader) Reload(ctx context.Context, trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event := audit.Event{
+		Type:    audit.TypeConfigReload,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"trigger": trigger, "file": r.sources.File},
+	}
+	defer func() {
+		r.auditor.Record(ctx, event)
+	}()
+
+	changed, err := r.reload()
+	switch {
+	case errors.Is(err, errRestartRequired):
+		metrics.ConfigReloads.WithLabelValues("rejected").Inc()
+		event.Outcome = audit.OutcomeDenied
+		event.Reason = err.Error()
+		r.logger.Error("config reload rejected", zap.String("file", r.sources.File), zap.Error(err))
+		return err
+	case err != nil:
+		metrics.ConfigReloads.WithLabelValues("failed").Inc()
+		event.Outcome = audit.OutcomeFailure
+		event.Reason = err.Error()
+		r.logger.Error("config reload failed", zap.String("file", r.sources.File), zap.Error(err))
+		return err
+	case len(changed) == 0:
+		metrics.ConfigReloads.WithLabelValues("unchanged").Inc()
+		r.logger.Info("config reloaded, no changes", zap.String("file", r.sources.File))
+		return nil
+	}
+
+	metrics.ConfigReloads.WithLabelValues("applied").Inc()
+	// Only key paths are logged; values may be secrets
+	event.Details["changed"] = strings.Join(changed, ",")
+	event.Details["count"] = strconv.Itoa(len(changed))
+	r.logger.Info("config reloaded",
+		zap.String("file", r.sources.File),
+		zap.Strings("changed", changed),
+	)
+	return nil
+}
+
+// reload loads, validates and applies the config file, returning the
+// changed keys
+func (r *configReloader) reload() ([]string, error) {
+	cfg, err := configs.Load(r.sources)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	changed := configs.Diff(r.current, cfg)
+	var restart []string
+	for _, key := range changed {
+		if !reloadable(key) {
+			r.logger.Warn("changed setting requires a restart", zap.String("field", key))
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) > 0 {
+		return nil, fmt.Errorf("%w: %s", errRestartRequired, strings.Join(restart, ", "))
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	_, warnings := cfg.Check()
+	for _, w := range warnings {
+		r.logger.Warn("weak configuration value", zap.String("field", w.Path), zap.String("problem", w.Message))
+	}
+
+	// Compile everything that can fail before swapping anything
+	set, err := r.policies.build(cfg)
+	if err != nil {
+		return nil, err
+	}
+	level, err := rootLogLevel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing below can fail, so a reload never stops partway
+	r.jwt.Store(cfg.JwtSecret)
+	r.metric.Store(cfg.Metric)
+	r.admin.Store(cfg.Admin)
+	r.policies.apply(set)
+	// Leave a level set through the admin API alone unless the file changed
+	if cfg.Log.Level != r.current.Log.Level {
+		r.levels.SetRoot(level, 0)
+	}
+
+	r.current = cfg
+	return changed, nil
+}
+
+// newConfigWatcher watches the directory holding path, since editors and
+// mounted ConfigMaps replace the file rather than write to it
+func newConfigWatcher(path string) (*fsnotify.Watcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create config watcher: %w", err)
+	}
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("watch %s: %w", filepath.Dir(path), err)
+	}
+	return w, nil
+}
+
+// watchConfig calls reload once events for path have been quiet for
+// debounce, until ctx is done
+func watchConfig(ctx context.Context, w *fsnotify.Watcher, path string, debounce time.Duration, reload func(), logger *zap.Logger) {
+	defer func() {
+		_ = w.Close()
+	}()
+
+	name := filepath.Clean(path)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			// Kubernetes swaps the ..data symlink when a ConfigMap changes
+			if filepath.Clean(ev.Name) != name && filepath.Base(ev.Name) != "..data" {
+				continue
+			}
+			if !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Rename) {
+				continue
+			}
+			timer.Reset(debounce)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("config watcher error", zap.Error(err))
+		case <-timer.C:
+			reload()
+		}
+	}
+}
+
+// rootLogLevel returns log.level, or the app.mode default when unset
+func rootLogLevel(cfg *configs.Config) (zapcore.Level, error) {
+	if cfg.Log.Level != "" {
+		return zapcore.ParseLevel(cfg.Log.Level)
+	}
+	if strings.EqualFold(cfg.App.Mode, "production") {
+		return zapcore.InfoLevel, nil
+	}
+	return zapcore.DebugLevel, nil
+}
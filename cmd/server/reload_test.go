@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/handler"
+	applogger "hls-key-server-go/internal/pkg/logger"
+)
+
+const reloadBaseConfig = `
+app:
+  port: "8080"
+jwt:
+  secretkey: "first-secret-key-at-least-32-bytes!!"
+  expire: 10
+  user: "player"
+  header-key: "X-Player-Key"
+  header-value: "first-header-value"
+  iss: "hls-key-server"
+  aud: "hls-key-api"
+metric:
+  user: "prometheus"
+  password: "first-metric-password"
+hotlink:
+  domains: ["player.example.com"]
+`
+
+func newTestReloader(t *testing.T, body string) (*configReloader, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	src := configs.Sources{File: path}
+	cfg, err := configs.Load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := zap.NewNop()
+	p, err := newPolicies(cfg, handler.NewHLSHandler(nil, nil, logger), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &configReloader{
+		sources:  src,
+		jwt:      configs.NewSnapshot(cfg.JwtSecret),
+		metric:   configs.NewSnapshot(cfg.Metric),
+		admin:    configs.NewSnapshot(cfg.Admin),
+		policies: p,
+		levels:   applogger.NewLevels(zapcore.InfoLevel),
+		logger:   logger,
+		current:  cfg,
+	}, path
+}
+
+func writeConfig(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigReloader_Apply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, path := newTestReloader(t, reloadBaseConfig)
+	oldJWT := r.jwt.Load()
+
+	router := gin.New()
+	router.Use(r.policies.cors.Handler())
+	router.POST("/key", func(c *gin.Context) { c.Status(http.StatusOK) })
+	preflight := func() int {
+		req := httptest.NewRequest(http.MethodOptions, "/key", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := preflight(); code == http.StatusForbidden {
+		t.Fatal("preflight rejected before CORS was enabled")
+	}
+
+	body := strings.NewReplacer(
+		"first-secret-key", "second-secret-key",
+		"first-metric-password", "second-metric-password",
+	).Replace(reloadBaseConfig) + `
+cors:
+  enable: true
+log:
+  level: "warn"
+`
+	writeConfig(t, path, body)
+
+	if err := r.Reload(context.Background(), "test"); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got := r.jwt.Load().SecretKey; !strings.HasPrefix(got, "second-secret-key") {
+		t.Errorf("jwt.secretkey = %q, want the reloaded value", got)
+	}
+	if !strings.HasPrefix(oldJWT.SecretKey, "first-secret-key") {
+		t.Errorf("earlier jwt snapshot changed to %q", oldJWT.SecretKey)
+	}
+	if got := r.metric.Load().Password; got != "second-metric-password" {
+		t.Errorf("metric.password = %q, want %q", got, "second-metric-password")
+	}
+	if got := r.levels.Root().Level(); got != zapcore.WarnLevel {
+		t.Errorf("root level = %v, want %v", got, zapcore.WarnLevel)
+	}
+	if code := preflight(); code != http.StatusForbidden {
+		t.Errorf("preflight from unlisted origin = %d after enabling CORS, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestConfigReloader_RejectsRestartOnlyChanges(t *testing.T) {
+	r, path := newTestReloader(t, reloadBaseConfig)
+
+	body := strings.NewReplacer(
+		`port: "8080"`, `port: "9090"`,
+		"first-metric-password", "second-metric-password",
+	).Replace(reloadBaseConfig)
+	writeConfig(t, path, body)
+
+	err := r.Reload(context.Background(), "test")
+	if !errors.Is(err, errRestartRequired) {
+		t.Fatalf("Reload() error = %v, want errRestartRequired", err)
+	}
+	if !strings.Contains(err.Error(), "app.port") {
+		t.Errorf("error %q does not name app.port", err)
+	}
+	// A rejected reload applies nothing
+	if got := r.metric.Load().Password; got != "first-metric-password" {
+		t.Errorf("metric.password = %q after rejected reload", got)
+	}
+}
+
+func TestConfigReloader_KeepsConfigOnInvalidFile(t *testing.T) {
+	r, path := newTestReloader(t, reloadBaseConfig)
+
+	body := strings.Replace(reloadBaseConfig, "expire: 10", "expire: 0", 1)
+	body = strings.Replace(body, "first-metric-password", "second-metric-password", 1)
+	writeConfig(t, path, body)
+
+	var verr *configs.ValidationError
+	if err := r.Reload(context.Background(), "test"); !errors.As(err, &verr) {
+		t.Fatalf("Reload() error = %v, want *configs.ValidationError", err)
+	}
+	if got := r.metric.Load().Password; got != "first-metric-password" {
+		t.Errorf("metric.password = %q after invalid reload", got)
+	}
+	if r.jwt.Load().Expire != 10 {
+		t.Errorf("jwt.expire = %d after invalid reload", r.jwt.Load().Expire)
+	}
+}
+
+func TestConfigReloader_InvalidLevelAppliesNothing(t *testing.T) {
+	r, path := newTestReloader(t, reloadBaseConfig)
+
+	body := strings.Replace(reloadBaseConfig, "first-metric-password", "second-metric-password", 1) + `
+log:
+  level: "verbose"
+`
+	writeConfig(t, path, body)
+
+	if err := r.Reload(context.Background(), "test"); err == nil {
+		t.Fatal("Reload() expected error for an unknown log level")
+	}
+	if got := r.metric.Load().Password; got != "first-metric-password" {
+		t.Errorf("metric.password = %q after a reload with a bad level", got)
+	}
+	if got := r.levels.Root().Level(); got != zapcore.InfoLevel {
+		t.Errorf("root level = %v, want %v", got, zapcore.InfoLevel)
+	}
+	if r.current.Log.Level == "verbose" {
+		t.Error("current config replaced by a rejected reload")
+	}
+}
+
+func TestReloadable(t *testing.T) {
+	tests := map[string]bool{
+		"jwt.secretkey":         true,
+		"jwt.enable":            false,
+		"metric.password":       true,
+		"ratelimit.auth.rate":   true,
+		"ratelimit.enable":      false,
+		"ratelimit.redis.addr":  false,
+		"ippolicy.rules":        true,
+		"log.level":             true,
+		"log.output":            false,
+		"app.port":              false,
+		"tls.cert-file":         false,
+		"listener.read-timeout": false,
+	}
+	for key, want := range tests {
+		if got := reloadable(key); got != want {
+			t.Errorf("reloadable(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "app:\n  port: \"8080\"\n")
+
+	w, err := newConfigWatcher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloads := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchConfig(ctx, w, path, 20*time.Millisecond, func() { reloads <- struct{}{} }, zap.NewNop())
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Other files in the directory are ignored
+	writeConfig(t, filepath.Join(filepath.Dir(path), "other.yaml"), "x: 1\n")
+	// A burst of writes is coalesced into one reload
+	for i := 0; i < 3; i++ {
+		writeConfig(t, path, "app:\n  port: \"9090\"\n")
+	}
+
+	select {
+	case <-reloads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after config file changed")
+	}
+	select {
+	case <-reloads:
+		t.Fatal("burst of writes triggered more than one reload")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/keysync"
+	"hls-key-server-go/internal/repository"
+)
+
+// startReplica creates the replica key repository, pulls once and keeps
+// pulling every cfg.Interval until lc stops. A failed first pull is not
+// fatal: the cached snapshot, if any, is served and readiness waits for keys.
+func startReplica(cfg configs.Sync, codec *keysync.Codec, lc *lifecycle, logger *zap.Logger) (*repository.RemoteKeyRepository, error) {
+	opts := []repository.RemoteKeyOption{
+		repository.WithRemoteLogger(logger),
+		repository.WithRemoteHTTPClient(&http.Client{Timeout: cfg.Timeout}),
+	}
+	if cfg.CacheFile != "" {
+		opts = append(opts, repository.WithRemoteCacheFile(cfg.CacheFile))
+	}
+	repo, err := repository.NewRemoteKeyRepository(cfg.Upstream, codec, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.Reload(context.Background()); err != nil {
+		logger.Error("initial key sync failed", zap.String("upstream", cfg.Upstream), zap.Error(err))
+	}
+	lc.Go("key-sync", func(ctx context.Context) {
+		repo.Run(ctx, cfg.Interval)
+	})
+
+	logger.Info("replicating keys from upstream",
+		zap.String("upstream", cfg.Upstream),
+		zap.Duration("interval", cfg.Interval),
+	)
+	return repo, nil
+}
@@ -31,6 +31,18 @@ var (
 
 	// ErrMissingHeader indicates required HTTP header is missing
 	ErrMissingHeader = errors.New("required header is missing")
+
+	// ErrTooManyAttempts indicates the caller is in backoff or locked out after repeated failures
+	ErrTooManyAttempts = errors.New("too many failed attempts")
+
+	// ErrSessionLimitExceeded indicates the subject already has the maximum number of active sessions
+	ErrSessionLimitExceeded = errors.New("concurrent session limit exceeded")
+
+	// ErrForbidden indicates the authenticated principal may not access the resource
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrNotLeader indicates a replicated write reached a node that is not the cluster leader
+	ErrNotLeader = errors.New("not the cluster leader")
 )
 
 // Wrap wraps an error with additional context
@@ -65,3 +77,18 @@ func IsInvalidKeyName(err error) bool {
 func IsInvalidCredentials(err error) bool {
 	return errors.Is(err, ErrInvalidCredentials)
 }
+
+// IsTooManyAttempts checks if error is ErrTooManyAttempts
+func IsTooManyAttempts(err error) bool {
+	return errors.Is(err, ErrTooManyAttempts)
+}
+
+// IsSessionLimitExceeded checks if error is ErrSessionLimitExceeded
+func IsSessionLimitExceeded(err error) bool {
+	return errors.Is(err, ErrSessionLimitExceeded)
+}
+
+// IsForbidden checks if error is ErrForbidden
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
@@ -0,0 +1,11 @@
+package configs
+
+// Admin defines credentials protecting management endpoints
+// @Summary Admin configuration
+// @Description Admin configuration
+// @Tags Admin
+// @ID admin-conf
+type Admin struct {
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+}
@@ -0,0 +1,20 @@
+package configs
+
+// Audit defines the key disclosure audit log
+// @Summary Audit configuration
+// @Description Audit configuration
+// @Tags Audit
+// @ID audit-conf
+type Audit struct {
+	Enable bool `mapstructure:"enable"`
+	// File is the active JSON-lines file; rotated files get a timestamp suffix
+	File string `mapstructure:"file"`
+	// MaxSizeMB rotates the file once it reaches this size; 0 disables rotation
+	MaxSizeMB int `mapstructure:"max-size-mb"`
+	// MaxBackups limits retained rotated files; 0 keeps all
+	MaxBackups int `mapstructure:"max-backups"`
+	// SigningKeyFile is a PKCS#8 PEM Ed25519 key; when set, signed checkpoints are appended
+	SigningKeyFile string `mapstructure:"signing-key-file"`
+	// CheckpointEvery is the number of records between signed checkpoints
+	CheckpointEvery int `mapstructure:"checkpoint-every"`
+}
@@ -0,0 +1,52 @@
+package configs
+
+import "time"
+
+// Cluster defines Raft replication of key writes between key servers
+// @Summary Cluster configuration
+// @Description Cluster configuration
+// @Tags Cluster
+// @ID cluster-conf
+type Cluster struct {
+	Enable bool `mapstructure:"enable"`
+	// NodeID uniquely identifies this server in the cluster
+	NodeID string `mapstructure:"node-id"`
+	// BindAddr is the host:port the Raft transport listens on
+	BindAddr string `mapstructure:"bind-addr"`
+	// AdvertiseAddr is the address peers dial; defaults to BindAddr
+	AdvertiseAddr string `mapstructure:"advertise-addr"`
+	// DataDir holds the Raft log and snapshots
+	DataDir string `mapstructure:"data-dir"`
+	// Bootstrap forms a new cluster from Peers on first start; nodes with
+	// existing state ignore it. Every initial member may set it as long as
+	// all of them list the same peers.
+	Bootstrap bool          `mapstructure:"bootstrap"`
+	Peers     []ClusterPeer `mapstructure:"peers"`
+	// ApplyTimeout bounds waiting for a write to be committed
+	ApplyTimeout time.Duration `mapstructure:"apply-timeout"`
+	// SnapshotThreshold is the number of log entries between snapshots
+	SnapshotThreshold uint64     `mapstructure:"snapshot-threshold"`
+	TLS               ClusterTLS `mapstructure:"tls"`
+}
+
+// ClusterPeer is one initial cluster member
+type ClusterPeer struct {
+	ID   string `mapstructure:"id"`
+	Addr string `mapstructure:"addr"`
+	// APIURL is the member's HTTP base URL; writes sent to a follower are
+	// redirected to the leader's when known
+	APIURL string `mapstructure:"api-url"`
+}
+
+// ClusterTLS enables mutual TLS between Raft peers; all three files are
+// required together
+type ClusterTLS struct {
+	CertFile string `mapstructure:"cert-file"`
+	KeyFile  string `mapstructure:"key-file"`
+	CAFile   string `mapstructure:"ca-file"`
+}
+
+// Enabled reports whether peer traffic is encrypted
+func (t ClusterTLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != "" || t.CAFile != ""
+}
@@ -0,0 +1,44 @@
+package configs
+
+import "reflect"
+
+// Diff returns the key paths, in Keys order, whose values differ between
+// a and b
+func Diff(a, b *Config) []string {
+	return appendDiff(nil, "", reflect.ValueOf(*a), reflect.ValueOf(*b))
+}
+
+func appendDiff(keys []string, prefix string, a, b reflect.Value) []string {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			keys = appendDiff(keys, key, a.Field(i), b.Field(i))
+			continue
+		}
+		if !equalValue(a.Field(i), b.Field(i)) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// equalValue treats nil and empty slices and maps as equal, since the file
+// and the defaults spell an empty list either way
+func equalValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
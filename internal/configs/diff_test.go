@@ -0,0 +1,52 @@
+package configs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	a := &Config{
+		App:      AppConf{Port: "8080"},
+		Lockout:  Lockout{Window: time.Minute},
+		Network:  Network{TrustedProxies: nil},
+		IPPolicy: IPPolicy{Rules: []IPPolicyRule{{Keys: []string{"*"}, Allow: []string{"10.0.0.0/8"}}}},
+	}
+	b := *a
+	b.Network.TrustedProxies = []string{}
+
+	if got := Diff(a, &b); len(got) != 0 {
+		t.Fatalf("Diff() of equal configs = %v, want none", got)
+	}
+
+	b.App.Port = "9090"
+	b.JwtSecret.SecretKey = "rotated"
+	b.Lockout.Window = 2 * time.Minute
+	b.IPPolicy.Rules = []IPPolicyRule{{Keys: []string{"*"}, Allow: []string{"192.168.0.0/16"}}}
+
+	want := []string{"app.port", "jwt.secretkey", "lockout.window", "ippolicy.rules"}
+	if got := Diff(a, &b); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff() = %v, want %v", got, want)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	src := Metric{User: "prometheus", Password: "first"}
+	s := NewSnapshot(src)
+
+	// The snapshot holds a copy, so later changes to src are not visible
+	src.Password = "mutated"
+	if got := s.Load().Password; got != "first" {
+		t.Fatalf("Load().Password = %q, want %q", got, "first")
+	}
+
+	old := s.Load()
+	s.Store(Metric{User: "prometheus", Password: "second"})
+	if got := s.Load().Password; got != "second" {
+		t.Fatalf("after Store, Password = %q, want %q", got, "second")
+	}
+	if old.Password != "first" {
+		t.Fatalf("earlier snapshot changed to %q", old.Password)
+	}
+}
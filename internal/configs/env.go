@@ -0,0 +1,122 @@
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix prefixes every configuration environment variable
+const EnvPrefix = "HLSKEY"
+
+// FileEnvSuffix marks a variable naming a file whose content is the value,
+// e.g. HLSKEY_JWT_SECRETKEY_FILE=/run/secrets/jwt
+const FileEnvSuffix = "_FILE"
+
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// EnvVar returns the environment variable overriding a config key path,
+// e.g. jwt.secretkey -> HLSKEY_JWT_SECRETKEY and
+// listener.http3.advertise-alt-svc -> HLSKEY_LISTENER_HTTP3_ADVERTISE_ALT_SVC
+func EnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// Keys returns the key path of every leaf field of Config in declaration
+// order. Lists of objects such as ippolicy.rules are single keys whose
+// environment value is JSON.
+func Keys() []string {
+	return appendKeys(nil, "", reflect.TypeOf(Config{}))
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func appendKeys(keys []string, prefix string, t reflect.Type) []string {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			keys = appendKeys(keys, key, f.Type)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// LookupFunc returns the value of an environment variable and whether it is
+// set; os.LookupEnv reads the process environment
+type LookupFunc func(name string) (string, bool)
+
+// applyEnv overrides keys from their environment variables, then applies
+// *_FILE variables, which take precedence over plain ones
+func applyEnv(v *viper.Viper, lookup LookupFunc) error {
+	for _, key := range Keys() {
+		name := EnvVar(key)
+		value, set := lookup(name)
+		// Like unset variables, empty ones leave the file value in place
+		if value != "" {
+			v.Set(key, value)
+		}
+
+		path, ok := lookup(name + FileEnvSuffix)
+		if !ok {
+			continue
+		}
+		if set {
+			return fmt.Errorf("%s and %s are both set", name, name+FileEnvSuffix)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", name+FileEnvSuffix, err)
+		}
+		// Mounted secrets usually end with a newline
+		v.Set(key, strings.TrimRight(string(data), "\r\n"))
+	}
+	return nil
+}
+
+// decodeHook converts environment strings into config values: durations,
+// comma-separated lists, and JSON for lists and objects
+func decodeHook() viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		jsonStringHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+}
+
+// jsonStringHook decodes a string holding a JSON array or object into a
+// slice, map or struct field
+func jsonStringHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String {
+		return data, nil
+	}
+	switch to.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Struct:
+	default:
+		return data, nil
+	}
+	s := strings.TrimSpace(data.(string))
+	if !strings.HasPrefix(s, "[") && !strings.HasPrefix(s, "{") {
+		return data, nil
+	}
+	var out interface{}
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return nil, fmt.Errorf("decode JSON value: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,110 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvVar(t *testing.T) {
+	tests := map[string]string{
+		"jwt.secretkey":                    "HLSKEY_JWT_SECRETKEY",
+		"jwt.header-value":                 "HLSKEY_JWT_HEADER_VALUE",
+		"listener.http3.advertise-alt-svc": "HLSKEY_LISTENER_HTTP3_ADVERTISE_ALT_SVC",
+	}
+	for key, want := range tests {
+		if got := EnvVar(key); got != want {
+			t.Errorf("EnvVar(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := strings.Join(Keys(), " ")
+	for _, key := range []string{"app.port", "jwt.secretkey", "lockout.window", "listener.http3.port", "ippolicy.rules", "log.sampling.initial"} {
+		if !strings.Contains(" "+keys+" ", " "+key+" ") {
+			t.Errorf("Keys() missing %s", key)
+		}
+	}
+	if strings.Contains(keys, "listener.http3 ") {
+		t.Error("Keys() contains a non-leaf struct key")
+	}
+}
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(cfgFile, []byte(`
+app:
+  port: "9090"
+jwt:
+  secretkey: "from-file"
+  expire: 10
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	secretFile := filepath.Join(dir, "header-value")
+	if err := os.WriteFile(secretFile, []byte("mounted-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := mapEnv{
+		"HLSKEY_APP_PORT":              "8443",
+		"HLSKEY_JWT_SECRETKEY":         "from-env",
+		"HLSKEY_JWT_HEADER_VALUE_FILE": secretFile,
+		"HLSKEY_JWT_ENABLE":            "true",
+		"HLSKEY_LOCKOUT_WINDOW":        "90s",
+		"HLSKEY_CORS_ALLOW_METHODS":    "GET,HEAD",
+		"HLSKEY_IPPOLICY_RULES":        `[{"name":"office","keys":["*.key"],"allow":["10.0.0.0/8"]}]`,
+	}
+
+	cfg, err := Load(Sources{File: cfgFile, Env: env.Lookup})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.App.Port != "8443" {
+		t.Errorf("app.port = %q, want env value", cfg.App.Port)
+	}
+	if cfg.JwtSecret.SecretKey != "from-env" {
+		t.Errorf("jwt.secretkey = %q, want env value", cfg.JwtSecret.SecretKey)
+	}
+	if cfg.JwtSecret.HeaderValue != "mounted-secret" {
+		t.Errorf("jwt.header-value = %q, want file content", cfg.JwtSecret.HeaderValue)
+	}
+	if !cfg.JwtSecret.Enable || cfg.JwtSecret.Expire != 10 {
+		t.Errorf("jwt = %+v", cfg.JwtSecret)
+	}
+	if cfg.Lockout.Window != 90*time.Second {
+		t.Errorf("lockout.window = %v, want 90s", cfg.Lockout.Window)
+	}
+	if strings.Join(cfg.CORS.AllowMethods, ",") != "GET,HEAD" {
+		t.Errorf("cors.allow-methods = %v", cfg.CORS.AllowMethods)
+	}
+	if len(cfg.IPPolicy.Rules) != 1 || cfg.IPPolicy.Rules[0].Name != "office" || cfg.IPPolicy.Rules[0].Allow[0] != "10.0.0.0/8" {
+		t.Errorf("ippolicy.rules = %+v", cfg.IPPolicy.Rules)
+	}
+}
+
+func TestLoad_EnvFileConflicts(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(cfgFile, []byte("app:\n  port: \"9090\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := mapEnv{
+		"HLSKEY_METRIC_PASSWORD":      "plain",
+		"HLSKEY_METRIC_PASSWORD_FILE": filepath.Join(dir, "metric"),
+	}
+	if _, err := Load(Sources{File: cfgFile, Env: env.Lookup}); err == nil || !strings.Contains(err.Error(), "both set") {
+		t.Errorf("Load() error = %v, want conflict", err)
+	}
+
+	delete(env, "HLSKEY_METRIC_PASSWORD")
+	if _, err := Load(Sources{File: cfgFile, Env: env.Lookup}); err == nil || !strings.Contains(err.Error(), "HLSKEY_METRIC_PASSWORD_FILE") {
+		t.Errorf("Load() error = %v, want missing file", err)
+	}
+}
@@ -2,18 +2,9 @@
 package configs
 
 import (
-	"fmt"
-	"log"
-
-	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
-// 設定命令行標誌 (flags)，允許指定配置文件路徑
-var (
-	configFile = pflag.StringP("config", "c", "", "Path to configuration file")
-)
-
 // AppConf defines application configuration settings
 // @Summary App configuration
 // @Description App configuration
@@ -40,93 +31,34 @@ type Metric struct {
 	Password string `mapstructure:"password"`
 }
 
+// Config is the complete server configuration. Load builds a new value for
+// every call; treat it as read-only once loaded and share changes by loading
+// again rather than mutating it.
 type Config struct {
 	App       AppConf   `mapstructure:"app"`
 	Metric    Metric    `mapstructure:"metric"`
 	JwtSecret JwtSecret `mapstructure:"jwt"`
-}
-
-// Conf stores the global application configuration
-var Conf Config
-
-// LoadConfig loads configuration from file or defaults
-// Returns a Config instance instead of using global variable
-func LoadConfig() (*Config, error) {
-	// Parse command line arguments
-	pflag.Parse()
-
-	// Create new Viper instance
-	v := viper.New()
-	setDefaults(v)
-	v.AutomaticEnv()
-
-	// Set config file
-	if *configFile != "" {
-		v.SetConfigFile(*configFile)
-	} else {
-		v.SetConfigName("config")
-		v.AddConfigPath("./config")
-		v.AddConfigPath(".")
-	}
-	v.SetConfigType("yaml")
-
-	// Read config
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("read config file: %w", err)
-	}
-
-	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("unmarshal config: %w", err)
-	}
-
-	// Also set global variable for backward compatibility
-	Conf = cfg
-
-	if v.ConfigFileUsed() != "" {
-		fmt.Printf("成功加載配置: %s\n", v.ConfigFileUsed())
-	}
-
-	return &cfg, nil
-}
-
-// Init initializes configuration (deprecated: use LoadConfig instead)
-// Kept for backward compatibility
-func Init() {
-	// 解析命令行參數
-	pflag.Parse()
-
-	// 創建新的 Viper 實例
-	v := viper.New()
-	setDefaults(v) // 設置默認配置
-	v.AutomaticEnv()
-
-	// 設定配置文件
-	if *configFile != "" {
-		v.SetConfigFile(*configFile)
-	} else {
-		v.SetConfigName("config")   // 預設文件名稱 config.yaml
-		v.AddConfigPath("./config") // 尋找 config/ 目錄
-		v.AddConfigPath(".")        // 當前目錄
-	}
-	v.SetConfigType("yaml")
-
-	// 讀取配置
-	if err := v.ReadInConfig(); err != nil {
-		log.Fatalf("讀取配置文件失敗: %v", err)
-	}
-
-	// 解析配置到結構體
-	if err := v.Unmarshal(&Conf); err != nil {
-		log.Fatalf("解析配置文件失敗: %v", err)
-	}
-
-	// 確保加載成功
-	if v.ConfigFileUsed() != "" {
-		fmt.Printf("成功加載配置: %s\n", v.ConfigFileUsed())
-	} else {
-		log.Println("未找到任何有效的配置文件，將使用默認值")
-	}
+	RateLimit RateLimit `mapstructure:"ratelimit"`
+	Lockout   Lockout   `mapstructure:"lockout"`
+	Admin     Admin     `mapstructure:"admin"`
+	Session   Session   `mapstructure:"session"`
+	Network   Network   `mapstructure:"network"`
+	IPPolicy  IPPolicy  `mapstructure:"ippolicy"`
+	Hotlink   Hotlink   `mapstructure:"hotlink"`
+	CORS      CORS      `mapstructure:"cors"`
+	TLS       TLS       `mapstructure:"tls"`
+	Listener  Listener  `mapstructure:"listener"`
+	Audit     Audit     `mapstructure:"audit"`
+	Tracing   Tracing   `mapstructure:"tracing"`
+	Log       Log       `mapstructure:"log"`
+	Shutdown  Shutdown  `mapstructure:"shutdown"`
+	Reload    Reload    `mapstructure:"reload"`
+	Cluster   Cluster   `mapstructure:"cluster"`
+	Sync      Sync      `mapstructure:"sync"`
+	Keys      KeyStore  `mapstructure:"keys"`
+
+	// renamed lists old key names set in the config file; see renamedKeys
+	renamed []string
 }
 
 // 設置默認值
@@ -142,4 +74,128 @@ func setDefaults(v *viper.Viper) {
 
 	v.SetDefault("metric.user", "admin")
 	v.SetDefault("metric.password", "password")
+
+	v.SetDefault("jwt.enable", false)
+
+	v.SetDefault("ratelimit.enable", false)
+	v.SetDefault("ratelimit.backend", "memory")
+	v.SetDefault("ratelimit.redis.addr", "127.0.0.1:6379")
+	v.SetDefault("ratelimit.redis.prefix", "hls:ratelimit:")
+	v.SetDefault("ratelimit.auth.rate", 0.2)
+	v.SetDefault("ratelimit.auth.burst", 5)
+	v.SetDefault("ratelimit.auth.by", "ip")
+	v.SetDefault("ratelimit.key.rate", 10)
+	v.SetDefault("ratelimit.key.burst", 30)
+	v.SetDefault("ratelimit.key.by", "subject")
+
+	v.SetDefault("lockout.enable", false)
+	v.SetDefault("lockout.max-failures", 5)
+	v.SetDefault("lockout.window", "15m")
+	v.SetDefault("lockout.base-delay", "1s")
+	v.SetDefault("lockout.max-delay", "30s")
+	v.SetDefault("lockout.duration", "15m")
+	v.SetDefault("lockout.max-duration", "24h")
+
+	v.SetDefault("session.enable", false)
+	v.SetDefault("session.max-per-subject", 2)
+	v.SetDefault("session.idle-timeout", "2m")
+
+	v.SetDefault("network.trusted-proxies", []string{})
+	v.SetDefault("network.remote-ip-headers", []string{"X-Forwarded-For", "X-Real-IP"})
+
+	v.SetDefault("ippolicy.enable", false)
+
+	v.SetDefault("hotlink.enable", false)
+	v.SetDefault("hotlink.allow-empty", true)
+	v.SetDefault("hotlink.domains", []string{})
+
+	v.SetDefault("cors.enable", false)
+	v.SetDefault("cors.allow-credentials", false)
+	v.SetDefault("cors.allow-methods", []string{"GET", "POST", "OPTIONS"})
+	v.SetDefault("cors.allow-headers", []string{"Authorization", "Content-Type", "Accept", "Origin", "X-Requested-With", "X-Request-ID"})
+	v.SetDefault("cors.expose-headers", []string{"Content-Length", "Content-Type", "Retry-After", "X-Request-ID"})
+	v.SetDefault("cors.max-age", "10m")
+
+	v.SetDefault("tls.enable", false)
+	v.SetDefault("tls.reload-interval", "1m")
+	v.SetDefault("tls.min-version", "1.2")
+	v.SetDefault("tls.client-auth", "none")
+
+	v.SetDefault("listener.read-timeout", "15s")
+	v.SetDefault("listener.read-header-timeout", "10s")
+	v.SetDefault("listener.write-timeout", "15s")
+	v.SetDefault("listener.idle-timeout", "60s")
+	v.SetDefault("listener.max-header-bytes", 1<<20)
+	v.SetDefault("listener.h2c", false)
+	v.SetDefault("listener.http3.enable", false)
+	v.SetDefault("listener.http3.port", "")
+	v.SetDefault("listener.http3.advertise-alt-svc", true)
+
+	v.SetDefault("audit.enable", false)
+	v.SetDefault("audit.file", "./logs/audit.jsonl")
+	v.SetDefault("audit.max-size-mb", 100)
+	v.SetDefault("audit.max-backups", 0)
+	v.SetDefault("audit.signing-key-file", "")
+	v.SetDefault("audit.checkpoint-every", 1000)
+
+	v.SetDefault("tracing.enable", false)
+	v.SetDefault("tracing.exporter", "otlp-grpc")
+	v.SetDefault("tracing.endpoint", "localhost:4317")
+	v.SetDefault("tracing.insecure", false)
+	v.SetDefault("tracing.file", "./logs/traces.jsonl")
+	v.SetDefault("tracing.sample-ratio", 1.0)
+	v.SetDefault("tracing.service-name", "hls-key-server")
+
+	v.SetDefault("log.level", "")
+	v.SetDefault("log.encoding", "")
+	v.SetDefault("log.output", "stdout")
+	v.SetDefault("log.max-size-mb", 100)
+	v.SetDefault("log.max-age-days", 30)
+	v.SetDefault("log.max-backups", 10)
+	v.SetDefault("log.compress", false)
+	v.SetDefault("log.sampling.enable", false)
+	v.SetDefault("log.sampling.initial", 100)
+	v.SetDefault("log.sampling.thereafter", 100)
+	v.SetDefault("log.skip-paths", []string{"/healthz", "/livez", "/readyz", "/startupz", "/api/v1/metrics"})
+
+	v.SetDefault("shutdown.pre-stop-delay", "5s")
+	v.SetDefault("shutdown.drain-timeout", "20s")
+	v.SetDefault("shutdown.stop-timeout", "10s")
+
+	v.SetDefault("reload.watch", false)
+	v.SetDefault("reload.debounce", "500ms")
+
+	v.SetDefault("cluster.enable", false)
+	v.SetDefault("cluster.bind-addr", "127.0.0.1:7000")
+	v.SetDefault("cluster.data-dir", "./data/raft")
+	v.SetDefault("cluster.bootstrap", false)
+	v.SetDefault("cluster.apply-timeout", "5s")
+	v.SetDefault("cluster.snapshot-threshold", 1024)
+
+	v.SetDefault("sync.export", false)
+	v.SetDefault("sync.interval", "30s")
+	v.SetDefault("sync.timeout", "10s")
+
+	v.SetDefault("keys.backend", "file")
+	v.SetDefault("keys.dir", "./keys")
+	v.SetDefault("keys.refresh-interval", "0s")
+	v.SetDefault("keys.s3.timeout", "10s")
+	v.SetDefault("keys.vault.mount", "secret")
+	v.SetDefault("keys.vault.path", "hls/keys")
+	v.SetDefault("keys.vault.field", "key")
+	v.SetDefault("keys.vault.auth", "token")
+	v.SetDefault("keys.vault.approle-mount", "approle")
+	v.SetDefault("keys.vault.transit.mount", "transit")
+	v.SetDefault("keys.vault.timeout", "10s")
+	v.SetDefault("keys.kek.provider", "")
+	v.SetDefault("keys.kek.unwrap", "reload")
+	v.SetDefault("keys.kek.pkcs11.slot", 0)
+	v.SetDefault("keys.kek.pkcs11.key-label", "hls-kek")
+	v.SetDefault("keys.kek.pkcs11.mechanism", "aes-key-wrap-pad")
+	v.SetDefault("keys.cache.mode", "eager")
+	v.SetDefault("keys.cache.size", 10000)
+	v.SetDefault("keys.cache.ttl", "10m")
+
+	v.SetDefault("admin.user", "")
+	v.SetDefault("admin.password", "")
 }
@@ -0,0 +1,37 @@
+package configs
+
+// IPPolicy defines CIDR allow and deny lists for key retrieval
+// @Summary IP policy configuration
+// @Description IP policy configuration
+// @Tags IPPolicy
+// @ID ip-policy-conf
+type IPPolicy struct {
+	Enable bool `mapstructure:"enable"`
+	// Rules are evaluated in order; the first rule matching the key or tenant applies
+	Rules []IPPolicyRule `mapstructure:"rules"`
+}
+
+// IPPolicyRule restricts which networks may fetch a group of keys
+type IPPolicyRule struct {
+	Name string `mapstructure:"name"`
+	// Keys are key name glob patterns (path.Match syntax), e.g. "qa-*.key"
+	Keys []string `mapstructure:"keys"`
+	// Tenants match the JWT "tenant" claim
+	Tenants []string `mapstructure:"tenants"`
+	// Allow lists permitted CIDRs; when non-empty all other addresses are denied
+	Allow []string `mapstructure:"allow"`
+	// Deny lists rejected CIDRs, checked before Allow
+	Deny []string `mapstructure:"deny"`
+}
+
+// Network defines client address resolution behind proxies
+// @Summary Network configuration
+// @Description Network configuration
+// @Tags Network
+// @ID network-conf
+type Network struct {
+	// TrustedProxies lists proxy CIDRs whose forwarding headers are honored; empty trusts none
+	TrustedProxies []string `mapstructure:"trusted-proxies"`
+	// RemoteIPHeaders lists headers carrying the client address, in priority order
+	RemoteIPHeaders []string `mapstructure:"remote-ip-headers"`
+}
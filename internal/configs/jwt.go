@@ -15,4 +15,7 @@ type JwtSecret struct {
 	HeaderValue string `mapstructure:"header-value"`
 	Iss         string `mapstructure:"iss"`
 	Aud         string `mapstructure:"aud"`
+	// Tenant is issued as the "tenant" claim of every token, for the
+	// tenant-scoped ippolicy and hotlink rules; empty issues none
+	Tenant string `mapstructure:"tenant"`
 }
@@ -0,0 +1,118 @@
+package configs
+
+import "time"
+
+// KeyStore defines where content keys are stored
+// @Summary Key storage configuration
+// @Description Key storage configuration
+// @Tags Keys
+// @ID keys-conf
+type KeyStore struct {
+	// Backend is "file" (Dir), "s3" (S3) or "vault" (Vault)
+	Backend string `mapstructure:"backend"`
+	// Dir holds the .key files of the file backend
+	Dir string `mapstructure:"dir"`
+	// RefreshInterval reloads keys periodically, in addition to SIGHUP;
+	// zero disables it
+	RefreshInterval time.Duration `mapstructure:"refresh-interval"`
+	S3              KeyStoreS3    `mapstructure:"s3"`
+	Vault           KeyStoreVault `mapstructure:"vault"`
+	KEK             KeyStoreKEK   `mapstructure:"kek"`
+	Cache           KeyStoreCache `mapstructure:"cache"`
+}
+
+// KeyStoreCache selects how the file backend loads keys
+type KeyStoreCache struct {
+	// Mode is "eager" (default; read every key on reload) or "lazy" (read
+	// keys on first request through an LRU cache)
+	Mode string `mapstructure:"mode"`
+	// Size is the maximum number of keys cached in lazy mode
+	Size int `mapstructure:"size"`
+	// TTL re-reads a cached key once it is older; zero keeps keys until
+	// they are evicted or reloaded
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// Lazy reports whether keys are read on first request
+func (c KeyStoreCache) Lazy() bool {
+	return c.Mode == "lazy"
+}
+
+// KeyStoreS3 defines an S3-compatible bucket holding one object per key
+type KeyStoreS3 struct {
+	// Endpoint is the service URL, e.g. https://s3.amazonaws.com; the scheme
+	// selects TLS
+	Endpoint string `mapstructure:"endpoint"`
+	Region   string `mapstructure:"region"`
+	Bucket   string `mapstructure:"bucket"`
+	// Prefix selects objects directly below it, e.g. "hls/keys/"; object
+	// names after the prefix are the key names
+	Prefix string `mapstructure:"prefix"`
+	// AccessKey and SecretKey are static credentials; when empty the AWS
+	// environment variables, shared credentials file and instance role are
+	// tried in turn
+	AccessKey string `mapstructure:"access-key"`
+	SecretKey string `mapstructure:"secret-key"`
+	// Timeout bounds listing the prefix and fetching each object
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// KeyStoreVault defines a HashiCorp Vault KV v2 path holding one secret
+// per key
+type KeyStoreVault struct {
+	// Address is Vault's URL, e.g. https://vault.example.com:8200
+	Address   string `mapstructure:"address"`
+	Namespace string `mapstructure:"namespace"`
+	// CAFile verifies Vault's certificate; empty uses the system roots
+	CAFile string `mapstructure:"ca-file"`
+	// Mount is the KV v2 secrets engine mount
+	Mount string `mapstructure:"mount"`
+	// Path lists the key secrets; each secret's name is the key name
+	Path string `mapstructure:"path"`
+	// Field holds the base64-encoded key, or its Transit ciphertext
+	Field string `mapstructure:"field"`
+	// Auth is "token" (Token) or "approle" (RoleID, SecretID)
+	Auth         string `mapstructure:"auth"`
+	Token        string `mapstructure:"token"`
+	AppRoleMount string `mapstructure:"approle-mount"`
+	RoleID       string `mapstructure:"role-id"`
+	SecretID     string `mapstructure:"secret-id"`
+	// Transit decrypts Field with a Transit key when set
+	Transit KeyStoreTransit `mapstructure:"transit"`
+	Timeout time.Duration   `mapstructure:"timeout"`
+}
+
+// KeyStoreTransit names the Transit key that envelope-encrypts stored keys
+type KeyStoreTransit struct {
+	Mount string `mapstructure:"mount"`
+	// Key is the Transit key name; empty stores keys in plaintext
+	Key string `mapstructure:"key"`
+}
+
+// KeyStoreKEK defines a key-encryption key (KEK) under which the stored
+// content keys are wrapped; the backend then holds only wrapped keys
+type KeyStoreKEK struct {
+	// Provider is "" (keys are stored in plaintext) or "pkcs11"
+	Provider string `mapstructure:"provider"`
+	// Unwrap is "reload", which unwraps every key on load and serves them
+	// from memory, or "get", which unwraps on every request so plaintext
+	// keys are never cached
+	Unwrap string    `mapstructure:"unwrap"`
+	PKCS11 KEKPKCS11 `mapstructure:"pkcs11"`
+}
+
+// KEKPKCS11 locates an AES KEK in a PKCS#11 token such as an HSM
+type KEKPKCS11 struct {
+	// Module is the vendor's PKCS#11 library
+	Module string `mapstructure:"module"`
+	// Slot is the slot ID holding the token; TokenLabel, when set, selects
+	// the token by label instead, since some tokens renumber their slots
+	Slot       uint   `mapstructure:"slot"`
+	TokenLabel string `mapstructure:"token-label"`
+	// PINFile holds the user PIN
+	PINFile string `mapstructure:"pin-file"`
+	// KeyLabel is the CKA_LABEL of the KEK
+	KeyLabel string `mapstructure:"key-label"`
+	// Mechanism is "aes-key-wrap-pad" (RFC 5649) or "aes-key-wrap" (RFC 3394)
+	Mechanism string `mapstructure:"mechanism"`
+}
@@ -0,0 +1,28 @@
+package configs
+
+import "time"
+
+// Listener defines settings applied to every HTTP listener (HTTP/1.1, h2c and HTTP/3)
+// @Summary Listener configuration
+// @Description Listener configuration
+// @Tags Listener
+// @ID listener-conf
+type Listener struct {
+	ReadTimeout       time.Duration `mapstructure:"read-timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read-header-timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write-timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle-timeout"`
+	MaxHeaderBytes    int           `mapstructure:"max-header-bytes"`
+	// H2C serves HTTP/2 without TLS (prior knowledge) on the main port
+	H2C   bool  `mapstructure:"h2c"`
+	HTTP3 HTTP3 `mapstructure:"http3"`
+}
+
+// HTTP3 defines the optional QUIC listener; it requires tls.enable
+type HTTP3 struct {
+	Enable bool `mapstructure:"enable"`
+	// Port is the UDP port; empty uses app.port
+	Port string `mapstructure:"port"`
+	// AdvertiseAltSvc adds an Alt-Svc header to TCP responses so clients upgrade
+	AdvertiseAltSvc bool `mapstructure:"advertise-alt-svc"`
+}
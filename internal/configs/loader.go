@@ -0,0 +1,118 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// DefaultFileName is the config file looked up when none is given
+const DefaultFileName = "config.yaml"
+
+// searchPaths are the directories searched for DefaultFileName
+var searchPaths = []string{"./config", "."}
+
+// ErrNoConfigFile is returned by FindFile when no config file exists
+var ErrNoConfigFile = errors.New("no config file found")
+
+// Sources are the layers Load merges, from lowest to highest precedence:
+// built-in defaults, File, Env (plain variables, then *_FILE variables)
+// and Flags. Zero fields are skipped, so a zero Sources yields the defaults.
+type Sources struct {
+	// File is a YAML config file; see FindFile for the default locations
+	File string
+	// Env looks up HLSKEY_* variables; pass os.LookupEnv for the process
+	// environment or a map-backed func in tests
+	Env LookupFunc
+	// Flags override keys whose name they share, e.g. --app.port, and only
+	// when set on the command line. Load does not parse them.
+	Flags *pflag.FlagSet
+}
+
+// renamedKeys maps keys that were renamed to their current name. Old names
+// are ignored, and Check warns about them.
+var renamedKeys = map[string]string{
+	// Before JWT enforcement on key fetches existed, the shipped config
+	// spelled this jwt.enabled, which nothing read
+	"jwt.enabled": "jwt.enable",
+}
+
+// FindFile returns path when set, or else the first DefaultFileName in
+// ./config or the working directory
+func FindFile(path string) (string, error) {
+	return findFile(path, searchPaths)
+}
+
+func findFile(path string, dirs []string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	for _, dir := range dirs {
+		candidate := filepath.Join(dir, DefaultFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s in %v", ErrNoConfigFile, DefaultFileName, dirs)
+}
+
+// Load merges src into a new Config without validating it. It reads no
+// process state beyond what src names, so it is safe to call repeatedly.
+func Load(src Sources) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	if src.File != "" {
+		v.SetConfigFile(src.File)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	if src.Env != nil {
+		if err := applyEnv(v, src.Env); err != nil {
+			return nil, fmt.Errorf("environment overrides: %w", err)
+		}
+	}
+
+	if src.Flags != nil {
+		applyFlags(v, src.Flags)
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg, decodeHook()); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	for old := range renamedKeys {
+		if v.InConfig(old) {
+			cfg.renamed = append(cfg.renamed, old)
+		}
+	}
+	sort.Strings(cfg.renamed)
+	return &cfg, nil
+}
+
+// applyFlags overrides keys with the flags of the same name that were set
+func applyFlags(v *viper.Viper, fs *pflag.FlagSet) {
+	keys := make(map[string]bool)
+	for _, key := range Keys() {
+		keys[key] = true
+	}
+
+	fs.Visit(func(f *pflag.Flag) {
+		if !keys[f.Name] {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			v.Set(f.Name, sv.GetSlice())
+			return
+		}
+		v.Set(f.Name, f.Value.String())
+	})
+}
@@ -0,0 +1,207 @@
+package configs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// mapEnv is an environment for tests that leaves the process untouched
+type mapEnv map[string]string
+
+func (m mapEnv) Lookup(name string) (string, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+func writeFile(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := writeFile(t, dir, "config.yaml", `
+app:
+  port: "1000"
+  mode: "file"
+  version: "file"
+  logpath: "file"
+jwt:
+  secretkey: "file"
+`)
+	secretFile := writeFile(t, dir, "secret", "secret-file\n")
+
+	newFlags := func(args ...string) *pflag.FlagSet {
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		fs.String("app.port", "", "")
+		fs.String("app.mode", "", "")
+		fs.StringSlice("cors.allow-methods", nil, "")
+		fs.String("unrelated", "", "")
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return fs
+	}
+
+	env := mapEnv{
+		"HLSKEY_APP_PORT":           "2000",
+		"HLSKEY_APP_MODE":           "env",
+		"HLSKEY_APP_LOGPATH":        "",
+		"HLSKEY_JWT_SECRETKEY_FILE": secretFile,
+	}
+
+	tests := []struct {
+		name    string
+		src     Sources
+		port    string
+		mode    string
+		version string
+		logpath string
+		secret  string
+		methods []string
+	}{
+		{
+			name:    "defaults only",
+			src:     Sources{},
+			port:    "8080",
+			mode:    "development",
+			version: "1.0.0",
+			logpath: "./logs",
+			secret:  "",
+			methods: []string{"GET", "POST", "OPTIONS"},
+		},
+		{
+			name:    "file over defaults",
+			src:     Sources{File: cfgFile},
+			port:    "1000",
+			mode:    "file",
+			version: "file",
+			logpath: "file",
+			secret:  "file",
+			methods: []string{"GET", "POST", "OPTIONS"},
+		},
+		{
+			name:    "env over file, empty variables ignored",
+			src:     Sources{File: cfgFile, Env: env.Lookup},
+			port:    "2000",
+			mode:    "env",
+			version: "file",
+			logpath: "file",
+			secret:  "secret-file",
+			methods: []string{"GET", "POST", "OPTIONS"},
+		},
+		{
+			name:    "set flags over env",
+			src:     Sources{File: cfgFile, Env: env.Lookup, Flags: newFlags("--app.port=3000", "--cors.allow-methods=GET,HEAD", "--unrelated=x")},
+			port:    "3000",
+			mode:    "env",
+			version: "file",
+			logpath: "file",
+			secret:  "secret-file",
+			methods: []string{"GET", "HEAD"},
+		},
+		{
+			name:    "unset flags keep lower layers",
+			src:     Sources{File: cfgFile, Env: env.Lookup, Flags: newFlags()},
+			port:    "2000",
+			mode:    "env",
+			version: "file",
+			logpath: "file",
+			secret:  "secret-file",
+			methods: []string{"GET", "POST", "OPTIONS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(tt.src)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			got := []string{cfg.App.Port, cfg.App.Mode, cfg.App.Version, cfg.App.LogPath, cfg.JwtSecret.SecretKey}
+			want := []string{tt.port, tt.mode, tt.version, tt.logpath, tt.secret}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("port, mode, version, logpath, secret = %q, want %q", got, want)
+			}
+			if !reflect.DeepEqual(cfg.CORS.AllowMethods, tt.methods) {
+				t.Errorf("cors.allow-methods = %v, want %v", cfg.CORS.AllowMethods, tt.methods)
+			}
+		})
+	}
+}
+
+func TestLoad_Independent(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := writeFile(t, dir, "config.yaml", "app:\n  port: \"1000\"\n")
+
+	first, err := Load(Sources{File: cfgFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.App.Port = "mutated"
+	first.CORS.AllowMethods[0] = "mutated"
+
+	second, err := Load(Sources{File: cfgFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.App.Port != "1000" || second.CORS.AllowMethods[0] != "GET" {
+		t.Errorf("second Load() saw changes to the first: port %q, methods %v", second.App.Port, second.CORS.AllowMethods)
+	}
+}
+
+func TestLoad_RenamedKeys(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := writeFile(t, dir, "config.yaml", "jwt:\n  enabled: true\n")
+
+	cfg, err := Load(Sources{File: cfgFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.JwtSecret.Enable {
+		t.Error("jwt.enabled set jwt.enable")
+	}
+	_, warnings := cfg.Check()
+	found := false
+	for _, w := range warnings {
+		if w.Path == "jwt.enabled" && strings.Contains(w.Message, "jwt.enable") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Check() warnings = %v, want one for jwt.enabled", warnings)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	if _, err := Load(Sources{File: filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Fatal("Load() of a missing file succeeded")
+	}
+}
+
+func TestFindFile(t *testing.T) {
+	if got, err := FindFile("explicit.yaml"); err != nil || got != "explicit.yaml" {
+		t.Errorf("FindFile(explicit) = %q, %v", got, err)
+	}
+
+	dir := t.TempDir()
+	dirs := []string{filepath.Join(dir, "config"), dir}
+	if _, err := findFile("", dirs); !errors.Is(err, ErrNoConfigFile) {
+		t.Fatalf("findFile() error = %v, want ErrNoConfigFile", err)
+	}
+
+	want := writeFile(t, dir, DefaultFileName, "")
+	if got, err := findFile("", dirs); err != nil || got != want {
+		t.Errorf("findFile() = %q, %v, want %q", got, err, want)
+	}
+}
@@ -0,0 +1,24 @@
+package configs
+
+import "time"
+
+// Lockout defines brute-force protection for token issuance
+// @Summary Lockout configuration
+// @Description Lockout configuration
+// @Tags Lockout
+// @ID lockout-conf
+type Lockout struct {
+	Enable bool `mapstructure:"enable"`
+	// MaxFailures is the number of failures within Window that triggers a lockout
+	MaxFailures int `mapstructure:"max-failures"`
+	// Window is the period over which failures are counted
+	Window time.Duration `mapstructure:"window"`
+	// BaseDelay is the backoff after the first failure, doubled for each subsequent one
+	BaseDelay time.Duration `mapstructure:"base-delay"`
+	// MaxDelay caps the progressive backoff
+	MaxDelay time.Duration `mapstructure:"max-delay"`
+	// Duration is the length of the first lockout, doubled for repeat offenders
+	Duration time.Duration `mapstructure:"duration"`
+	// MaxDuration caps the lockout length
+	MaxDuration time.Duration `mapstructure:"max-duration"`
+}
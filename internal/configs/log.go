@@ -0,0 +1,33 @@
+package configs
+
+// Log defines application and access logging
+// @Summary Log configuration
+// @Description Log configuration
+// @Tags Log
+// @ID log-conf
+type Log struct {
+	// Level is debug, info, warn or error; empty follows app.mode
+	Level string `mapstructure:"level"`
+	// Encoding is json or console; empty follows app.mode
+	Encoding string `mapstructure:"encoding"`
+	// Output is stdout, stderr or file; file writes to app.logpath/app.logfile
+	Output string `mapstructure:"output"`
+	// MaxSizeMB rotates the log file once it reaches this size
+	MaxSizeMB int `mapstructure:"max-size-mb"`
+	// MaxAgeDays removes rotated files older than this; 0 keeps them
+	MaxAgeDays int `mapstructure:"max-age-days"`
+	// MaxBackups limits retained rotated files; 0 keeps all
+	MaxBackups int         `mapstructure:"max-backups"`
+	Compress   bool        `mapstructure:"compress"`
+	Sampling   LogSampling `mapstructure:"sampling"`
+	// SkipPaths are request paths left out of the access log
+	SkipPaths []string `mapstructure:"skip-paths"`
+}
+
+// LogSampling caps repeated log lines per second: the first Initial entries
+// with the same level and message are kept, then every Thereafter-th
+type LogSampling struct {
+	Enable     bool `mapstructure:"enable"`
+	Initial    int  `mapstructure:"initial"`
+	Thereafter int  `mapstructure:"thereafter"`
+}
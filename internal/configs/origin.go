@@ -0,0 +1,38 @@
+package configs
+
+import "time"
+
+// Hotlink defines Origin/Referer validation for key requests
+// @Summary Hotlink protection configuration
+// @Description Hotlink protection configuration
+// @Tags Hotlink
+// @ID hotlink-conf
+type Hotlink struct {
+	Enable bool `mapstructure:"enable"`
+	// AllowEmpty admits requests carrying neither Origin nor Referer (native players)
+	AllowEmpty bool `mapstructure:"allow-empty"`
+	// Domains is the default allow-list; "*.example.com" matches subdomains, "*" matches any host
+	Domains []string `mapstructure:"domains"`
+	// Tenants overrides the allow-list for JWT tenants
+	Tenants []TenantDomains `mapstructure:"tenants"`
+}
+
+// TenantDomains sets the allowed embedding domains of one tenant
+type TenantDomains struct {
+	Tenant  string   `mapstructure:"tenant"`
+	Domains []string `mapstructure:"domains"`
+}
+
+// CORS defines the cross-origin policy; allowed origins come from the Hotlink allow-lists
+// @Summary CORS configuration
+// @Description CORS configuration
+// @Tags CORS
+// @ID cors-conf
+type CORS struct {
+	Enable           bool          `mapstructure:"enable"`
+	AllowCredentials bool          `mapstructure:"allow-credentials"`
+	AllowMethods     []string      `mapstructure:"allow-methods"`
+	AllowHeaders     []string      `mapstructure:"allow-headers"`
+	ExposeHeaders    []string      `mapstructure:"expose-headers"`
+	MaxAge           time.Duration `mapstructure:"max-age"`
+}
@@ -0,0 +1,32 @@
+package configs
+
+// RateLimit defines request rate limiting configuration
+// @Summary Rate limit configuration
+// @Description Rate limit configuration
+// @Tags RateLimit
+// @ID rate-limit-conf
+type RateLimit struct {
+	Enable  bool            `mapstructure:"enable"`
+	Backend string          `mapstructure:"backend"` // memory or redis
+	Redis   RedisConf       `mapstructure:"redis"`
+	Auth    RateLimitPolicy `mapstructure:"auth"`
+	Key     RateLimitPolicy `mapstructure:"key"`
+}
+
+// RateLimitPolicy defines a token bucket policy for a group of routes
+type RateLimitPolicy struct {
+	// Rate is the number of tokens refilled per second
+	Rate float64 `mapstructure:"rate"`
+	// Burst is the bucket capacity
+	Burst int `mapstructure:"burst"`
+	// By selects the limiting key: ip, subject or key
+	By string `mapstructure:"by"`
+}
+
+// RedisConf defines connection settings for a Redis-protocol store
+type RedisConf struct {
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+	Prefix   string `mapstructure:"prefix"`
+}
@@ -0,0 +1,15 @@
+package configs
+
+import "time"
+
+// Reload defines how configuration changes are picked up at runtime.
+// SIGHUP always triggers a reload; Watch also reloads when the file changes.
+// @Summary Reload configuration
+// @Description Reload configuration
+// @Tags Reload
+// @ID reload-conf
+type Reload struct {
+	Watch bool `mapstructure:"watch"`
+	// Debounce coalesces the burst of events editors produce on save
+	Debounce time.Duration `mapstructure:"debounce"`
+}
@@ -0,0 +1,24 @@
+package configs
+
+import "time"
+
+// Session defines concurrent playback session limits per JWT subject
+// @Summary Session configuration
+// @Description Session configuration
+// @Tags Session
+// @ID session-conf
+type Session struct {
+	Enable bool `mapstructure:"enable"`
+	// MaxPerSubject is the default number of simultaneous sessions per subject
+	MaxPerSubject int `mapstructure:"max-per-subject"`
+	// IdleTimeout expires sessions without key fetches for this long
+	IdleTimeout time.Duration `mapstructure:"idle-timeout"`
+	// Overrides sets per-subject limits
+	Overrides []SessionOverride `mapstructure:"overrides"`
+}
+
+// SessionOverride sets the session limit for one subject
+type SessionOverride struct {
+	Subject string `mapstructure:"subject"`
+	Max     int    `mapstructure:"max"`
+}
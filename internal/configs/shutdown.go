@@ -0,0 +1,18 @@
+package configs
+
+import "time"
+
+// Shutdown defines the graceful drain phases run on SIGINT/SIGTERM
+// @Summary Shutdown configuration
+// @Description Shutdown configuration
+// @Tags Shutdown
+// @ID shutdown-conf
+type Shutdown struct {
+	// PreStopDelay keeps serving after /readyz starts failing, so load
+	// balancers stop routing new requests before listeners close
+	PreStopDelay time.Duration `mapstructure:"pre-stop-delay"`
+	// DrainTimeout bounds waiting for in-flight requests once listeners close
+	DrainTimeout time.Duration `mapstructure:"drain-timeout"`
+	// StopTimeout bounds stopping background workers after the drain
+	StopTimeout time.Duration `mapstructure:"stop-timeout"`
+}
@@ -0,0 +1,27 @@
+package configs
+
+import "sync/atomic"
+
+// Snapshot publishes the current value of a reloadable configuration
+// section. Readers Load once per request and treat the result as read-only;
+// a reload Stores a fresh copy, so in-flight requests keep a consistent view.
+type Snapshot[T any] struct {
+	v atomic.Pointer[T]
+}
+
+// NewSnapshot creates a snapshot holding a copy of v
+func NewSnapshot[T any](v T) *Snapshot[T] {
+	s := &Snapshot[T]{}
+	s.Store(v)
+	return s
+}
+
+// Load returns the current value
+func (s *Snapshot[T]) Load() *T {
+	return s.v.Load()
+}
+
+// Store atomically replaces the current value with a copy of v
+func (s *Snapshot[T]) Store(v T) {
+	s.v.Store(&v)
+}
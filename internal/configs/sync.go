@@ -0,0 +1,32 @@
+package configs
+
+import "time"
+
+// Sync defines pull-based key replication: an origin exports its keys and
+// replicas at edge locations poll it, serving the last good copy from memory
+// @Summary Key sync configuration
+// @Description Key sync configuration
+// @Tags Sync
+// @ID sync-conf
+type Sync struct {
+	// Secret is shared by the origin and its replicas; it authenticates
+	// requests and encrypts the exported keys
+	Secret string `mapstructure:"secret"`
+	// Export serves /api/v1/sync/export on this server
+	Export bool `mapstructure:"export"`
+	// Upstream is the origin's base URL; setting it turns this server into a
+	// replica that does not own keys
+	Upstream string `mapstructure:"upstream"`
+	// Interval between pulls from Upstream
+	Interval time.Duration `mapstructure:"interval"`
+	// Timeout bounds a single pull
+	Timeout time.Duration `mapstructure:"timeout"`
+	// CacheFile keeps the last good snapshot, still encrypted, so a replica
+	// restarting while the origin is down can serve keys; empty disables it
+	CacheFile string `mapstructure:"cache-file"`
+}
+
+// Replica reports whether keys are pulled from an upstream server
+func (s Sync) Replica() bool {
+	return s.Upstream != ""
+}
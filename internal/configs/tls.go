@@ -0,0 +1,38 @@
+package configs
+
+import "time"
+
+// TLS defines native HTTPS serving and client certificate verification
+// @Summary TLS configuration
+// @Description TLS configuration
+// @Tags TLS
+// @ID tls-conf
+type TLS struct {
+	Enable   bool   `mapstructure:"enable"`
+	CertFile string `mapstructure:"cert-file"`
+	KeyFile  string `mapstructure:"key-file"`
+	// ReloadInterval is how often certificate files are checked for changes; 0 disables reload
+	ReloadInterval time.Duration `mapstructure:"reload-interval"`
+	// MinVersion is "1.2" or "1.3"
+	MinVersion string `mapstructure:"min-version"`
+	// CipherSuites lists IANA suite names for TLS 1.2; empty uses Go defaults
+	CipherSuites []string `mapstructure:"cipher-suites"`
+	// ClientAuth is none, request, require-any, verify-if-given or require
+	ClientAuth string `mapstructure:"client-auth"`
+	// ClientCAFile is the PEM bundle used to verify client certificates
+	ClientCAFile string `mapstructure:"client-ca-file"`
+	// Principals map verified client certificate identities to principals
+	Principals []CertPrincipal `mapstructure:"principals"`
+}
+
+// CertPrincipal maps a client certificate identity to a principal
+type CertPrincipal struct {
+	// Match is a glob (path.Match syntax) against the certificate's DNS/URI/email SANs or CN
+	Match string `mapstructure:"match"`
+	// Subject is the principal subject assigned to matching certificates
+	Subject string `mapstructure:"subject"`
+	// Tenant is the optional tenant of the principal
+	Tenant string `mapstructure:"tenant"`
+	// Keys restricts which key names (glob patterns) the principal may fetch; empty allows all
+	Keys []string `mapstructure:"keys"`
+}
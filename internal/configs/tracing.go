@@ -0,0 +1,21 @@
+package configs
+
+// Tracing defines OpenTelemetry trace export
+// @Summary Tracing configuration
+// @Description Tracing configuration
+// @Tags Tracing
+// @ID tracing-conf
+type Tracing struct {
+	Enable bool `mapstructure:"enable"`
+	// Exporter is otlp-grpc, otlp-http, stdout or file
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint is the OTLP collector host:port
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure disables TLS to the OTLP collector
+	Insecure bool `mapstructure:"insecure"`
+	// File receives JSON spans when Exporter is file
+	File string `mapstructure:"file"`
+	// SampleRatio is the fraction of new traces sampled; remote parent decisions are honored
+	SampleRatio float64 `mapstructure:"sample-ratio"`
+	ServiceName string  `mapstructure:"service-name"`
+}
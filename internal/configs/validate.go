@@ -0,0 +1,505 @@
+package configs
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Minimum secret lengths enforced in production mode
+const (
+	// MinJWTSecretLength matches the HS256 output size (RFC 7518 §3.2)
+	MinJWTSecretLength = 32
+	// MinPasswordLength applies to header values and basic auth passwords
+	MinPasswordLength = 16
+)
+
+// weakSecrets are placeholder and default values refused in production
+var weakSecrets = map[string]bool{
+	"admin":     true,
+	"changeme":  true,
+	"change-me": true,
+	"default":   true,
+	"password":  true,
+	"secret":    true,
+	"secretkey": true,
+	"test":      true,
+}
+
+// FieldError is one invalid configuration value, identified by its key path
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError aggregates every invalid field found by Validate
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration (%d errors):", len(e.Errors))
+	for _, fe := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(fe.Error())
+	}
+	return b.String()
+}
+
+// IsProduction reports whether app.mode is production
+func (c *Config) IsProduction() bool {
+	return strings.EqualFold(c.App.Mode, "production")
+}
+
+// IsDevelopment reports whether app.mode enables development conveniences
+// such as printing a test token
+func (c *Config) IsDevelopment() bool {
+	switch strings.ToLower(c.App.Mode) {
+	case "", "development", "debug":
+		return true
+	}
+	return false
+}
+
+// Validate checks the configuration and returns a *ValidationError listing
+// every invalid field. Weak secrets and default credentials are errors in
+// production mode and warnings otherwise; see Check.
+func (c *Config) Validate() error {
+	errs, _ := c.Check()
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// Check returns the configuration errors and the warnings that would be
+// errors in production mode
+func (c *Config) Check() (errs, warnings []FieldError) {
+	v := &validator{production: c.IsProduction()}
+
+	v.port("app.port", c.App.Port)
+
+	// Tokens are issued whether or not key routes require them
+	v.secret("jwt.secretkey", c.JwtSecret.SecretKey, MinJWTSecretLength)
+	if c.JwtSecret.Expire <= 0 {
+		v.fail("jwt.expire", "must be a positive number of minutes")
+	}
+	v.required("jwt.user", c.JwtSecret.User)
+	v.required("jwt.header-key", c.JwtSecret.HeaderKey)
+	v.secret("jwt.header-value", c.JwtSecret.HeaderValue, MinPasswordLength)
+	v.required("jwt.iss", c.JwtSecret.Iss)
+	v.required("jwt.aud", c.JwtSecret.Aud)
+
+	v.required("metric.user", c.Metric.User)
+	v.secret("metric.password", c.Metric.Password, MinPasswordLength)
+	if c.Metric.User == "admin" && c.Metric.Password == "password" {
+		v.weak("metric", "default credentials admin/password")
+	}
+
+	// An empty admin password disables the admin API
+	if c.Admin.Password != "" {
+		v.required("admin.user", c.Admin.User)
+		v.secret("admin.password", c.Admin.Password, MinPasswordLength)
+	}
+
+	if c.RateLimit.Enable {
+		v.oneOf("ratelimit.backend", c.RateLimit.Backend, "", "memory", "redis")
+		if c.RateLimit.Backend == "redis" {
+			v.required("ratelimit.redis.addr", c.RateLimit.Redis.Addr)
+		}
+		policies := []struct {
+			path   string
+			policy RateLimitPolicy
+		}{{"ratelimit.auth", c.RateLimit.Auth}, {"ratelimit.key", c.RateLimit.Key}}
+		for _, rp := range policies {
+			path, p := rp.path, rp.policy
+			if p.Rate <= 0 {
+				v.fail(path+".rate", "must be positive")
+			}
+			if p.Burst <= 0 {
+				v.fail(path+".burst", "must be positive")
+			}
+			v.oneOf(path+".by", p.By, "", "ip", "subject", "key")
+		}
+	}
+
+	if c.Lockout.Enable {
+		if c.Lockout.MaxFailures <= 0 {
+			v.fail("lockout.max-failures", "must be positive")
+		}
+		if c.Lockout.Window <= 0 {
+			v.fail("lockout.window", "must be positive")
+		}
+		if c.Lockout.Duration <= 0 {
+			v.fail("lockout.duration", "must be positive")
+		}
+	}
+
+	if c.Session.Enable {
+		if c.Session.MaxPerSubject <= 0 {
+			v.fail("session.max-per-subject", "must be positive")
+		}
+		if c.Session.IdleTimeout <= 0 {
+			v.fail("session.idle-timeout", "must be positive")
+		}
+		for i, o := range c.Session.Overrides {
+			path := fmt.Sprintf("session.overrides[%d]", i)
+			v.required(path+".subject", o.Subject)
+			if o.Max <= 0 {
+				v.fail(path+".max", "must be positive")
+			}
+		}
+	}
+
+	if c.TLS.Enable {
+		v.required("tls.cert-file", c.TLS.CertFile)
+		v.required("tls.key-file", c.TLS.KeyFile)
+		v.oneOf("tls.min-version", c.TLS.MinVersion, "", "1.2", "1.3")
+		v.oneOf("tls.client-auth", c.TLS.ClientAuth, "", "none", "request", "require-any", "verify-if-given", "require")
+		if c.TLS.ClientAuth == "verify-if-given" || c.TLS.ClientAuth == "require" {
+			v.required("tls.client-ca-file", c.TLS.ClientCAFile)
+		}
+		for i, p := range c.TLS.Principals {
+			path := fmt.Sprintf("tls.principals[%d]", i)
+			v.required(path+".match", p.Match)
+			v.required(path+".subject", p.Subject)
+		}
+	}
+
+	if c.Listener.MaxHeaderBytes <= 0 {
+		v.fail("listener.max-header-bytes", "must be positive")
+	}
+	if c.Listener.HTTP3.Enable {
+		if !c.TLS.Enable {
+			v.fail("listener.http3.enable", "requires tls.enable")
+		}
+		if c.Listener.HTTP3.Port != "" {
+			v.port("listener.http3.port", c.Listener.HTTP3.Port)
+		}
+	}
+
+	if c.Audit.Enable {
+		v.required("audit.file", c.Audit.File)
+		if c.Audit.MaxSizeMB < 0 {
+			v.fail("audit.max-size-mb", "must not be negative")
+		}
+		if c.Audit.MaxBackups < 0 {
+			v.fail("audit.max-backups", "must not be negative")
+		}
+		if c.Audit.SigningKeyFile != "" && c.Audit.CheckpointEvery <= 0 {
+			v.fail("audit.checkpoint-every", "must be positive when audit.signing-key-file is set")
+		}
+	}
+
+	if c.Tracing.Enable {
+		v.oneOf("tracing.exporter", c.Tracing.Exporter, "otlp-grpc", "otlp-http", "stdout", "file")
+		switch c.Tracing.Exporter {
+		case "otlp-grpc", "otlp-http":
+			v.required("tracing.endpoint", c.Tracing.Endpoint)
+		case "file":
+			v.required("tracing.file", c.Tracing.File)
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			v.fail("tracing.sample-ratio", "must be between 0 and 1")
+		}
+	}
+
+	if c.Log.Level != "" {
+		if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+			v.fail("log.level", "must be debug, info, warn or error")
+		}
+	}
+	v.oneOf("log.encoding", c.Log.Encoding, "", "json", "console")
+	v.oneOf("log.output", c.Log.Output, "", "stdout", "stderr", "file")
+	if c.Log.Output == "file" {
+		v.required("app.logpath", c.App.LogPath)
+		v.required("app.logfile", c.App.LogFile)
+	}
+	if c.Log.Sampling.Enable {
+		if c.Log.Sampling.Initial <= 0 {
+			v.fail("log.sampling.initial", "must be positive")
+		}
+		if c.Log.Sampling.Thereafter <= 0 {
+			v.fail("log.sampling.thereafter", "must be positive")
+		}
+	}
+
+	if c.Shutdown.PreStopDelay < 0 {
+		v.fail("shutdown.pre-stop-delay", "must not be negative")
+	}
+	if c.Shutdown.DrainTimeout <= 0 {
+		v.fail("shutdown.drain-timeout", "must be positive")
+	}
+	if c.Shutdown.StopTimeout <= 0 {
+		v.fail("shutdown.stop-timeout", "must be positive")
+	}
+
+	if c.Cluster.Enable {
+		v.required("cluster.node-id", c.Cluster.NodeID)
+		v.required("cluster.bind-addr", c.Cluster.BindAddr)
+		v.required("cluster.data-dir", c.Cluster.DataDir)
+		if c.Cluster.ApplyTimeout <= 0 {
+			v.fail("cluster.apply-timeout", "must be positive")
+		}
+		ids := make(map[string]bool, len(c.Cluster.Peers))
+		for i, p := range c.Cluster.Peers {
+			path := fmt.Sprintf("cluster.peers[%d]", i)
+			v.required(path+".id", p.ID)
+			v.required(path+".addr", p.Addr)
+			if ids[p.ID] {
+				v.fail(path+".id", fmt.Sprintf("duplicate peer id %q", p.ID))
+			}
+			ids[p.ID] = true
+		}
+		if c.Cluster.Bootstrap && !ids[c.Cluster.NodeID] {
+			v.fail("cluster.peers", "must include cluster.node-id when cluster.bootstrap is set")
+		}
+		if t := c.Cluster.TLS; t.Enabled() {
+			v.required("cluster.tls.cert-file", t.CertFile)
+			v.required("cluster.tls.key-file", t.KeyFile)
+			v.required("cluster.tls.ca-file", t.CAFile)
+		} else {
+			v.weak("cluster.tls", "peer traffic, including keys, is unencrypted")
+		}
+	}
+
+	if c.Sync.Export || c.Sync.Replica() {
+		v.secret("sync.secret", c.Sync.Secret, MinJWTSecretLength)
+	}
+	if c.Sync.Replica() {
+		if u, err := url.Parse(c.Sync.Upstream); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.fail("sync.upstream", "must be an http or https URL")
+		} else if u.Scheme == "http" {
+			v.weak("sync.upstream", "the sync token is sent without TLS")
+		}
+		if c.Sync.Interval <= 0 {
+			v.fail("sync.interval", "must be positive")
+		}
+		if c.Sync.Timeout <= 0 {
+			v.fail("sync.timeout", "must be positive")
+		}
+		if c.Cluster.Enable {
+			v.fail("sync.upstream", "cannot be combined with cluster.enable")
+		}
+	}
+
+	v.oneOf("keys.backend", c.Keys.Backend, "file", "s3", "vault")
+	if c.Keys.RefreshInterval < 0 {
+		v.fail("keys.refresh-interval", "must not be negative")
+	}
+	switch c.Keys.Backend {
+	case "file":
+		v.required("keys.dir", c.Keys.Dir)
+	case "s3":
+		if u, err := url.Parse(c.Keys.S3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.fail("keys.s3.endpoint", "must be an http or https URL")
+		} else if u.Scheme == "http" {
+			v.weak("keys.s3.endpoint", "keys are fetched without TLS")
+		}
+		v.required("keys.s3.bucket", c.Keys.S3.Bucket)
+		if (c.Keys.S3.AccessKey == "") != (c.Keys.S3.SecretKey == "") {
+			v.fail("keys.s3.secret-key", "must be set together with keys.s3.access-key")
+		}
+		if c.Keys.S3.Timeout <= 0 {
+			v.fail("keys.s3.timeout", "must be positive")
+		}
+	case "vault":
+		vc := c.Keys.Vault
+		if u, err := url.Parse(vc.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.fail("keys.vault.address", "must be an http or https URL")
+		} else if u.Scheme == "http" {
+			v.weak("keys.vault.address", "keys and tokens are sent without TLS")
+		}
+		v.required("keys.vault.mount", vc.Mount)
+		v.required("keys.vault.path", vc.Path)
+		v.required("keys.vault.field", vc.Field)
+		v.oneOf("keys.vault.auth", vc.Auth, "token", "approle")
+		switch vc.Auth {
+		case "token":
+			v.required("keys.vault.token", vc.Token)
+		case "approle":
+			v.required("keys.vault.approle-mount", vc.AppRoleMount)
+			v.required("keys.vault.role-id", vc.RoleID)
+			v.required("keys.vault.secret-id", vc.SecretID)
+		}
+		if vc.Transit.Key != "" {
+			v.required("keys.vault.transit.mount", vc.Transit.Mount)
+		}
+		if vc.Timeout <= 0 {
+			v.fail("keys.vault.timeout", "must be positive")
+		}
+	}
+	v.oneOf("keys.cache.mode", c.Keys.Cache.Mode, "", "eager", "lazy")
+	if c.Keys.Cache.Lazy() {
+		if c.Keys.Cache.Size <= 0 {
+			v.fail("keys.cache.size", "must be positive")
+		}
+		if c.Keys.Cache.TTL < 0 {
+			v.fail("keys.cache.ttl", "must not be negative")
+		}
+		if c.Keys.Backend != "file" || c.Sync.Replica() {
+			v.fail("keys.cache.mode", "lazy needs the file backend without sync.upstream")
+		}
+	}
+	v.oneOf("keys.kek.provider", c.Keys.KEK.Provider, "", "pkcs11")
+	if c.Keys.KEK.Provider == "pkcs11" {
+		p := c.Keys.KEK.PKCS11
+		v.oneOf("keys.kek.unwrap", c.Keys.KEK.Unwrap, "reload", "get")
+		// Unwrapping on reload would read every key file, defeating lazy loading
+		if c.Keys.Cache.Lazy() && c.Keys.KEK.Unwrap == "reload" {
+			v.fail("keys.kek.unwrap", "must be get when keys.cache.mode is lazy")
+		}
+		v.required("keys.kek.pkcs11.module", p.Module)
+		v.required("keys.kek.pkcs11.pin-file", p.PINFile)
+		v.required("keys.kek.pkcs11.key-label", p.KeyLabel)
+		v.oneOf("keys.kek.pkcs11.mechanism", p.Mechanism, "aes-key-wrap-pad", "aes-key-wrap")
+		// Admin writes, cluster replication and key sync carry plaintext
+		// keys, which would bypass the KEK
+		if c.Sync.Replica() || c.Sync.Export || c.Cluster.Enable {
+			v.fail("keys.kek.provider", "cannot be combined with cluster.enable, sync.export or sync.upstream")
+		}
+	}
+	// Cluster writes and replicas replace the key store, so both need the
+	// file backend
+	if c.Keys.Backend != "file" && c.Cluster.Enable {
+		v.fail("keys.backend", "must be file when cluster.enable is set")
+	}
+	if c.Keys.Backend != "file" && c.Sync.Replica() {
+		v.fail("keys.backend", "must be file when sync.upstream is set")
+	}
+
+	if c.Reload.Watch && c.Reload.Debounce < 0 {
+		v.fail("reload.debounce", "must not be negative")
+	}
+
+	if c.CORS.Enable && c.CORS.AllowCredentials {
+		if anyDomain(c.Hotlink.Domains) {
+			v.fail("hotlink.domains", `must not contain "*" when cors.allow-credentials is set`)
+		}
+		for i, t := range c.Hotlink.Tenants {
+			if anyDomain(t.Domains) {
+				v.fail(fmt.Sprintf("hotlink.tenants[%d].domains", i), `must not contain "*" when cors.allow-credentials is set`)
+			}
+		}
+	}
+
+	// Tenant rules match the "tenant" claim or a client-certificate tenant;
+	// without jwt.tenant only tokens from an external issuer carry one
+	if path := c.tenantRulePath(); path != "" && c.JwtSecret.Tenant == "" && !c.certTenants() {
+		v.warnings = append(v.warnings, FieldError{Path: path, Message: "never matches tokens issued by this server; set jwt.tenant or use an external issuer or tls.principals tenants"})
+	}
+
+	for _, old := range c.renamed {
+		v.warnings = append(v.warnings, FieldError{Path: old, Message: "is ignored; it was renamed to " + renamedKeys[old]})
+	}
+
+	return v.errs, v.warnings
+}
+
+// validator collects field errors; weak values are errors only in production
+type validator struct {
+	production bool
+	errs       []FieldError
+	warnings   []FieldError
+}
+
+func (v *validator) fail(path, msg string) {
+	v.errs = append(v.errs, FieldError{Path: path, Message: msg})
+}
+
+func (v *validator) weak(path, msg string) {
+	if v.production {
+		v.fail(path, msg+" (not allowed in production)")
+		return
+	}
+	v.warnings = append(v.warnings, FieldError{Path: path, Message: msg})
+}
+
+func (v *validator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.fail(path, "is required")
+	}
+}
+
+func (v *validator) secret(path, value string, minLen int) {
+	if value == "" {
+		v.fail(path, "is required")
+		return
+	}
+	if weakSecrets[strings.ToLower(value)] {
+		v.weak(path, "is a well-known default value")
+		return
+	}
+	if len(value) < minLen {
+		v.weak(path, fmt.Sprintf("is shorter than %d characters", minLen))
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	var names []string
+	for _, a := range allowed {
+		if a != "" {
+			names = append(names, a)
+		}
+	}
+	v.fail(path, fmt.Sprintf("must be one of %s, got %q", strings.Join(names, ", "), value))
+}
+
+func (v *validator) port(path, value string) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 65535 {
+		v.fail(path, fmt.Sprintf("must be a port number between 1 and 65535, got %q", value))
+	}
+}
+
+// anyDomain reports whether an allow-list contains the "*" pattern
+func anyDomain(domains []string) bool {
+	for _, d := range domains {
+		if strings.TrimSpace(d) == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantRulePath returns the path of the first enabled rule scoped to a
+// tenant, or "" when there is none
+func (c *Config) tenantRulePath() string {
+	if c.IPPolicy.Enable {
+		for i, r := range c.IPPolicy.Rules {
+			if len(r.Tenants) > 0 {
+				return fmt.Sprintf("ippolicy.rules[%d].tenants", i)
+			}
+		}
+	}
+	if c.Hotlink.Enable && len(c.Hotlink.Tenants) > 0 {
+		return "hotlink.tenants"
+	}
+	return ""
+}
+
+// certTenants reports whether a client-certificate principal has a tenant
+func (c *Config) certTenants() bool {
+	if !c.TLS.Enable {
+		return false
+	}
+	for _, p := range c.TLS.Principals {
+		if p.Tenant != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package configs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() *Config {
+	return &Config{
+		App: AppConf{Mode: "production", Port: "9090"},
+		JwtSecret: JwtSecret{
+			SecretKey:   strings.Repeat("k", MinJWTSecretLength),
+			Expire:      10,
+			User:        "player",
+			HeaderKey:   "X-Player-Key",
+			HeaderValue: strings.Repeat("h", MinPasswordLength),
+			Iss:         "hls-key-server",
+			Aud:         "hls-key-api",
+		},
+		Metric:   Metric{User: "prometheus", Password: strings.Repeat("m", MinPasswordLength)},
+		Listener: Listener{MaxHeaderBytes: 1 << 20},
+		Log:      Log{Output: "stdout"},
+		Shutdown: Shutdown{DrainTimeout: time.Second, StopTimeout: time.Second},
+		Keys:     KeyStore{Backend: "file", Dir: "./keys"},
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(*Config)
+		wantPaths []string
+	}{
+		{name: "valid", mutate: func(*Config) {}},
+		{
+			name: "missing jwt fields",
+			mutate: func(c *Config) {
+				c.JwtSecret.SecretKey = ""
+				c.JwtSecret.Expire = 0
+				c.JwtSecret.HeaderValue = ""
+			},
+			wantPaths: []string{"jwt.secretkey", "jwt.expire", "jwt.header-value"},
+		},
+		{
+			name: "weak secrets in production",
+			mutate: func(c *Config) {
+				c.JwtSecret.SecretKey = "short"
+				c.Metric = Metric{User: "admin", Password: "password"}
+				c.Admin = Admin{User: "admin", Password: "changeme"}
+			},
+			wantPaths: []string{"jwt.secretkey", "metric.password", "metric", "admin.password"},
+		},
+		{
+			name: "weak secrets outside production",
+			mutate: func(c *Config) {
+				c.App.Mode = "development"
+				c.JwtSecret.SecretKey = "short"
+				c.Metric = Metric{User: "admin", Password: "password"}
+			},
+		},
+		{
+			name: "enabled sections",
+			mutate: func(c *Config) {
+				c.App.Port = "http"
+				c.RateLimit = RateLimit{Enable: true, Backend: "etcd", Auth: RateLimitPolicy{Rate: 1, Burst: 1, By: "ip"}}
+				c.Listener.HTTP3.Enable = true
+				c.Tracing = Tracing{Enable: true, Exporter: "file", SampleRatio: 2}
+				c.Log.Level = "verbose"
+				c.Shutdown.DrainTimeout = 0
+			},
+			wantPaths: []string{
+				"app.port", "ratelimit.backend", "ratelimit.key.rate", "ratelimit.key.burst",
+				"listener.http3.enable", "tracing.file", "tracing.sample-ratio", "log.level",
+				"shutdown.drain-timeout",
+			},
+		},
+		{
+			name: "cluster",
+			mutate: func(c *Config) {
+				c.Cluster = Cluster{
+					Enable:       true,
+					NodeID:       "node-0",
+					BindAddr:     "127.0.0.1:7000",
+					DataDir:      "./data/raft",
+					Bootstrap:    true,
+					ApplyTimeout: time.Second,
+					Peers:        []ClusterPeer{{ID: "node-1", Addr: "10.0.0.1:7000"}, {ID: "node-1"}},
+					TLS:          ClusterTLS{CertFile: "peer.crt"},
+				}
+			},
+			wantPaths: []string{
+				"cluster.peers[1].addr", "cluster.peers[1].id", "cluster.peers",
+				"cluster.tls.key-file", "cluster.tls.ca-file",
+			},
+		},
+		{
+			name: "sync replica",
+			mutate: func(c *Config) {
+				c.Cluster = Cluster{Enable: true, NodeID: "node-0", BindAddr: "127.0.0.1:7000", DataDir: "./data/raft", ApplyTimeout: time.Second, TLS: ClusterTLS{CertFile: "a", KeyFile: "b", CAFile: "c"}}
+				c.Sync = Sync{Upstream: "origin.example.com"}
+			},
+			wantPaths: []string{"sync.secret", "sync.upstream", "sync.interval", "sync.timeout", "sync.upstream"},
+		},
+		{
+			name: "plain http sync upstream",
+			mutate: func(c *Config) {
+				c.Sync = Sync{Secret: strings.Repeat("s", MinJWTSecretLength), Upstream: "http://origin.example.com", Interval: time.Second, Timeout: time.Second}
+			},
+			wantPaths: []string{"sync.upstream"},
+		},
+		{
+			name: "s3 keys",
+			mutate: func(c *Config) {
+				c.Keys = KeyStore{Backend: "s3", RefreshInterval: -time.Second, S3: KeyStoreS3{Endpoint: "http://minio:9000", AccessKey: "AKIA"}}
+				c.Sync = Sync{Secret: strings.Repeat("s", MinJWTSecretLength), Upstream: "https://origin.example.com", Interval: time.Second, Timeout: time.Second}
+			},
+			wantPaths: []string{"keys.refresh-interval", "keys.s3.endpoint", "keys.s3.bucket", "keys.s3.secret-key", "keys.s3.timeout", "keys.backend"},
+		},
+		{
+			name: "vault keys",
+			mutate: func(c *Config) {
+				c.Keys = KeyStore{Backend: "vault", Vault: KeyStoreVault{Address: "vault:8200", Mount: "secret", Path: "hls/keys", Field: "key", Auth: "approle", RoleID: "role", Timeout: time.Second}}
+			},
+			wantPaths: []string{"keys.vault.address", "keys.vault.approle-mount", "keys.vault.secret-id"},
+		},
+		{
+			name: "pkcs11 kek",
+			mutate: func(c *Config) {
+				c.Keys.KEK = KeyStoreKEK{Provider: "pkcs11", Unwrap: "always", PKCS11: KEKPKCS11{Module: "/usr/lib/softhsm/libsofthsm2.so", KeyLabel: "hls-kek", Mechanism: "aes-key-wrap-pad"}}
+				c.Sync.Export = true
+				c.Sync.Secret = strings.Repeat("s", MinJWTSecretLength)
+			},
+			wantPaths: []string{"keys.kek.unwrap", "keys.kek.pkcs11.pin-file", "keys.kek.provider"},
+		},
+		{
+			name: "lazy key cache",
+			mutate: func(c *Config) {
+				c.Keys = KeyStore{Backend: "s3", S3: KeyStoreS3{Endpoint: "https://s3.amazonaws.com", Bucket: "keys", Timeout: time.Second}, Cache: KeyStoreCache{Mode: "lazy", TTL: -time.Second}}
+			},
+			wantPaths: []string{"keys.cache.size", "keys.cache.ttl", "keys.cache.mode"},
+		},
+		{
+			name: "lazy key cache with unwrap on reload",
+			mutate: func(c *Config) {
+				c.Keys.Cache = KeyStoreCache{Mode: "lazy", Size: 100}
+				c.Keys.KEK = KeyStoreKEK{Provider: "pkcs11", Unwrap: "reload", PKCS11: KEKPKCS11{Module: "/usr/lib/softhsm/libsofthsm2.so", PINFile: "./hsm.pin", KeyLabel: "hls-kek", Mechanism: "aes-key-wrap-pad"}}
+			},
+			wantPaths: []string{"keys.kek.unwrap"},
+		},
+		{
+			name: "credentialed cors with any origin",
+			mutate: func(c *Config) {
+				c.CORS = CORS{Enable: true, AllowCredentials: true}
+				c.Hotlink = Hotlink{Domains: []string{"*"}, Tenants: []TenantDomains{{Tenant: "acme", Domains: []string{"*.acme.tv"}}, {Tenant: "open", Domains: []string{" * "}}}}
+			},
+			wantPaths: []string{"hotlink.domains", "hotlink.tenants[1].domains"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantPaths) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+			got := make([]string, 0, len(verr.Errors))
+			for _, fe := range verr.Errors {
+				got = append(got, fe.Path)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantPaths, ",") {
+				t.Errorf("error paths = %v, want %v", got, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestConfig_CheckWarnings(t *testing.T) {
+	cfg := validConfig()
+	cfg.App.Mode = "development"
+	cfg.JwtSecret.SecretKey = "secret"
+
+	errs, warnings := cfg.Check()
+	if len(errs) != 0 {
+		t.Errorf("Check() errors = %v", errs)
+	}
+	if len(warnings) != 1 || warnings[0].Path != "jwt.secretkey" {
+		t.Errorf("Check() warnings = %v", warnings)
+	}
+}
+
+func TestConfig_CheckTenantRulesWarning(t *testing.T) {
+	cfg := validConfig()
+	cfg.IPPolicy = IPPolicy{Enable: true, Rules: []IPPolicyRule{{Name: "acme", Tenants: []string{"acme"}, Allow: []string{"10.0.0.0/8"}}}}
+
+	_, warnings := cfg.Check()
+	if len(warnings) != 1 || warnings[0].Path != "ippolicy.rules[0].tenants" {
+		t.Fatalf("Check() warnings = %v, want ippolicy.rules[0].tenants", warnings)
+	}
+
+	cfg.JwtSecret.Tenant = "acme"
+	if _, warnings := cfg.Check(); len(warnings) != 0 {
+		t.Errorf("Check() with jwt.tenant warnings = %v", warnings)
+	}
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/handler/middleware"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/repository"
+)
+
+// maxKeyBytes bounds uploaded key material; AES-128 keys are 16 bytes
+const maxKeyBytes = 4096
+
+// ClusterMembership reports and changes cluster membership
+type ClusterMembership interface {
+	Status() (repository.ClusterStatus, error)
+	AddVoter(ctx context.Context, id, addr string) error
+	RemoveServer(ctx context.Context, id string) error
+}
+
+// WithKeyWriter enables key uploads and deletion; in cluster mode w
+// replicates the change to every node
+func WithKeyWriter(w repository.KeyWriter) AdminHandlerOption {
+	return func(h *AdminHandler) {
+		h.keys = w
+	}
+}
+
+// WithCluster enables the cluster status and membership endpoints
+func WithCluster(cluster ClusterMembership) AdminHandlerOption {
+	return func(h *AdminHandler) {
+		h.cluster = cluster
+	}
+}
+
+// PutKey handles uploading a key
+// @Summary Upload key
+// @Description Creates or replaces a key with the raw request body. In cluster mode the write is replicated and only the leader accepts it; followers redirect to the leader when its API URL is known.
+// @Tags Admin
+// @Accept application/octet-stream
+// @Produce json
+// @Security BasicAuth
+// @Param name path string true "Key file name"
+// @Success 200 {object} map[string]interface{} "Stored key name and size"
+// @Failure 400 {object} map[string]string "Invalid key name or empty body"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 413 {object} map[string]string "Key too large"
+// @Failure 501 {object} map[string]string "Key writes unavailable"
+// @Failure 503 {object} map[string]string "Not the cluster leader"
+// @Router /api/v1/admin/keys/{name} [put]
+func (h *AdminHandler) PutKey(c *gin.Context) {
+	if h.keys == nil {
+		c.JSON(http.StatusNotImplemented, middleware.ErrorBody(c, "Key writes unavailable"))
+		return
+	}
+
+	name := c.Param("name")
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxKeyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, middleware.ErrorBody(c, "Key too large"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid request body"))
+		return
+	}
+	if len(data) == 0 {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Empty key"))
+		return
+	}
+
+	if err := h.keys.Put(c.Request.Context(), name, data); err != nil {
+		h.writeError(c, "put key", name, err)
+		return
+	}
+
+	// Never log key material, only its size
+	logger.FromContext(c.Request.Context(), h.logger).Info("admin stored key",
+		zap.String("key_name", name),
+		zap.Int("size", len(data)),
+		zap.String("ip", c.ClientIP()),
+	)
+	h.auditChange(c, "put_key", map[string]string{"key": name, "size": strconv.Itoa(len(data))})
+	c.JSON(http.StatusOK, gin.H{"key": name, "size": len(data)})
+}
+
+// DeleteKey handles removing a key
+// @Summary Delete key
+// @Description Removes a key; deleting a missing key succeeds. Cluster followers behave as for uploads.
+// @Tags Admin
+// @Produce json
+// @Security BasicAuth
+// @Param name path string true "Key file name"
+// @Success 200 {object} map[string]string "Deleted key name"
+// @Failure 400 {object} map[string]string "Invalid key name"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 501 {object} map[string]string "Key writes unavailable"
+// @Failure 503 {object} map[string]string "Not the cluster leader"
+// @Router /api/v1/admin/keys/{name} [delete]
+func (h *AdminHandler) DeleteKey(c *gin.Context) {
+	if h.keys == nil {
+		c.JSON(http.StatusNotImplemented, middleware.ErrorBody(c, "Key writes unavailable"))
+		return
+	}
+
+	name := c.Param("name")
+	if err := h.keys.Delete(c.Request.Context(), name); err != nil {
+		h.writeError(c, "delete key", name, err)
+		return
+	}
+
+	logger.FromContext(c.Request.Context(), h.logger).Info("admin deleted key",
+		zap.String("key_name", name),
+		zap.String("ip", c.ClientIP()),
+	)
+	h.auditChange(c, "delete_key", map[string]string{"key": name})
+	c.JSON(http.StatusOK, gin.H{"deleted": name})
+}
+
+// GetCluster handles reading cluster status
+// @Summary Cluster status
+// @Description Returns this node's Raft state, the current leader and the cluster members
+// @Tags Admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} repository.ClusterStatus "Cluster status"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 501 {object} map[string]string "Cluster mode disabled"
+// @Router /api/v1/admin/cluster [get]
+func (h *AdminHandler) GetCluster(c *gin.Context) {
+	if h.cluster == nil {
+		c.JSON(http.StatusNotImplemented, middleware.ErrorBody(c, "Cluster mode disabled"))
+		return
+	}
+
+	status, err := h.cluster.Status()
+	if err != nil {
+		logger.FromContext(c.Request.Context(), h.logger).Error("failed to read cluster status", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "Cluster status unavailable"))
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// ClusterMemberRequest adds a node to the cluster
+type ClusterMemberRequest struct {
+	// ID is the new node's cluster.node-id
+	ID string `json:"id" binding:"required"`
+	// Addr is the new node's Raft address (cluster.advertise-addr)
+	Addr string `json:"addr" binding:"required"`
+}
+
+// AddClusterMember handles adding a voting member
+// @Summary Add cluster member
+// @Description Adds a voting member; only the leader changes membership
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param request body ClusterMemberRequest true "New member"
+// @Success 200 {object} repository.ClusterStatus "Cluster status"
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 501 {object} map[string]string "Cluster mode disabled"
+// @Failure 503 {object} map[string]string "Not the cluster leader"
+// @Router /api/v1/admin/cluster/members [post]
+func (h *AdminHandler) AddClusterMember(c *gin.Context) {
+	if h.cluster == nil {
+		c.JSON(http.StatusNotImplemented, middleware.ErrorBody(c, "Cluster mode disabled"))
+		return
+	}
+
+	var req ClusterMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid request body"))
+		return
+	}
+	if err := h.cluster.AddVoter(c.Request.Context(), req.ID, req.Addr); err != nil {
+		h.writeError(c, "add cluster member", req.ID, err)
+		return
+	}
+
+	logger.FromContext(c.Request.Context(), h.logger).Info("admin added cluster member",
+		zap.String("node_id", req.ID),
+		zap.String("addr", req.Addr),
+		zap.String("ip", c.ClientIP()),
+	)
+	h.auditChange(c, "add_member", map[string]string{"node_id": req.ID, "addr": req.Addr})
+	h.GetCluster(c)
+}
+
+// RemoveClusterMember handles removing a member
+// @Summary Remove cluster member
+// @Description Removes a member; only the leader changes membership
+// @Tags Admin
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "Node ID"
+// @Success 200 {object} repository.ClusterStatus "Cluster status"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 501 {object} map[string]string "Cluster mode disabled"
+// @Failure 503 {object} map[string]string "Not the cluster leader"
+// @Router /api/v1/admin/cluster/members/{id} [delete]
+func (h *AdminHandler) RemoveClusterMember(c *gin.Context) {
+	if h.cluster == nil {
+		c.JSON(http.StatusNotImplemented, middleware.ErrorBody(c, "Cluster mode disabled"))
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.cluster.RemoveServer(c.Request.Context(), id); err != nil {
+		h.writeError(c, "remove cluster member", id, err)
+		return
+	}
+
+	logger.FromContext(c.Request.Context(), h.logger).Info("admin removed cluster member",
+		zap.String("node_id", id),
+		zap.String("ip", c.ClientIP()),
+	)
+	h.auditChange(c, "remove_member", map[string]string{"node_id": id})
+	h.GetCluster(c)
+}
+
+// writeError maps a key or membership change error to a response. Writes
+// sent to a follower are redirected to the leader when its API URL is
+// configured, so clients retry there with the same method and body.
+func (h *AdminHandler) writeError(c *gin.Context, action, target string, err error) {
+	log := logger.FromContext(c.Request.Context(), h.logger)
+
+	var notLeader *repository.NotLeaderError
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidKeyName):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid key name"))
+	case errors.As(err, &notLeader) && notLeader.LeaderURL != "":
+		c.Redirect(http.StatusTemporaryRedirect, notLeader.LeaderURL+c.Request.URL.RequestURI())
+	case errors.As(err, &notLeader):
+		body := middleware.ErrorBody(c, "Not the cluster leader")
+		body["leader_id"] = notLeader.LeaderID
+		c.JSON(http.StatusServiceUnavailable, body)
+	default:
+		log.Error("admin "+action+" failed", zap.String("target", target), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "Internal server error"))
+	}
+}
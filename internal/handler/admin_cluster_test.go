@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/repository"
+	"hls-key-server-go/internal/service"
+)
+
+// fakeCluster is a follower or leader with a fixed membership
+type fakeCluster struct {
+	leader    bool
+	leaderURL string
+	added     []string
+	removed   []string
+}
+
+func (f *fakeCluster) notLeader() error {
+	return &repository.NotLeaderError{LeaderID: "node-1", LeaderAddr: "10.0.0.1:7000", LeaderURL: f.leaderURL}
+}
+
+func (f *fakeCluster) Status() (repository.ClusterStatus, error) {
+	return repository.ClusterStatus{
+		NodeID:   "node-0",
+		State:    "Follower",
+		LeaderID: "node-1",
+		Members: []repository.ClusterMember{
+			{ID: "node-0", Addr: "10.0.0.0:7000", Suffrage: "Voter"},
+			{ID: "node-1", Addr: "10.0.0.1:7000", Suffrage: "Voter", Leader: true},
+		},
+	}, nil
+}
+
+func (f *fakeCluster) AddVoter(_ context.Context, id, _ string) error {
+	if !f.leader {
+		return f.notLeader()
+	}
+	f.added = append(f.added, id)
+	return nil
+}
+
+func (f *fakeCluster) RemoveServer(_ context.Context, id string) error {
+	if !f.leader {
+		return f.notLeader()
+	}
+	f.removed = append(f.removed, id)
+	return nil
+}
+
+// followerWriter rejects writes the way a cluster follower does
+type followerWriter struct{ cluster *fakeCluster }
+
+func (w followerWriter) Put(context.Context, string, []byte) error { return w.cluster.notLeader() }
+func (w followerWriter) Delete(context.Context, string) error      { return w.cluster.notLeader() }
+
+func newTestKeyAdminRouter(t *testing.T, opts ...AdminHandlerOption) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	lockouts := service.NewLockoutService(configs.Lockout{}, zap.NewNop())
+	h := NewAdminHandler(configs.NewSnapshot(configs.Admin{User: "root", Password: "secret"}), lockouts, nil, zap.NewNop(), opts...)
+
+	router := gin.New()
+	admin := router.Group("/api/v1/admin", h.BasicAuth())
+	admin.PUT("/keys/:name", h.PutKey)
+	admin.DELETE("/keys/:name", h.DeleteKey)
+	admin.GET("/cluster", h.GetCluster)
+	admin.POST("/cluster/members", h.AddClusterMember)
+	admin.DELETE("/cluster/members/:id", h.RemoveClusterMember)
+	return router
+}
+
+func adminRequest(router *gin.Engine, method, path string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.SetBasicAuth("root", "secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminHandler_Keys(t *testing.T) {
+	repo, err := repository.NewFileKeyRepository(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newTestKeyAdminRouter(t, WithKeyWriter(repo))
+	content := []byte("0123456789abcdef")
+
+	if w := adminRequest(router, http.MethodPut, "/api/v1/admin/keys/new.key", content); w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, body %s", w.Code, w.Body)
+	}
+	if got, err := repo.Get(context.Background(), "new.key"); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("stored key = %q, %v", got, err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   []byte
+		want   int
+	}{
+		{name: "invalid name", method: http.MethodPut, path: "/api/v1/admin/keys/new.txt", body: content, want: http.StatusBadRequest},
+		{name: "empty body", method: http.MethodPut, path: "/api/v1/admin/keys/empty.key", want: http.StatusBadRequest},
+		{name: "too large", method: http.MethodPut, path: "/api/v1/admin/keys/big.key", body: make([]byte, maxKeyBytes+1), want: http.StatusRequestEntityTooLarge},
+		{name: "delete", method: http.MethodDelete, path: "/api/v1/admin/keys/new.key", want: http.StatusOK},
+		{name: "delete missing", method: http.MethodDelete, path: "/api/v1/admin/keys/new.key", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := adminRequest(router, tt.method, tt.path, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_KeysUnavailable(t *testing.T) {
+	router := newTestKeyAdminRouter(t)
+	if w := adminRequest(router, http.MethodPut, "/api/v1/admin/keys/new.key", []byte("x")); w.Code != http.StatusNotImplemented {
+		t.Errorf("PUT without key writer = %d, want %d", w.Code, http.StatusNotImplemented)
+	}
+	if w := adminRequest(router, http.MethodGet, "/api/v1/admin/cluster", nil); w.Code != http.StatusNotImplemented {
+		t.Errorf("GET cluster without cluster = %d, want %d", w.Code, http.StatusNotImplemented)
+	}
+}
+
+func TestAdminHandler_FollowerWrites(t *testing.T) {
+	t.Run("redirect to known leader", func(t *testing.T) {
+		cluster := &fakeCluster{leaderURL: "https://node-1.example.com:8443"}
+		router := newTestKeyAdminRouter(t, WithKeyWriter(followerWriter{cluster}), WithCluster(cluster))
+
+		w := adminRequest(router, http.MethodPut, "/api/v1/admin/keys/new.key", []byte("x"))
+		if w.Code != http.StatusTemporaryRedirect {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusTemporaryRedirect)
+		}
+		if got, want := w.Header().Get("Location"), "https://node-1.example.com:8443/api/v1/admin/keys/new.key"; got != want {
+			t.Errorf("Location = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("unavailable without leader url", func(t *testing.T) {
+		cluster := &fakeCluster{}
+		router := newTestKeyAdminRouter(t, WithKeyWriter(followerWriter{cluster}), WithCluster(cluster))
+
+		w := adminRequest(router, http.MethodDelete, "/api/v1/admin/keys/new.key", nil)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+		if !strings.Contains(w.Body.String(), `"leader_id":"node-1"`) {
+			t.Errorf("body %s does not name the leader", w.Body)
+		}
+	})
+}
+
+func TestAdminHandler_Cluster(t *testing.T) {
+	cluster := &fakeCluster{leader: true}
+	router := newTestKeyAdminRouter(t, WithCluster(cluster))
+
+	w := adminRequest(router, http.MethodGet, "/api/v1/admin/cluster", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d", w.Code)
+	}
+	var status repository.ClusterStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.LeaderID != "node-1" || len(status.Members) != 2 {
+		t.Errorf("status = %+v", status)
+	}
+
+	w = adminRequest(router, http.MethodPost, "/api/v1/admin/cluster/members", []byte(`{"id":"node-2","addr":"10.0.0.2:7000"}`))
+	if w.Code != http.StatusOK || len(cluster.added) != 1 || cluster.added[0] != "node-2" {
+		t.Errorf("POST member status = %d, added %v", w.Code, cluster.added)
+	}
+	if w := adminRequest(router, http.MethodPost, "/api/v1/admin/cluster/members", []byte(`{"id":"node-3"}`)); w.Code != http.StatusBadRequest {
+		t.Errorf("POST member without addr = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	w = adminRequest(router, http.MethodDelete, "/api/v1/admin/cluster/members/node-2", nil)
+	if w.Code != http.StatusOK || len(cluster.removed) != 1 {
+		t.Errorf("DELETE member status = %d, removed %v", w.Code, cluster.removed)
+	}
+}
@@ -0,0 +1,296 @@
+// Package handler provides HTTP request handlers including management endpoints.
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/handler/middleware"
+	"hls-key-server-go/internal/pkg/audit"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/repository"
+	"hls-key-server-go/internal/service"
+)
+
+// AdminHandler handles management endpoints protected by admin basic auth
+type AdminHandler struct {
+	config    *configs.Snapshot[configs.Admin]
+	lockouts  *service.LockoutService
+	audit     *audit.Logger
+	logLevels *logger.Levels
+	keys      repository.KeyWriter
+	cluster   ClusterMembership
+	logger    *zap.Logger
+}
+
+// AdminHandlerOption configures optional AdminHandler behavior
+type AdminHandlerOption func(*AdminHandler)
+
+// WithLogLevels enables runtime log level control
+func WithLogLevels(levels *logger.Levels) AdminHandlerOption {
+	return func(h *AdminHandler) {
+		h.logLevels = levels
+	}
+}
+
+// NewAdminHandler creates a new admin handler; auditor may be nil.
+// Credentials are read from config on every request.
+func NewAdminHandler(config *configs.Snapshot[configs.Admin], lockouts *service.LockoutService, auditor *audit.Logger, logger *zap.Logger, opts ...AdminHandlerOption) *AdminHandler {
+	h := &AdminHandler{
+		config:   config,
+		lockouts: lockouts,
+		audit:    auditor,
+		logger:   logger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// BasicAuth provides basic authentication middleware for admin endpoints.
+// All requests are rejected when no admin password is configured.
+func (h *AdminHandler) BasicAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.FromContext(c.Request.Context(), h.logger)
+
+		cfg := h.config.Load()
+		if cfg.Password == "" {
+			log.Warn("admin endpoint accessed but admin credentials are not configured",
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, middleware.ErrorBody(c, "Admin API disabled"))
+			return
+		}
+
+		user, pass, ok := c.Request.BasicAuth()
+		userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(cfg.User)) == 1
+		passMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(cfg.Password)) == 1
+
+		if !ok || !userMatch || !passMatch {
+			log.Warn("admin endpoint accessed with invalid credentials",
+				zap.String("user", user),
+				zap.String("ip", c.ClientIP()),
+			)
+			c.Header("WWW-Authenticate", `Basic realm="admin"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ListLockouts handles listing active authentication lockouts
+// @Summary List lockouts
+// @Description Lists IPs and usernames currently locked out of token issuance
+// @Tags Admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} map[string][]service.Lockout "Active lockouts"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /api/v1/admin/lockouts [get]
+func (h *AdminHandler) ListLockouts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"lockouts": h.lockouts.List(c.Request.Context())})
+}
+
+// ClearLockouts handles clearing all authentication lockouts
+// @Summary Clear all lockouts
+// @Description Clears every failure counter and lockout
+// @Tags Admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} map[string]int "Number of cleared entries"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /api/v1/admin/lockouts [delete]
+func (h *AdminHandler) ClearLockouts(c *gin.Context) {
+	log := logger.FromContext(c.Request.Context(), h.logger)
+
+	n := h.lockouts.ClearAll(c.Request.Context())
+	log.Info("admin cleared all lockouts",
+		zap.Int("count", n),
+		zap.String("ip", c.ClientIP()),
+	)
+	h.auditChange(c, "clear_lockouts", map[string]string{"count": strconv.Itoa(n)})
+	c.JSON(http.StatusOK, gin.H{"cleared": n})
+}
+
+// ClearLockout handles clearing a single IP or username lockout
+// @Summary Clear lockout
+// @Description Clears the failure counter and lockout for one IP or username
+// @Tags Admin
+// @Produce json
+// @Security BasicAuth
+// @Param scope path string true "Lockout scope (ip or user)"
+// @Param value path string true "IP address or username"
+// @Success 200 {object} map[string]int "Number of cleared entries"
+// @Failure 400 {object} map[string]string "Invalid scope"
+// @Failure 404 {object} map[string]string "Lockout not found"
+// @Router /api/v1/admin/lockouts/{scope}/{value} [delete]
+func (h *AdminHandler) ClearLockout(c *gin.Context) {
+	log := logger.FromContext(c.Request.Context(), h.logger)
+
+	scope := c.Param("scope")
+	value := c.Param("value")
+
+	if scope != "ip" && scope != "user" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid scope"))
+		return
+	}
+
+	if !h.lockouts.Clear(c.Request.Context(), scope, value) {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "Lockout not found"))
+		return
+	}
+
+	log.Info("admin cleared lockout",
+		zap.String("scope", scope),
+		zap.String("value", value),
+		zap.String("ip", c.ClientIP()),
+	)
+	h.auditChange(c, "clear_lockout", map[string]string{"scope": scope, "value": value})
+	c.JSON(http.StatusOK, gin.H{"cleared": 1})
+}
+
+// LogLevelRequest changes the root or a component log level
+type LogLevelRequest struct {
+	// Level is debug, info, warn or error
+	Level string `json:"level" binding:"required"`
+	// Component is auth, hls, repository or cluster; empty sets the root level
+	Component string `json:"component"`
+	// Duration such as "15m" after which the change is reverted; empty keeps it
+	Duration string `json:"duration"`
+}
+
+// GetLogLevel handles reading the runtime log levels
+// @Summary Get log levels
+// @Description Returns the root log level and the effective level of each component
+// @Tags Admin
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} map[string]interface{} "Root and component levels"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 501 {object} map[string]string "Log level control unavailable"
+// @Router /api/v1/admin/log-level [get]
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	if h.logLevels == nil {
+		c.JSON(http.StatusNotImplemented, middleware.ErrorBody(c, "Log level control unavailable"))
+		return
+	}
+
+	root, components := h.logLevels.Status()
+	c.JSON(http.StatusOK, gin.H{"root": root, "components": components})
+}
+
+// SetLogLevel handles changing the root or a component log level
+// @Summary Set log level
+// @Description Sets the root or a component log level, optionally reverting after a duration
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param request body LogLevelRequest true "Level change"
+// @Success 200 {object} map[string]interface{} "Root and component levels"
+// @Failure 400 {object} map[string]string "Invalid level, component or duration"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 501 {object} map[string]string "Log level control unavailable"
+// @Router /api/v1/admin/log-level [put]
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	log := logger.FromContext(c.Request.Context(), h.logger)
+
+	if h.logLevels == nil {
+		c.JSON(http.StatusNotImplemented, middleware.ErrorBody(c, "Log level control unavailable"))
+		return
+	}
+
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid request body"))
+		return
+	}
+	level, err := zapcore.ParseLevel(req.Level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid level"))
+		return
+	}
+	var revertAfter time.Duration
+	if req.Duration != "" {
+		revertAfter, err = time.ParseDuration(req.Duration)
+		if err != nil || revertAfter <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid duration"))
+			return
+		}
+	}
+
+	if err := h.logLevels.Set(req.Component, level, revertAfter); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Unknown component"))
+		return
+	}
+
+	log.Info("admin changed log level",
+		zap.String("component", req.Component),
+		zap.String("level", level.String()),
+		zap.Duration("revert_after", revertAfter),
+		zap.String("ip", c.ClientIP()),
+	)
+	h.auditChange(c, "set_log_level", map[string]string{
+		"component": req.Component,
+		"level":     level.String(),
+		"duration":  req.Duration,
+	})
+
+	root, components := h.logLevels.Status()
+	c.JSON(http.StatusOK, gin.H{"root": root, "components": components})
+}
+
+// ResetLogLevel handles removing a component log level override
+// @Summary Reset component log level
+// @Description Removes a component override so it follows the root level again
+// @Tags Admin
+// @Produce json
+// @Security BasicAuth
+// @Param component path string true "Component (auth, hls, repository or cluster)"
+// @Success 200 {object} map[string]interface{} "Root and component levels"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {object} map[string]string "Unknown component"
+// @Failure 501 {object} map[string]string "Log level control unavailable"
+// @Router /api/v1/admin/log-level/{component} [delete]
+func (h *AdminHandler) ResetLogLevel(c *gin.Context) {
+	log := logger.FromContext(c.Request.Context(), h.logger)
+
+	if h.logLevels == nil {
+		c.JSON(http.StatusNotImplemented, middleware.ErrorBody(c, "Log level control unavailable"))
+		return
+	}
+
+	component := c.Param("component")
+	if err := h.logLevels.Reset(component); err != nil {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "Unknown component"))
+		return
+	}
+
+	log.Info("admin reset log level",
+		zap.String("component", component),
+		zap.String("ip", c.ClientIP()),
+	)
+	h.auditChange(c, "reset_log_level", map[string]string{"component": component})
+
+	root, components := h.logLevels.Status()
+	c.JSON(http.StatusOK, gin.H{"root": root, "components": components})
+}
+
+// auditChange records a successful admin change made by the authenticated admin user
+func (h *AdminHandler) auditChange(c *gin.Context, action string, details map[string]string) {
+	e := middleware.AuditEvent(c, audit.TypeAdminChange, audit.OutcomeSuccess)
+	e.Subject, _, _ = c.Request.BasicAuth()
+	e.Details = details
+	e.Details["action"] = action
+	h.audit.Record(c.Request.Context(), e)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/service"
+)
+
+func newTestAdminRouter(t *testing.T, password string) (*gin.Engine, *service.LockoutService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &configs.Config{
+		Admin: configs.Admin{User: "root", Password: password},
+	}
+	lockouts := service.NewLockoutService(configs.Lockout{
+		Enable:      true,
+		MaxFailures: 1,
+		Window:      time.Minute,
+		Duration:    time.Minute,
+	}, zap.NewNop())
+	h := NewAdminHandler(configs.NewSnapshot(cfg.Admin), lockouts, nil, zap.NewNop())
+
+	router := gin.New()
+	admin := router.Group("/admin", h.BasicAuth())
+	admin.GET("/lockouts", h.ListLockouts)
+	admin.DELETE("/lockouts", h.ClearLockouts)
+	admin.DELETE("/lockouts/:scope/:value", h.ClearLockout)
+
+	return router, lockouts
+}
+
+func TestAdminHandler_BasicAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		user       string
+		pass       string
+		wantStatus int
+	}{
+		{name: "valid credentials", password: "secret", user: "root", pass: "secret", wantStatus: http.StatusOK},
+		{name: "invalid password", password: "secret", user: "root", pass: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "no credentials", password: "secret", wantStatus: http.StatusUnauthorized},
+		{name: "admin disabled", password: "", user: "root", pass: "", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newTestAdminRouter(t, tt.password)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/lockouts", nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_Lockouts(t *testing.T) {
+	router, lockouts := newTestAdminRouter(t, "secret")
+	ctx := context.Background()
+
+	lockouts.RecordFailure(ctx, "192.0.2.1", "alice")
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.SetBasicAuth("root", "secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/admin/lockouts")
+	var body struct {
+		Lockouts []service.Lockout `json:"lockouts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(body.Lockouts) != 2 {
+		t.Fatalf("got %d lockouts, want 2", len(body.Lockouts))
+	}
+
+	if w := do(http.MethodDelete, "/admin/lockouts/user/alice"); w.Code != http.StatusOK {
+		t.Errorf("clear user status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := do(http.MethodDelete, "/admin/lockouts/user/alice"); w.Code != http.StatusNotFound {
+		t.Errorf("clear missing status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := do(http.MethodDelete, "/admin/lockouts/host/alice"); w.Code != http.StatusBadRequest {
+		t.Errorf("clear invalid scope status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := do(http.MethodDelete, "/admin/lockouts"); w.Code != http.StatusOK {
+		t.Errorf("clear all status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if n := len(lockouts.List(ctx)); n != 0 {
+		t.Errorf("List() returned %d lockouts after clear all, want 0", n)
+	}
+}
+
+func TestAdminHandler_LogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	l, err := logger.New(logger.Config{Level: zapcore.InfoLevel, OutputPath: "stderr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Component("auth")
+	levels := l.Levels
+
+	cfg := &configs.Config{Admin: configs.Admin{User: "root", Password: "secret"}}
+	h := NewAdminHandler(configs.NewSnapshot(cfg.Admin), nil, nil, zap.NewNop(), WithLogLevels(levels))
+	router := gin.New()
+	admin := router.Group("/admin", h.BasicAuth())
+	admin.GET("/log-level", h.GetLogLevel)
+	admin.PUT("/log-level", h.SetLogLevel)
+	admin.DELETE("/log-level/:component", h.ResetLogLevel)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth("root", "secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "component with revert", body: `{"level":"debug","component":"auth","duration":"10m"}`, wantStatus: http.StatusOK},
+		{name: "root", body: `{"level":"warn"}`, wantStatus: http.StatusOK},
+		{name: "invalid level", body: `{"level":"loud"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid duration", body: `{"level":"debug","duration":"-1m"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown component", body: `{"level":"debug","component":"cdn"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing level", body: `{}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := do(http.MethodPut, "/admin/log-level", tt.body); w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+	}
+
+	var body struct {
+		Root       logger.LevelStatus            `json:"root"`
+		Components map[string]logger.LevelStatus `json:"components"`
+	}
+	w := do(http.MethodGet, "/admin/log-level", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body.Root.Level != "warn" {
+		t.Errorf("root level = %q, want warn", body.Root.Level)
+	}
+	if auth := body.Components["auth"]; auth.Level != "debug" || !auth.Override || auth.RevertAt == nil {
+		t.Errorf("auth status = %+v", auth)
+	}
+
+	if w := do(http.MethodDelete, "/admin/log-level/auth", ""); w.Code != http.StatusOK {
+		t.Errorf("reset status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := do(http.MethodDelete, "/admin/log-level/cdn", ""); w.Code != http.StatusNotFound {
+		t.Errorf("reset unknown status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if _, components := levels.Status(); components["auth"].Override {
+		t.Error("auth override still set after reset")
+	}
+}
@@ -2,13 +2,19 @@
 package handler
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"hls-key-server-go/internal/apperrors"
 	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/handler/middleware"
+	"hls-key-server-go/internal/pkg/audit"
+	"hls-key-server-go/internal/pkg/logger"
 	"hls-key-server-go/internal/pkg/metrics"
 	"hls-key-server-go/internal/service"
 )
@@ -16,14 +22,18 @@ import (
 // AuthHandler handles authentication requests
 type AuthHandler struct {
 	service   *service.AuthService
-	jwtConfig *configs.JwtSecret
+	lockouts  *service.LockoutService
+	audit     *audit.Logger
+	jwtConfig *configs.Snapshot[configs.JwtSecret]
 	logger    *zap.Logger
 }
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler(service *service.AuthService, jwtConfig *configs.JwtSecret, logger *zap.Logger) *AuthHandler {
+// NewAuthHandler creates a new auth handler; auditor may be nil
+func NewAuthHandler(service *service.AuthService, lockouts *service.LockoutService, auditor *audit.Logger, jwtConfig *configs.Snapshot[configs.JwtSecret], logger *zap.Logger) *AuthHandler {
 	return &AuthHandler{
 		service:   service,
+		lockouts:  lockouts,
+		audit:     auditor,
 		jwtConfig: jwtConfig,
 		logger:    logger,
 	}
@@ -31,72 +41,124 @@ func NewAuthHandler(service *service.AuthService, jwtConfig *configs.JwtSecret,
 
 // GenerateToken handles JWT token generation
 // @Summary Generate auth token
-// @Description Generates a JWT token if the username and custom header are valid
+// @Description Generates a JWT token if the username and custom header are valid.
+// @Description Presenting the previous token, even an expired one, keeps its playback session.
 // @Tags Auth
 // @Accept application/x-www-form-urlencoded
 // @Produce json
 // @Param username formData string true "Username"
 // @Param header-key header string true "Custom authentication header"
+// @Param Authorization header string false "Bearer token being renewed; its playback session is kept"
 // @Success 200 {object} map[string]string "JWT token"
 // @Failure 400 {object} map[string]string "Invalid request"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 429 {object} map[string]string "Too many failed attempts"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /api/v1/auth/token [post]
 func (h *AuthHandler) GenerateToken(c *gin.Context) {
+	log := logger.FromContext(c.Request.Context(), h.logger)
+
 	username := c.PostForm("username")
+	ip := c.ClientIP()
 
-	h.logger.Info("token generation request",
+	log.Info("token generation request",
 		zap.String("username", username),
-		zap.String("ip", c.ClientIP()),
+		zap.String("ip", ip),
 	)
 
+	// Reject callers in backoff or lockout before evaluating credentials
+	if wait, err := h.lockouts.Check(c.Request.Context(), ip, username); err != nil {
+		metrics.AuthAttempts.WithLabelValues("locked_out").Inc()
+		log.Warn("token request rejected by lockout",
+			zap.String("username", username),
+			zap.String("ip", ip),
+			zap.Duration("retry_after", wait),
+		)
+		h.auditTokenIssue(c, username, audit.OutcomeDenied, "locked_out")
+		c.Header("Retry-After", retryAfterSeconds(wait))
+		c.JSON(http.StatusTooManyRequests, middleware.ErrorBody(c, "Too many attempts"))
+		return
+	}
+
 	// Validate custom header
-	headerValue := c.GetHeader(h.jwtConfig.HeaderKey)
-	if headerValue != h.jwtConfig.HeaderValue {
+	jwtConfig := h.jwtConfig.Load()
+	headerValue := c.GetHeader(jwtConfig.HeaderKey)
+	if headerValue != jwtConfig.HeaderValue {
 		metrics.AuthAttempts.WithLabelValues("invalid_header").Inc()
-		h.logger.Warn("invalid custom header",
+		// Without the header the username is unproven, and every player
+		// shares jwt.user, so only the IP is counted
+		h.lockouts.RecordFailure(c.Request.Context(), ip, "")
+		log.Warn("invalid custom header",
 			zap.String("username", username),
-			zap.String("ip", c.ClientIP()),
-			zap.String("expected_header", h.jwtConfig.HeaderKey),
+			zap.String("ip", ip),
+			zap.String("expected_header", jwtConfig.HeaderKey),
 		)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication header"})
+		h.auditTokenIssue(c, username, audit.OutcomeDenied, "invalid_header")
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "Invalid credentials"))
 		return
 	}
 
 	// Validate credentials
-	if err := h.service.ValidateCredentials(c.Request.Context(), username, h.jwtConfig.HeaderValue); err != nil {
+	if err := h.service.ValidateCredentials(c.Request.Context(), username, headerValue); err != nil {
 		metrics.AuthAttempts.WithLabelValues("invalid_credentials").Inc()
-		h.logger.Warn("invalid credentials",
+		log.Warn("invalid credentials",
 			zap.String("username", username),
+			zap.String("ip", ip),
 			zap.Error(err),
 		)
 
 		if apperrors.IsInvalidCredentials(err) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			h.lockouts.RecordFailure(c.Request.Context(), ip, username)
+			h.auditTokenIssue(c, username, audit.OutcomeDenied, "invalid_credentials")
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "Invalid credentials"))
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication failed"})
+		h.auditTokenIssue(c, username, audit.OutcomeFailure, "internal_error")
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "Authentication failed"))
 		return
 	}
 
-	// Generate token
-	token, err := h.service.GenerateToken(c.Request.Context(), username)
+	// Generate token; a renewing player presents its current token to keep
+	// its playback session
+	previous := middleware.BearerToken(c.GetHeader("Authorization"))
+	token, err := h.service.IssueToken(c.Request.Context(), username, previous)
 	if err != nil {
 		metrics.ErrorsTotal.WithLabelValues("token_generation").Inc()
-		h.logger.Error("failed to generate token",
+		log.Error("failed to generate token",
 			zap.String("username", username),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		h.auditTokenIssue(c, username, audit.OutcomeFailure, "token_generation")
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "Failed to generate token"))
 		return
 	}
 
+	h.lockouts.RecordSuccess(c.Request.Context(), ip, username)
 	metrics.AuthAttempts.WithLabelValues("success").Inc()
 	metrics.TokenGenerations.Inc()
-	h.logger.Info("token generated successfully",
+	log.Info("token generated successfully",
 		zap.String("username", username),
 	)
 
+	h.auditTokenIssue(c, username, audit.OutcomeSuccess, "")
+
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+// auditTokenIssue records the outcome of a token request for username
+func (h *AuthHandler) auditTokenIssue(c *gin.Context, username, outcome, reason string) {
+	e := middleware.AuditEvent(c, audit.TypeTokenIssue, outcome)
+	e.Subject = username
+	e.Reason = reason
+	h.audit.Record(c.Request.Context(), e)
+}
+
+// retryAfterSeconds formats a wait duration as a Retry-After header value (at least 1 second)
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
@@ -7,12 +7,14 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"hls-key-server-go/internal/apperrors"
 	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/service"
 )
 
 // mockAuthService implements a mock Auth service for testing
@@ -72,7 +74,7 @@ func (h *testAuthHandler) GenerateToken(c *gin.Context) {
 	// Validate custom header
 	headerValue := c.GetHeader(h.jwtConfig.HeaderKey)
 	if headerValue != h.jwtConfig.HeaderValue {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication header"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
@@ -138,7 +140,7 @@ func TestAuthHandler_GenerateToken(t *testing.T) {
 			headerKey:      "X-Custom-Auth",
 			headerValue:    "wrong-header-value",
 			expectedStatus: http.StatusUnauthorized,
-			expectedBody:   "Invalid authentication header",
+			expectedBody:   "Invalid credentials",
 		},
 		{
 			name:           "missing header",
@@ -146,7 +148,7 @@ func TestAuthHandler_GenerateToken(t *testing.T) {
 			headerKey:      "",
 			headerValue:    "",
 			expectedStatus: http.StatusUnauthorized,
-			expectedBody:   "Invalid authentication header",
+			expectedBody:   "Invalid credentials",
 		},
 		{
 			name:           "invalid username",
@@ -226,3 +228,74 @@ func TestAuthHandler_GenerateToken_ContentType(t *testing.T) {
 		t.Errorf("expected Content-Type to contain 'application/json', got %q", contentType)
 	}
 }
+
+func TestAuthHandler_GenerateToken_Lockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtConfig := &configs.JwtSecret{
+		User:        "testuser",
+		HeaderKey:   "X-Custom-Auth",
+		HeaderValue: "valid-header-value",
+		SecretKey:   "test-secret",
+		Expire:      10,
+	}
+	logger := zap.NewNop()
+	lockouts := service.NewLockoutService(configs.Lockout{
+		Enable:      true,
+		MaxFailures: 2,
+		Window:      time.Minute,
+		Duration:    time.Minute,
+	}, logger)
+	jwtSnapshot := configs.NewSnapshot(*jwtConfig)
+	h := NewAuthHandler(service.NewAuthService(jwtSnapshot, logger), lockouts, nil, jwtSnapshot, logger)
+
+	router := gin.New()
+	router.POST("/api/v1/auth/token", h.GenerateToken)
+
+	requestFrom := func(addr, username, headerValue string) *httptest.ResponseRecorder {
+		form := url.Values{}
+		form.Add("username", username)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Custom-Auth", headerValue)
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	request := func(username, headerValue string) *httptest.ResponseRecorder {
+		return requestFrom("192.0.2.1:1234", username, headerValue)
+	}
+
+	if w := request("wronguser", "valid-header-value"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("first failure status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := request("wronguser", "wrong-header"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("second failure status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// Valid credentials from a locked-out IP are rejected
+	w := request("testuser", "valid-header-value")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("locked out status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on lockout")
+	}
+
+	lockouts.ClearAll(context.Background())
+	if w := request("testuser", "valid-header-value"); w.Code != http.StatusOK {
+		t.Errorf("status after clear = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Failures without the header lock out only their IP, not the shared user
+	for i := 0; i < 5; i++ {
+		requestFrom("198.51.100.7:1234", "testuser", "wrong-header")
+	}
+	if w := requestFrom("198.51.100.7:1234", "testuser", "valid-header-value"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("attacker status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := requestFrom("203.0.113.9:1234", "testuser", "valid-header-value"); w.Code != http.StatusOK {
+		t.Errorf("other viewer status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
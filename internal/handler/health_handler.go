@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/pkg/health"
+	"hls-key-server-go/internal/pkg/logger"
+)
+
+// HealthHandler serves Kubernetes-style liveness, readiness and startup probes
+type HealthHandler struct {
+	checker *health.Checker
+	logger  *zap.Logger
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(checker *health.Checker, logger *zap.Logger) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+// Livez handles the liveness probe
+// @Summary Liveness probe
+// @Description Returns 200 while the process is able to serve requests
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "All checks passed"
+// @Failure 503 {object} health.Report "A check failed"
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	h.respond(c, "liveness", h.checker.Live(c.Request.Context()))
+}
+
+// Readyz handles the readiness probe
+// @Summary Readiness probe
+// @Description Returns 503 when keys are missing, the last reload failed, the key store is unreadable or the server is draining
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "All checks passed"
+// @Failure 503 {object} health.Report "A check failed"
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	h.respond(c, "readiness", h.checker.Ready(c.Request.Context()))
+}
+
+// Startupz handles the startup probe
+// @Summary Startup probe
+// @Description Returns 200 once initialization has completed
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "Startup complete"
+// @Failure 503 {object} health.Report "Still starting"
+// @Router /startupz [get]
+func (h *HealthHandler) Startupz(c *gin.Context) {
+	h.respond(c, "startup", h.checker.Started(c.Request.Context()))
+}
+
+func (h *HealthHandler) respond(c *gin.Context, probe string, report health.Report) {
+	if report.OK() {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	failed := make([]string, 0, len(report.Checks))
+	for name, result := range report.Checks {
+		if result.Status != health.StatusOK {
+			failed = append(failed, name)
+		}
+	}
+	logger.FromContext(c.Request.Context(), h.logger).Debug("probe failed",
+		zap.String("probe", probe),
+		zap.Strings("checks", failed),
+	)
+	c.JSON(http.StatusServiceUnavailable, report)
+}
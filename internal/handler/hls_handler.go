@@ -3,27 +3,57 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/handler/middleware"
+	"hls-key-server-go/internal/pkg/audit"
+	"hls-key-server-go/internal/pkg/ippolicy"
+	"hls-key-server-go/internal/pkg/logger"
 	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/principal"
 	"hls-key-server-go/internal/service"
 )
 
 // HLSHandler handles HLS key requests
 type HLSHandler struct {
-	service *service.HLSService
-	logger  *zap.Logger
+	service  *service.HLSService
+	ipPolicy atomic.Pointer[ippolicy.Engine]
+	audit    *audit.Logger
+	logger   *zap.Logger
 }
 
-// NewHLSHandler creates a new HLS handler
-func NewHLSHandler(service *service.HLSService, logger *zap.Logger) *HLSHandler {
-	return &HLSHandler{
+// HLSHandlerOption configures optional HLSHandler behavior
+type HLSHandlerOption func(*HLSHandler)
+
+// WithIPPolicy evaluates CIDR policies before key retrieval
+func WithIPPolicy(engine *ippolicy.Engine) HLSHandlerOption {
+	return func(h *HLSHandler) {
+		h.ipPolicy.Store(engine)
+	}
+}
+
+// SetIPPolicy replaces the CIDR policy applied to subsequent requests;
+// nil disables it
+func (h *HLSHandler) SetIPPolicy(engine *ippolicy.Engine) {
+	h.ipPolicy.Store(engine)
+}
+
+// NewHLSHandler creates a new HLS handler; auditor may be nil
+func NewHLSHandler(service *service.HLSService, auditor *audit.Logger, logger *zap.Logger, opts ...HLSHandlerOption) *HLSHandler {
+	h := &HLSHandler{
 		service: service,
+		audit:   auditor,
 		logger:  logger,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // GetKey handles the key retrieval request
@@ -36,10 +66,13 @@ func NewHLSHandler(service *service.HLSService, logger *zap.Logger) *HLSHandler
 // @Security BearerAuth
 // @Success 200 {file} binary "Encryption key"
 // @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Access denied or concurrent session limit exceeded"
 // @Failure 404 {object} map[string]string "Key not found"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /api/v1/hls/key [post]
 func (h *HLSHandler) GetKey(c *gin.Context) {
+	log := logger.FromContext(c.Request.Context(), h.logger)
+
 	// Support both query parameter and form data
 	keyName := c.Query("key")
 	if keyName == "" {
@@ -49,34 +82,72 @@ func (h *HLSHandler) GetKey(c *gin.Context) {
 		keyName = "stream.key"
 	}
 
-	h.logger.Info("key request",
+	log.Info("key request",
 		zap.String("key", keyName),
 		zap.String("ip", c.ClientIP()),
 	)
 
+	if ipPolicy := h.ipPolicy.Load(); ipPolicy != nil {
+		p, _ := principal.FromContext(c.Request.Context())
+		decision := ipPolicy.Evaluate(c.ClientIP(), keyName, p.Tenant)
+		if !decision.Allowed {
+			metrics.KeyRequestsTotal.WithLabelValues(keyName, "denied").Inc()
+			metrics.IPPolicyDenied.WithLabelValues(decision.Rule).Inc()
+			log.Warn("key request denied by ip policy",
+				zap.String("key", keyName),
+				zap.String("ip", c.ClientIP()),
+				zap.String("remote_addr", c.Request.RemoteAddr),
+				zap.String("subject", p.Subject),
+				zap.String("tenant", p.Tenant),
+				zap.String("rule", decision.Rule),
+				zap.String("reason", decision.Reason),
+			)
+			h.auditKeyFetch(c, keyName, audit.OutcomeDenied, "ip_policy", map[string]string{
+				"rule":   decision.Rule,
+				"detail": decision.Reason,
+			})
+			c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "Access denied"))
+			return
+		}
+	}
+
 	keyData, err := h.service.GetKey(c.Request.Context(), keyName)
 	if err != nil {
 		metrics.KeyRequestsTotal.WithLabelValues(keyName, "error").Inc()
 		metrics.ErrorsTotal.WithLabelValues("key_retrieval").Inc()
-		h.logger.Error("failed to get key",
+		log.Error("failed to get key",
 			zap.String("key", keyName),
 			zap.Error(err),
 		)
 
 		if apperrors.IsKeyNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Key not found"})
+			h.auditKeyFetch(c, keyName, audit.OutcomeFailure, "key_not_found", nil)
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "Key not found"))
 			return
 		}
 		if apperrors.IsInvalidKeyName(err) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid key name"})
+			h.auditKeyFetch(c, keyName, audit.OutcomeFailure, "invalid_key_name", nil)
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid key name"))
+			return
+		}
+		if apperrors.IsForbidden(err) {
+			h.auditKeyFetch(c, keyName, audit.OutcomeDenied, "key_not_permitted", nil)
+			c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "Access denied"))
+			return
+		}
+		if apperrors.IsSessionLimitExceeded(err) {
+			h.auditKeyFetch(c, keyName, audit.OutcomeDenied, "session_limit", nil)
+			c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "Concurrent session limit exceeded"))
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve key"})
+		h.auditKeyFetch(c, keyName, audit.OutcomeFailure, "internal_error", nil)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "Failed to retrieve key"))
 		return
 	}
 
 	metrics.KeyRequestsTotal.WithLabelValues(keyName, "success").Inc()
+	h.auditKeyFetch(c, keyName, audit.OutcomeSuccess, "", nil)
 	c.Data(http.StatusOK, "application/octet-stream", keyData)
 }
 
@@ -99,24 +170,44 @@ func (h *HLSHandler) ListKeys(c *gin.Context) {
 // @Tags HLS
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} map[string]string "Reload status"
+// @Success 200 {object} map[string]interface{} "Reload status with key and unreadable file counts"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /api/v1/hls/reload [post]
 func (h *HLSHandler) ReloadKeys(c *gin.Context) {
-	h.logger.Info("key reload request",
+	log := logger.FromContext(c.Request.Context(), h.logger)
+
+	log.Info("key reload request",
 		zap.String("ip", c.ClientIP()),
 	)
 
-	if err := h.service.ReloadKeys(c.Request.Context()); err != nil {
-		h.logger.Error("failed to reload keys", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload keys"})
+	unreadable, err := h.service.ReloadKeys(c.Request.Context())
+	if err != nil {
+		log.Error("failed to reload keys", zap.Error(err))
+		e := middleware.AuditEvent(c, audit.TypeKeyReload, audit.OutcomeFailure)
+		e.Reason = err.Error()
+		h.audit.Record(c.Request.Context(), e)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "Failed to reload keys"))
 		return
 	}
 
+	// Unreadable files kept their last key; report them without failing
 	keys := h.service.ListKeys(c.Request.Context())
-	h.logger.Info("keys reloaded successfully", zap.Int("count", len(keys)))
+	log.Info("keys reloaded successfully", zap.Int("count", len(keys)), zap.Int("unreadable", unreadable))
+	e := middleware.AuditEvent(c, audit.TypeKeyReload, audit.OutcomeSuccess)
+	e.Details = map[string]string{"count": strconv.Itoa(len(keys)), "unreadable": strconv.Itoa(unreadable)}
+	h.audit.Record(c.Request.Context(), e)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Keys reloaded successfully",
-		"count":   len(keys),
+		"message":    "Keys reloaded successfully",
+		"count":      len(keys),
+		"unreadable": unreadable,
 	})
 }
+
+// auditKeyFetch records the outcome of a key retrieval
+func (h *HLSHandler) auditKeyFetch(c *gin.Context, keyName, outcome, reason string, details map[string]string) {
+	e := middleware.AuditEvent(c, audit.TypeKeyFetch, outcome)
+	e.KeyID = keyName
+	e.Reason = reason
+	e.Details = details
+	h.audit.Record(c.Request.Context(), e)
+}
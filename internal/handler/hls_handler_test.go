@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -12,12 +14,19 @@ import (
 	"go.uber.org/zap"
 
 	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/handler/middleware"
+	"hls-key-server-go/internal/pkg/audit"
+	"hls-key-server-go/internal/pkg/ippolicy"
+	"hls-key-server-go/internal/repository"
+	"hls-key-server-go/internal/service"
 )
 
 // mockHLSService implements a mock HLS service for testing
 type mockHLSService struct {
-	keys      map[string][]byte
-	reloadErr error
+	keys       map[string][]byte
+	reloadErr  error
+	unreadable int
 }
 
 func newMockHLSService() *mockHLSService {
@@ -48,15 +57,18 @@ func (m *mockHLSService) ListKeys(_ context.Context) []string {
 	return keys
 }
 
-func (m *mockHLSService) ReloadKeys(_ context.Context) error {
-	return m.reloadErr
+func (m *mockHLSService) ReloadKeys(_ context.Context) (int, error) {
+	if m.reloadErr != nil {
+		return 0, m.reloadErr
+	}
+	return m.unreadable, nil
 }
 
 // HLSServiceInterface defines the interface for HLS service operations
 type HLSServiceInterface interface {
 	GetKey(ctx context.Context, keyName string) ([]byte, error)
 	ListKeys(ctx context.Context) []string
-	ReloadKeys(ctx context.Context) error
+	ReloadKeys(ctx context.Context) (int, error)
 }
 
 // testHLSHandler wraps HLSHandler for testing with mock service
@@ -104,14 +116,16 @@ func (h *testHLSHandler) ListKeys(c *gin.Context) {
 }
 
 func (h *testHLSHandler) ReloadKeys(c *gin.Context) {
-	if err := h.service.ReloadKeys(c.Request.Context()); err != nil {
+	unreadable, err := h.service.ReloadKeys(c.Request.Context())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload keys"})
 		return
 	}
 	keys := h.service.ListKeys(c.Request.Context())
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Keys reloaded successfully",
-		"count":   len(keys),
+		"message":    "Keys reloaded successfully",
+		"count":      len(keys),
+		"unreadable": unreadable,
 	})
 }
 
@@ -231,6 +245,7 @@ func TestHLSHandler_ReloadKeys(t *testing.T) {
 	tests := []struct {
 		name           string
 		reloadErr      error
+		unreadable     int
 		expectedStatus int
 		expectedBody   string
 	}{
@@ -240,6 +255,12 @@ func TestHLSHandler_ReloadKeys(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   "Keys reloaded successfully",
 		},
+		{
+			name:           "some key files unreadable",
+			unreadable:     2,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"unreadable":2`,
+		},
 		{
 			name:           "reload failure",
 			reloadErr:      apperrors.ErrKeyReadFailed,
@@ -252,6 +273,7 @@ func TestHLSHandler_ReloadKeys(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := newMockHLSService()
 			mockService.reloadErr = tt.reloadErr
+			mockService.unreadable = tt.unreadable
 			handler := newTestHLSHandler(mockService)
 			router := setupTestRouter(handler)
 
@@ -270,6 +292,40 @@ func TestHLSHandler_ReloadKeys(t *testing.T) {
 	}
 }
 
+func TestHLSHandler_ReloadKeys_Unreadable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stream.key"), []byte("0123456789abcdef"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repository.NewFileKeyRepository(dir)
+	if err != nil {
+		t.Fatalf("NewFileKeyRepository() error = %v", err)
+	}
+	// A link to a directory is listed as a key file but cannot be read
+	if err := os.Symlink(t.TempDir(), filepath.Join(dir, "broken.key")); err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &memorySink{}
+	h := NewHLSHandler(service.NewHLSService(repo, zap.NewNop()), audit.New(zap.NewNop(), sink), zap.NewNop())
+	router := gin.New()
+	router.POST("/api/v1/hls/reload", h.ReloadKeys)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/hls/reload", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"unreadable":1`) {
+		t.Fatalf("reload = %d %s, want 200 with one unreadable file", w.Code, w.Body.String())
+	}
+	if len(sink.events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(sink.events))
+	}
+	if e := sink.events[0]; e.Outcome != audit.OutcomeSuccess || e.Details["unreadable"] != "1" || e.Details["count"] != "1" {
+		t.Errorf("event = %+v, want success with one unreadable file", e)
+	}
+}
+
 func TestHLSHandler_GetKey_ContentType(t *testing.T) {
 	mockService := newMockHLSService()
 	handler := newTestHLSHandler(mockService)
@@ -291,3 +347,139 @@ func TestHLSHandler_GetKey_ContentType(t *testing.T) {
 		t.Errorf("expected Content-Type 'application/octet-stream', got %q", contentType)
 	}
 }
+
+// newRealHLSService builds an HLSService over a temporary key directory
+func newRealHLSService(t *testing.T, keys ...string) *service.HLSService {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range keys {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("0123456789abcdef"), 0o600); err != nil {
+			t.Fatalf("write key: %v", err)
+		}
+	}
+	repo, err := repository.NewFileKeyRepository(dir)
+	if err != nil {
+		t.Fatalf("NewFileKeyRepository() error = %v", err)
+	}
+	return service.NewHLSService(repo, zap.NewNop())
+}
+
+func TestHLSHandler_GetKey_IPPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine, err := ippolicy.New([]configs.IPPolicyRule{
+		{Name: "qa", Keys: []string{"qa-*.key"}, Allow: []string{"10.0.0.0/8"}},
+	})
+	if err != nil {
+		t.Fatalf("ippolicy.New() error = %v", err)
+	}
+	h := NewHLSHandler(newRealHLSService(t, "qa-stream.key", "stream.key"), nil, zap.NewNop(), WithIPPolicy(engine))
+
+	router := gin.New()
+	if err := router.SetTrustedProxies([]string{"192.0.2.0/24"}); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	router.POST("/api/v1/hls/key", h.GetKey)
+
+	tests := []struct {
+		name         string
+		key          string
+		remoteAddr   string
+		forwardedFor string
+		expectedCode int
+	}{
+		{name: "allowed direct client", key: "qa-stream.key", remoteAddr: "10.1.1.1:5000", expectedCode: http.StatusOK},
+		{name: "denied direct client", key: "qa-stream.key", remoteAddr: "198.51.100.1:5000", expectedCode: http.StatusForbidden},
+		{name: "allowed via trusted proxy", key: "qa-stream.key", remoteAddr: "192.0.2.10:5000", forwardedFor: "10.1.1.1", expectedCode: http.StatusOK},
+		{name: "spoofed header from untrusted peer", key: "qa-stream.key", remoteAddr: "198.51.100.1:5000", forwardedFor: "10.1.1.1", expectedCode: http.StatusForbidden},
+		{name: "unrestricted key", key: "stream.key", remoteAddr: "198.51.100.1:5000", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/hls/key?key="+tt.key, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+// memorySink collects audit events in memory
+type memorySink struct {
+	events []audit.Event
+}
+
+func (s *memorySink) Write(e audit.Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func TestHLSHandler_GetKey_Audit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	sink := &memorySink{}
+	auditor := audit.New(zap.NewNop(), sink)
+	h := NewHLSHandler(newRealHLSService(t, "stream.key"), auditor, zap.NewNop())
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.POST("/api/v1/hls/key",
+		middleware.AuditDenials(auditor),
+		func(c *gin.Context) {
+			if c.GetHeader("Authorization") == "" {
+				c.AbortWithStatus(http.StatusUnauthorized)
+			}
+		},
+		h.GetKey,
+	)
+
+	request := func(key, authorization string) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/hls/key?key="+key, nil)
+		req.Header.Set("User-Agent", "test-player/1.0")
+		req.Header.Set("X-Request-ID", "req-"+key)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	request("stream.key", "Bearer x")
+	request("missing.key", "Bearer x")
+	request("stream.key", "")
+
+	want := []struct {
+		eventType string
+		outcome   string
+		reason    string
+	}{
+		{audit.TypeKeyFetch, audit.OutcomeSuccess, ""},
+		{audit.TypeKeyFetch, audit.OutcomeFailure, "key_not_found"},
+		{audit.TypeAccessDenied, audit.OutcomeDenied, "Unauthorized"},
+	}
+	if len(sink.events) != len(want) {
+		t.Fatalf("recorded %d events, want %d: %+v", len(sink.events), len(want), sink.events)
+	}
+	for i, w := range want {
+		e := sink.events[i]
+		if e.Type != w.eventType || e.Outcome != w.outcome || e.Reason != w.reason {
+			t.Errorf("event %d = %s/%s/%q, want %s/%s/%q", i, e.Type, e.Outcome, e.Reason, w.eventType, w.outcome, w.reason)
+		}
+		if e.UserAgent != "test-player/1.0" || e.ClientIP == "" || e.RequestID == "" || e.Time.IsZero() {
+			t.Errorf("event %d missing request fields: %+v", i, e)
+		}
+	}
+	if sink.events[0].KeyID != "stream.key" || sink.events[2].KeyID != "stream.key" {
+		t.Errorf("key ids = %q, %q", sink.events[0].KeyID, sink.events[2].KeyID)
+	}
+}
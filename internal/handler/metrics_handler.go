@@ -9,16 +9,18 @@ import (
 	"go.uber.org/zap"
 
 	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/logger"
 )
 
 // MetricsHandler handles Prometheus metrics endpoint with basic auth
 type MetricsHandler struct {
-	config *configs.Config
+	config *configs.Snapshot[configs.Metric]
 	logger *zap.Logger
 }
 
-// NewMetricsHandler creates a new metrics handler
-func NewMetricsHandler(config *configs.Config, logger *zap.Logger) *MetricsHandler {
+// NewMetricsHandler creates a new metrics handler; credentials are read from
+// config on every request so a reload applies immediately
+func NewMetricsHandler(config *configs.Snapshot[configs.Metric], logger *zap.Logger) *MetricsHandler {
 	return &MetricsHandler{
 		config: config,
 		logger: logger,
@@ -28,20 +30,23 @@ func NewMetricsHandler(config *configs.Config, logger *zap.Logger) *MetricsHandl
 // BasicAuth provides basic authentication middleware for metrics endpoint
 func (h *MetricsHandler) BasicAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		log := logger.FromContext(c.Request.Context(), h.logger)
+
 		user, pass, ok := c.Request.BasicAuth()
 		if !ok {
-			h.logger.Warn("metrics endpoint accessed without basic auth")
+			log.Warn("metrics endpoint accessed without basic auth")
 			c.Header("WWW-Authenticate", `Basic realm="metrics"`)
 			c.AbortWithStatus(401)
 			return
 		}
 
 		// Use constant time comparison to prevent timing attacks
-		userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(h.config.Metric.User)) == 1
-		passMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(h.config.Metric.Password)) == 1
+		cfg := h.config.Load()
+		userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(cfg.User)) == 1
+		passMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(cfg.Password)) == 1
 
 		if !userMatch || !passMatch {
-			h.logger.Warn("metrics endpoint accessed with invalid credentials",
+			log.Warn("metrics endpoint accessed with invalid credentials",
 				zap.String("user", user),
 			)
 			c.Header("WWW-Authenticate", `Basic realm="metrics"`)
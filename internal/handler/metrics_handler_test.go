@@ -64,7 +64,7 @@ func TestMetricsHandler_BasicAuth(t *testing.T) {
 			}
 
 			logger := zap.NewNop()
-			handler := NewMetricsHandler(cfg, logger)
+			handler := NewMetricsHandler(configs.NewSnapshot(cfg.Metric), logger)
 
 			router := gin.New()
 			router.GET("/metrics", handler.BasicAuth(), func(c *gin.Context) {
@@ -106,7 +106,7 @@ func TestMetricsHandler_Handler(t *testing.T) {
 	}
 
 	logger := zap.NewNop()
-	handler := NewMetricsHandler(cfg, logger)
+	handler := NewMetricsHandler(configs.NewSnapshot(cfg.Metric), logger)
 
 	router := gin.New()
 	router.GET("/metrics", handler.BasicAuth(), handler.Handler())
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"hls-key-server-go/internal/pkg/audit"
+	"hls-key-server-go/internal/pkg/principal"
+	"hls-key-server-go/internal/pkg/requestid"
+)
+
+// AuditEvent builds an audit event for the current request, filling the
+// caller identity, client and request ID fields
+func AuditEvent(c *gin.Context, eventType, outcome string) audit.Event {
+	p, _ := principal.FromContext(c.Request.Context())
+	return audit.Event{
+		Type:      eventType,
+		Outcome:   outcome,
+		Subject:   p.Subject,
+		Tenant:    p.Tenant,
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: requestid.FromContext(c.Request.Context()),
+	}
+}
+
+// AuditDenials records an access.denied event for requests aborted by a
+// later middleware (authentication, origin checks, rate limiting) before
+// reaching their handler. Place it first in the chain.
+func AuditDenials(auditor *audit.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if !c.IsAborted() || status < http.StatusBadRequest {
+			return
+		}
+
+		e := AuditEvent(c, audit.TypeAccessDenied, audit.OutcomeDenied)
+		e.KeyID = c.Query("key")
+		e.Reason = http.StatusText(status)
+		e.Details = map[string]string{
+			"path":   c.FullPath(),
+			"status": strconv.Itoa(status),
+		}
+		auditor.Record(c.Request.Context(), e)
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/principal"
+)
+
+// ClaimsKey is the gin context key holding validated JWT claims
+const ClaimsKey = "jwt_claims"
+
+// TokenValidator validates a raw JWT and returns its claims
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, tokenString string) (jwt.MapClaims, error)
+}
+
+// JWTAuth returns a middleware that requires a valid Bearer token,
+// stores its claims on the context under ClaimsKey and attaches
+// the caller principal to the request context.
+// Requests already authenticated by a client certificate are passed through.
+func JWTAuth(validator TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := principal.FromContext(c.Request.Context()); ok {
+			c.Next()
+			return
+		}
+
+		token := BearerToken(c.GetHeader("Authorization"))
+		if token == "" {
+			metrics.TokenValidations.WithLabelValues("missing").Inc()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorBody(c, "Missing token"))
+			return
+		}
+
+		claims, err := validator.ValidateToken(c.Request.Context(), token)
+		if err != nil {
+			metrics.TokenValidations.WithLabelValues("invalid").Inc()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorBody(c, "Invalid or expired token"))
+			return
+		}
+
+		metrics.TokenValidations.WithLabelValues("success").Inc()
+		c.Set(ClaimsKey, claims)
+
+		sub, _ := claims["sub"].(string)
+		sid, _ := claims["sid"].(string)
+		tenant, _ := claims["tenant"].(string)
+		c.Request = c.Request.WithContext(principal.WithPrincipal(c.Request.Context(), principal.Principal{
+			Subject:   sub,
+			SessionID: sid,
+			Tenant:    tenant,
+			Claims:    claims,
+		}))
+
+		c.Next()
+	}
+}
+
+// Claims returns the validated JWT claims stored by JWTAuth, if any
+func Claims(c *gin.Context) (jwt.MapClaims, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(jwt.MapClaims)
+	return claims, ok
+}
+
+// Subject returns the subject of the authenticated principal, or "" when unauthenticated
+func Subject(c *gin.Context) string {
+	if p, ok := principal.FromContext(c.Request.Context()); ok {
+		return p.Subject
+	}
+	claims, ok := Claims(c)
+	if !ok {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// BearerToken returns the token of a "Bearer" Authorization header, or ""
+func BearerToken(header string) string {
+	const prefix = "Bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
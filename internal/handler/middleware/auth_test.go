@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"hls-key-server-go/internal/pkg/principal"
+)
+
+func jwtClaims(m map[string]any) jwt.MapClaims {
+	return jwt.MapClaims(m)
+}
+
+type stubValidator struct {
+	token string
+}
+
+func (v stubValidator) ValidateToken(_ context.Context, tokenString string) (jwt.MapClaims, error) {
+	if tokenString != v.token {
+		return nil, errors.New("invalid token")
+	}
+	return jwt.MapClaims{"sub": "alice", "sid": "session-1"}, nil
+}
+
+func TestJWTAuth(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantSubject   string
+	}{
+		{name: "valid bearer token", authorization: "Bearer good", wantStatus: http.StatusOK, wantSubject: "alice"},
+		{name: "lowercase scheme", authorization: "bearer good", wantStatus: http.StatusOK, wantSubject: "alice"},
+		{name: "missing header", authorization: "", wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", authorization: "Basic good", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", authorization: "Bearer bad", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var subject, sessionID string
+			router := gin.New()
+			router.Use(JWTAuth(stubValidator{token: "good"}))
+			router.GET("/test", func(c *gin.Context) {
+				subject = Subject(c)
+				if p, ok := principal.FromContext(c.Request.Context()); ok {
+					sessionID = p.SessionID
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+			if tt.wantSubject != "" && sessionID != "session-1" {
+				t.Errorf("principal session id = %q, want %q", sessionID, "session-1")
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/pkg/certauth"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/principal"
+)
+
+// ClientCertAuth returns a middleware that attaches a principal for requests
+// presenting a verified client certificate matched by mapper. Requests
+// without a mapped certificate pass through unchanged, so JWTAuth placed
+// after it still authenticates them.
+func ClientCertAuth(mapper *certauth.Mapper, baseLogger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := c.Request.TLS
+		if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+			c.Next()
+			return
+		}
+
+		leaf := state.VerifiedChains[0][0]
+		p, ok := mapper.Resolve(leaf)
+		if !ok {
+			logger.FromContext(c.Request.Context(), baseLogger).Debug("client certificate not mapped to a principal",
+				zap.Strings("identities", certauth.Identities(leaf)),
+			)
+			c.Next()
+			return
+		}
+
+		c.Request = c.Request.WithContext(principal.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
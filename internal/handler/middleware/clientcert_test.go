@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/certauth"
+)
+
+func TestClientCertAuth(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	mapper, err := certauth.New([]configs.CertPrincipal{
+		{Match: "*.encoder.example.com", Subject: "encoder"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified := func(dns string) *tls.ConnectionState {
+		return &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{DNSNames: []string{dns}}}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		state         *tls.ConnectionState
+		authorization string
+		wantStatus    int
+		wantSubject   string
+	}{
+		{name: "mapped certificate skips jwt", state: verified("eu.encoder.example.com"), wantStatus: http.StatusOK, wantSubject: "encoder"},
+		{name: "unmapped certificate falls back to jwt", state: verified("other.example.com"), authorization: "Bearer good", wantStatus: http.StatusOK, wantSubject: "alice"},
+		{name: "unmapped certificate without token", state: verified("other.example.com"), wantStatus: http.StatusUnauthorized},
+		{name: "unverified certificate", state: &tls.ConnectionState{}, wantStatus: http.StatusUnauthorized},
+		{name: "plain http", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var subject string
+			router := gin.New()
+			router.Use(ClientCertAuth(mapper, zap.NewNop()), JWTAuth(stubValidator{token: "good"}))
+			router.GET("/test", func(c *gin.Context) {
+				subject = Subject(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.TLS = tt.state
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig holds cross-origin middleware configuration
+type CORSConfig struct {
+	// Origins is the allow-list of origins that receive CORS headers
+	Origins          *OriginMatcher
+	AllowCredentials bool
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	MaxAge           time.Duration
+}
+
+// DefaultCORSConfig returns a CORS configuration for the key API with an empty allow-list
+func DefaultCORSConfig() *CORSConfig {
+	return &CORSConfig{
+		AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodOptions},
+		AllowHeaders:  []string{"Authorization", "Content-Type", "Accept", "Origin", "X-Requested-With", "X-Request-ID"},
+		ExposeHeaders: []string{"Content-Length", "Content-Type", "Retry-After", "X-Request-ID"},
+		MaxAge:        10 * time.Minute,
+	}
+}
+
+// CORS returns a middleware that reflects only allow-listed origins.
+// Preflight requests from other origins are rejected with 403; simple
+// requests proceed without CORS headers so the browser blocks the response.
+func CORS(config *CORSConfig) gin.HandlerFunc {
+	if config == nil {
+		config = DefaultCORSConfig()
+	}
+
+	allowMethods := strings.Join(config.AllowMethods, ", ")
+	allowHeaders := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		headers := c.Writer.Header()
+		headers.Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !config.Origins.AllowsURL(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		headers.Set("Access-Control-Allow-Origin", origin)
+		if config.AllowCredentials {
+			headers.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if exposeHeaders != "" {
+			headers.Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
+
+		if preflight {
+			headers.Set("Access-Control-Allow-Methods", allowMethods)
+			headers.Set("Access-Control-Allow-Headers", allowHeaders)
+			if config.MaxAge > 0 {
+				headers.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
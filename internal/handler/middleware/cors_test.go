@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCORSRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	config := DefaultCORSConfig()
+	config.Origins = mustOriginMatcher(t, "player.example.com")
+	config.AllowCredentials = true
+	config.MaxAge = 5 * time.Minute
+
+	router := gin.New()
+	router.Use(CORS(config))
+	router.POST("/key", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestCORS_AllowedOrigin(t *testing.T) {
+	t.Parallel()
+
+	router := newCORSRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/key", nil)
+	req.Header.Set("Origin", "https://player.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://player.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORS_DisallowedOrigin(t *testing.T) {
+	t.Parallel()
+
+	router := newCORSRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/key", nil)
+	req.Header.Set("Origin", "https://pirate.example.org")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want empty for disallowed origin", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want empty", got)
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	t.Parallel()
+
+	router := newCORSRouter(t)
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantStatus int
+	}{
+		{name: "allowed", origin: "https://player.example.com", wantStatus: http.StatusNoContent},
+		{name: "disallowed", origin: "https://pirate.example.org", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodOptions, "/key", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusNoContent {
+				if got := w.Header().Get("Access-Control-Max-Age"); got != "300" {
+					t.Errorf("Access-Control-Max-Age = %q, want 300", got)
+				}
+				if got := w.Header().Get("Access-Control-Allow-Methods"); got == "" {
+					t.Error("expected Access-Control-Allow-Methods on preflight")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/principal"
+)
+
+// HotlinkConfig holds anti-hotlinking middleware configuration
+type HotlinkConfig struct {
+	// Default applies to requests without a tenant or with an unlisted tenant
+	Default *OriginMatcher
+	// Tenants maps JWT tenants to their own allow-lists
+	Tenants map[string]*OriginMatcher
+	// AllowEmpty admits requests carrying neither Origin nor Referer
+	AllowEmpty bool
+	Logger     *zap.Logger
+}
+
+// Hotlink returns a middleware that validates Origin (or Referer when Origin
+// is absent) against the tenant's allowed domains and rejects mismatches with 403.
+// It must run after JWTAuth for tenant allow-lists to apply.
+func Hotlink(config *HotlinkConfig) gin.HandlerFunc {
+	baseLogger := config.Logger
+	if baseLogger == nil {
+		baseLogger = zap.NewNop()
+	}
+
+	return func(c *gin.Context) {
+		source := c.GetHeader("Origin")
+		if source == "" {
+			source = c.GetHeader("Referer")
+		}
+
+		if source == "" {
+			if config.AllowEmpty {
+				c.Next()
+				return
+			}
+			deny(c, baseLogger, "missing origin", "", "")
+			return
+		}
+
+		p, _ := principal.FromContext(c.Request.Context())
+		matcher := config.Default
+		if m, ok := config.Tenants[p.Tenant]; ok && p.Tenant != "" {
+			matcher = m
+		}
+
+		if !matcher.AllowsURL(source) {
+			deny(c, baseLogger, "origin not allowed", source, p.Tenant)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func deny(c *gin.Context, baseLogger *zap.Logger, reason, source, tenant string) {
+	metrics.HotlinkDenied.Inc()
+	logger.FromContext(c.Request.Context(), baseLogger).Warn("key request denied by hotlink policy",
+		zap.String("reason", reason),
+		zap.String("origin", source),
+		zap.String("tenant", tenant),
+		zap.String("ip", c.ClientIP()),
+		zap.String("path", c.Request.URL.Path),
+	)
+	c.AbortWithStatusJSON(http.StatusForbidden, ErrorBody(c, "Origin not allowed"))
+}
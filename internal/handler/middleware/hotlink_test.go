@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"hls-key-server-go/internal/pkg/principal"
+)
+
+func mustOriginMatcher(t *testing.T, patterns ...string) *OriginMatcher {
+	t.Helper()
+	m, err := NewOriginMatcher(patterns)
+	if err != nil {
+		t.Fatalf("NewOriginMatcher() error = %v", err)
+	}
+	return m
+}
+
+func TestOriginMatcher(t *testing.T) {
+	t.Parallel()
+
+	m := mustOriginMatcher(t, "example.com", "*.cdn.example.net")
+
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{raw: "https://example.com", want: true},
+		{raw: "https://EXAMPLE.com:8443/player", want: true},
+		{raw: "https://www.example.com", want: false},
+		{raw: "https://a.cdn.example.net", want: true},
+		{raw: "https://a.b.cdn.example.net/page?x=1", want: true},
+		{raw: "https://cdn.example.net", want: false},
+		{raw: "https://evilcdn.example.net", want: false},
+		{raw: "https://example.com.evil.org", want: false},
+		{raw: "ftp://example.com", want: false},
+		{raw: "null", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := m.AllowsURL(tt.raw); got != tt.want {
+			t.Errorf("AllowsURL(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+
+	if !mustOriginMatcher(t, "*").AllowsURL("https://anything.test") {
+		t.Error("wildcard matcher rejected host")
+	}
+	if !mustOriginMatcher(t, "*").AllowsAny() || m.AllowsAny() {
+		t.Error("AllowsAny() must report only the \"*\" pattern")
+	}
+
+	for _, bad := range []string{"*.*.example.com", "ex*ample.com", "example.com/path"} {
+		if _, err := NewOriginMatcher([]string{bad}); err == nil {
+			t.Errorf("NewOriginMatcher(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestHotlink(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	config := &HotlinkConfig{
+		Default: mustOriginMatcher(t, "player.example.com"),
+		Tenants: map[string]*OriginMatcher{
+			"acme": mustOriginMatcher(t, "*.acme.tv"),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		origin     string
+		referer    string
+		tenant     string
+		allowEmpty bool
+		wantStatus int
+	}{
+		{name: "allowed origin", origin: "https://player.example.com", wantStatus: http.StatusOK},
+		{name: "allowed referer", referer: "https://player.example.com/watch/1", wantStatus: http.StatusOK},
+		{name: "pirate origin", origin: "https://pirate.example.org", wantStatus: http.StatusForbidden},
+		{name: "origin preferred over referer", origin: "https://pirate.example.org", referer: "https://player.example.com/", wantStatus: http.StatusForbidden},
+		{name: "tenant allow-list", origin: "https://www.acme.tv", tenant: "acme", wantStatus: http.StatusOK},
+		{name: "tenant does not inherit default", origin: "https://player.example.com", tenant: "acme", wantStatus: http.StatusForbidden},
+		{name: "unknown tenant uses default", origin: "https://player.example.com", tenant: "other", wantStatus: http.StatusOK},
+		{name: "missing origin rejected", wantStatus: http.StatusForbidden},
+		{name: "missing origin allowed", allowEmpty: true, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := *config
+			cfg.AllowEmpty = tt.allowEmpty
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.tenant != "" {
+					ctx := principal.WithPrincipal(c.Request.Context(), principal.Principal{Subject: "alice", Tenant: tt.tenant})
+					c.Request = c.Request.WithContext(ctx)
+				}
+			}, Hotlink(&cfg))
+			router.POST("/key", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/key", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@
 package middleware
 
 import (
-	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -15,6 +14,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"hls-key-server-go/internal/pkg/logger"
 )
 
 type Fn func(c *gin.Context) []zapcore.Field
@@ -43,12 +44,14 @@ type Config struct {
 // It receives:
 //  1. A time package format string (e.g. time.RFC3339).
 //  2. A boolean stating whether to use UTC time zone or local.
-func Ginzap(logger ZapLogger, timeFormat string, utc bool, category string) gin.HandlerFunc {
-	return GinzapWithConfig(logger, &Config{TimeFormat: timeFormat, UTC: utc, DefaultLevel: zapcore.InfoLevel, Category: category})
+func Ginzap(baseLogger ZapLogger, timeFormat string, utc bool, category string) gin.HandlerFunc {
+	return GinzapWithConfig(baseLogger, &Config{TimeFormat: timeFormat, UTC: utc, DefaultLevel: zapcore.InfoLevel, Category: category})
 }
 
-// GinzapWithConfig returns a gin.HandlerFunc using configs
-func GinzapWithConfig(logger ZapLogger, conf *Config) gin.HandlerFunc {
+// GinzapWithConfig returns a gin.HandlerFunc using configs.
+// With a *zap.Logger, entries carry the request and trace IDs, and responses
+// with status 4xx and 5xx are raised to Warn and Error.
+func GinzapWithConfig(baseLogger ZapLogger, conf *Config) gin.HandlerFunc {
 	skipPaths := make(map[string]bool, len(conf.SkipPaths))
 	for _, path := range conf.SkipPaths {
 		skipPaths[path] = true
@@ -61,67 +64,84 @@ func GinzapWithConfig(logger ZapLogger, conf *Config) gin.HandlerFunc {
 		query := c.Request.URL.RawQuery
 		c.Next()
 
-		if _, ok := skipPaths[path]; !ok {
-			end := time.Now()
-			latency := end.Sub(start)
-			if conf.UTC {
-				end = end.UTC()
-			}
-			category := conf.Category
-
-			fields := []zapcore.Field{
-				zap.Int("status", c.Writer.Status()),
-				zap.String("method", c.Request.Method),
-				zap.String("path", path),
-				zap.String("query", query),
-				zap.String("ip", c.ClientIP()),
-				zap.String("user-agent", c.Request.UserAgent()),
-				zap.Duration("latency", latency),
-				zap.String("mag", getRequestBody(c)),
-				zap.String("category", category),
-			}
-			if conf.TimeFormat != "" {
-				fields = append(fields, zap.String("time", end.Format(conf.TimeFormat)))
-			}
+		if _, ok := skipPaths[path]; ok {
+			return
+		}
 
-			if conf.Context != nil {
-				fields = append(fields, conf.Context(c)...)
-			}
+		end := time.Now()
+		latency := end.Sub(start)
+		if conf.UTC {
+			end = end.UTC()
+		}
+		status := c.Writer.Status()
+
+		fields := []zapcore.Field{
+			zap.Int("status", status),
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.String("query", query),
+			zap.String("ip", c.ClientIP()),
+			zap.String("user-agent", c.Request.UserAgent()),
+			zap.Duration("latency", latency),
+			zap.Int("size", c.Writer.Size()),
+		}
+		if conf.Category != "" {
+			fields = append(fields, zap.String("category", conf.Category))
+		}
+		if conf.TimeFormat != "" {
+			fields = append(fields, zap.String("time", end.Format(conf.TimeFormat)))
+		}
 
+		if conf.Context != nil {
+			fields = append(fields, conf.Context(c)...)
+		}
+
+		zl, ok := baseLogger.(*zap.Logger)
+		if !ok {
 			if len(c.Errors) > 0 {
-				// Append error field if this is an erroneous request.
 				for _, e := range c.Errors.Errors() {
-					logger.Error(e, fields...)
+					baseLogger.Error(e, fields...)
 				}
 			} else {
-				zl, ok := logger.(*zap.Logger)
-				if ok {
-					zl.Log(conf.DefaultLevel, path, fields...)
-				} else {
-					logger.Error(path, fields...)
-				}
+				baseLogger.Info(path, fields...)
 			}
+			return
 		}
-	}
-}
 
-func getRequestBody(c *gin.Context) string {
-	bodyAsByteArray, _ := io.ReadAll(c.Request.Body)
-	jsonBody := string(bodyAsByteArray)
-	return jsonBody
+		log := logger.FromContext(c.Request.Context(), zl)
+		if len(c.Errors) > 0 {
+			// Append error field if this is an erroneous request.
+			for _, e := range c.Errors.Errors() {
+				log.Error(e, fields...)
+			}
+			return
+		}
+		level := conf.DefaultLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = zapcore.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = zapcore.WarnLevel
+		}
+		log.Log(level, path, fields...)
+	}
 }
 
 func defaultHandleRecovery(c *gin.Context, err interface{}) {
 	c.AbortWithStatus(http.StatusInternalServerError)
 }
 
+// redactedHeaders always carry credentials and are masked in logged requests
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
 // RecoveryWithZap returns a gin.HandlerFunc (middleware)
 // that recovers from any panics and logs requests using uber-go/zap.
 // All errors are logged using zap.Error().
 // stack means whether output the stack info.
 // The stack info is easy to find where the error occurs but the stack info is too large.
-func RecoveryWithZap(logger ZapLogger, stack bool) gin.HandlerFunc {
-	return CustomRecoveryWithZap(logger, stack, defaultHandleRecovery)
+// sensitive returns further headers to mask besides Authorization and Cookie; it may be nil.
+func RecoveryWithZap(baseLogger ZapLogger, stack bool, sensitive func() []string) gin.HandlerFunc {
+	return CustomRecoveryWithZap(baseLogger, stack, defaultHandleRecovery, sensitive)
 }
 
 // CustomRecoveryWithZap returns a gin.HandlerFunc (middleware) with a custom recovery handler
@@ -129,7 +149,8 @@ func RecoveryWithZap(logger ZapLogger, stack bool) gin.HandlerFunc {
 // All errors are logged using zap.Error().
 // stack means whether output the stack info.
 // The stack info is easy to find where the error occurs but the stack info is too large.
-func CustomRecoveryWithZap(logger ZapLogger, stack bool, recovery gin.RecoveryFunc) gin.HandlerFunc {
+// Credential headers are masked in the logged request, see RecoveryWithZap.
+func CustomRecoveryWithZap(baseLogger ZapLogger, stack bool, recovery gin.RecoveryFunc, sensitive func() []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
@@ -144,9 +165,9 @@ func CustomRecoveryWithZap(logger ZapLogger, stack bool, recovery gin.RecoveryFu
 					}
 				}
 
-				httpRequest, _ := httputil.DumpRequest(c.Request, false)
+				httpRequest := dumpRequest(c.Request, sensitive)
 				if brokenPipe {
-					logger.Error(c.Request.URL.Path,
+					baseLogger.Error(c.Request.URL.Path,
 						zap.Any("error", err),
 						zap.String("request", string(httpRequest)),
 					)
@@ -157,14 +178,14 @@ func CustomRecoveryWithZap(logger ZapLogger, stack bool, recovery gin.RecoveryFu
 				}
 
 				if stack {
-					logger.Error("[Recovery from panic]",
+					baseLogger.Error("[Recovery from panic]",
 						zap.Time("time", time.Now()),
 						zap.Any("error", err),
 						zap.String("request", string(httpRequest)),
 						zap.String("stack", string(debug.Stack())),
 					)
 				} else {
-					logger.Error("[Recovery from panic]",
+					baseLogger.Error("[Recovery from panic]",
 						zap.Time("time", time.Now()),
 						zap.Any("error", err),
 						zap.String("request", string(httpRequest)),
@@ -176,3 +197,20 @@ func CustomRecoveryWithZap(logger ZapLogger, stack bool, recovery gin.RecoveryFu
 		c.Next()
 	}
 }
+
+// dumpRequest dumps the request line and headers with credential headers masked
+func dumpRequest(r *http.Request, sensitive func() []string) []byte {
+	masked := redactedHeaders
+	if sensitive != nil {
+		masked = append(append([]string{}, redactedHeaders...), sensitive()...)
+	}
+
+	req := r.Clone(r.Context())
+	for _, h := range masked {
+		if h != "" && len(req.Header.Values(h)) > 0 {
+			req.Header.Set(h, "[REDACTED]")
+		}
+	}
+	dump, _ := httputil.DumpRequest(req, false)
+	return dump
+}
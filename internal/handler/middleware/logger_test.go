@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGinzapWithConfig(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zap.DebugLevel)
+	router := gin.New()
+	router.Use(RequestID())
+	router.Use(GinzapWithConfig(zap.New(core), &Config{
+		SkipPaths:    []string{"/healthz"},
+		DefaultLevel: zapcore.InfoLevel,
+	}))
+	router.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/broken", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+
+	for _, path := range []string{"/healthz", "/ok", "/missing", "/broken"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Request-ID", "req"+path[1:])
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := []struct {
+		path  string
+		level zapcore.Level
+	}{
+		{"/ok", zapcore.InfoLevel},
+		{"/missing", zapcore.WarnLevel},
+		{"/broken", zapcore.ErrorLevel},
+	}
+	entries := logs.All()
+	if len(entries) != len(want) {
+		t.Fatalf("logged %d entries, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		fields := entries[i].ContextMap()
+		if fields["path"] != w.path || entries[i].Level != w.level {
+			t.Errorf("entry %d = %s at %s, want %s at %s", i, fields["path"], entries[i].Level, w.path, w.level)
+		}
+		if fields["request_id"] != "req"+w.path[1:] {
+			t.Errorf("entry %d request_id = %v", i, fields["request_id"])
+		}
+	}
+}
+
+func TestRecoveryWithZap_RedactsCredentials(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zap.DebugLevel)
+	router := gin.New()
+	router.Use(RecoveryWithZap(zap.New(core), false, func() []string { return []string{"X-Player-Key"} }))
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Cookie", "session=secret-cookie")
+	req.Header.Set("X-Player-Key", "secret-header")
+	req.Header.Set("User-Agent", "test-player")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	dump, _ := entries[0].ContextMap()["request"].(string)
+	for _, secret := range []string{"secret-token", "secret-cookie", "secret-header"} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("logged request contains %q:\n%s", secret, dump)
+		}
+	}
+	if !strings.Contains(dump, "test-player") || !strings.Contains(dump, "[REDACTED]") {
+		t.Errorf("logged request = %s", dump)
+	}
+	// The handler chain still sees the original headers
+	if req.Header.Get("Authorization") != "Bearer secret-token" {
+		t.Error("request headers were modified")
+	}
+}
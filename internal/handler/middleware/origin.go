@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OriginMatcher matches request origins against a domain allow-list.
+// Patterns are exact hostnames, "*.example.com" for any subdomain of
+// example.com, or "*" for any host.
+type OriginMatcher struct {
+	any      bool
+	exact    map[string]struct{}
+	suffixes []string
+}
+
+// NewOriginMatcher compiles domain patterns into a matcher
+func NewOriginMatcher(patterns []string) (*OriginMatcher, error) {
+	m := &OriginMatcher{exact: make(map[string]struct{})}
+
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case p == "":
+			continue
+		case p == "*":
+			m.any = true
+		case strings.HasPrefix(p, "*."):
+			if strings.Contains(p[2:], "*") {
+				return nil, fmt.Errorf("invalid domain pattern %q", p)
+			}
+			m.suffixes = append(m.suffixes, p[1:])
+		case strings.Contains(p, "*") || strings.Contains(p, "/"):
+			return nil, fmt.Errorf("invalid domain pattern %q", p)
+		default:
+			m.exact[p] = struct{}{}
+		}
+	}
+
+	return m, nil
+}
+
+// AllowsHost reports whether hostname is in the allow-list
+func (m *OriginMatcher) AllowsHost(host string) bool {
+	if m == nil || host == "" {
+		return false
+	}
+	if m.any {
+		return true
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if _, ok := m.exact[host]; ok {
+		return true
+	}
+	for _, suffix := range m.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAny reports whether the allow-list contains "*"
+func (m *OriginMatcher) AllowsAny() bool {
+	return m != nil && m.any
+}
+
+// AllowsURL reports whether the host of an Origin or Referer value is in the allow-list
+func (m *OriginMatcher) AllowsURL(raw string) bool {
+	return m.AllowsHost(hostOf(raw))
+}
+
+func hostOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.Hostname()
+}
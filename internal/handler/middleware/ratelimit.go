@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/ratelimit"
+)
+
+// KeyFunc derives the rate limit bucket key for a request
+type KeyFunc func(c *gin.Context) string
+
+// RateLimitConfig holds rate limit middleware configuration
+type RateLimitConfig struct {
+	// Name identifies the policy in bucket keys, metrics and logs
+	Name    string
+	Store   ratelimit.Store
+	Policy  ratelimit.Policy
+	KeyFunc KeyFunc
+	Logger  *zap.Logger
+}
+
+// KeyByIP limits by client IP address
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyBySubject limits by JWT subject, falling back to client IP when unauthenticated
+func KeyBySubject(c *gin.Context) string {
+	if sub := Subject(c); sub != "" {
+		return "sub:" + sub
+	}
+	return KeyByIP(c)
+}
+
+// KeyByKeyName limits by requested HLS key name
+func KeyByKeyName(c *gin.Context) string {
+	keyName := c.Query("key")
+	if keyName == "" {
+		keyName = c.PostForm("key")
+	}
+	if keyName == "" {
+		keyName = "stream.key"
+	}
+	return "key:" + keyName
+}
+
+// KeyFuncByName resolves a configured key selector (ip, subject or key)
+func KeyFuncByName(name string) (KeyFunc, error) {
+	switch name {
+	case "", "ip":
+		return KeyByIP, nil
+	case "subject", "sub":
+		return KeyBySubject, nil
+	case "key":
+		return KeyByKeyName, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit key %q", name)
+	}
+}
+
+// RateLimit returns a middleware enforcing a token bucket policy.
+// Rejected requests receive 429 with a Retry-After header.
+// Store failures are logged and the request is allowed through.
+func RateLimit(config *RateLimitConfig) gin.HandlerFunc {
+	keyFunc := config.KeyFunc
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+	baseLogger := config.Logger
+	if baseLogger == nil {
+		baseLogger = zap.NewNop()
+	}
+
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+
+		res, err := config.Store.Take(c.Request.Context(), config.Name+":"+key, config.Policy)
+		if err != nil {
+			metrics.ErrorsTotal.WithLabelValues("rate_limit_store").Inc()
+			logger.FromContext(c.Request.Context(), baseLogger).Warn("rate limit store error, allowing request",
+				zap.String("policy", config.Name),
+				zap.Error(err),
+			)
+			c.Next()
+			return
+		}
+
+		if !res.Allowed {
+			retryAfter := int(math.Ceil(res.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+
+			metrics.RateLimited.WithLabelValues(config.Name).Inc()
+			logger.FromContext(c.Request.Context(), baseLogger).Warn("rate limit exceeded",
+				zap.String("policy", config.Name),
+				zap.String("limit_key", key),
+				zap.String("ip", c.ClientIP()),
+			)
+
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorBody(c, "Too many requests"))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"hls-key-server-go/internal/pkg/ratelimit"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(_ context.Context, _ string, _ ratelimit.Policy) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store down")
+}
+
+func newRateLimitRouter(config *RateLimitConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(config))
+	router.POST("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	router := newRateLimitRouter(&RateLimitConfig{
+		Name:    "test",
+		Store:   ratelimit.NewMemoryStore(),
+		Policy:  ratelimit.Policy{Rate: 0.5, Burst: 2},
+		KeyFunc: KeyByIP,
+	})
+
+	codes := make([]int, 0, 3)
+	var last *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/test", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+		last = w
+	}
+
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Errorf("request %d status = %d, want %d", i+1, codes[i], want[i])
+		}
+	}
+	if got := last.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want %q", got, "2")
+	}
+
+	// A different client IP has its own bucket
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	req.RemoteAddr = "192.0.2.2:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("other client status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimit_StoreErrorFailsOpen(t *testing.T) {
+	t.Parallel()
+
+	router := newRateLimitRouter(&RateLimitConfig{
+		Name:   "test",
+		Store:  failingStore{},
+		Policy: ratelimit.Policy{Rate: 1, Burst: 1},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d when store fails", w.Code, http.StatusOK)
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		keyFunc KeyFunc
+		url     string
+		claims  map[string]any
+		want    string
+	}{
+		{name: "ip", keyFunc: KeyByIP, url: "/", want: "ip:192.0.2.1"},
+		{name: "subject without claims falls back to ip", keyFunc: KeyBySubject, url: "/", want: "ip:192.0.2.1"},
+		{name: "subject", keyFunc: KeyBySubject, url: "/", claims: map[string]any{"sub": "alice"}, want: "sub:alice"},
+		{name: "key name", keyFunc: KeyByKeyName, url: "/?key=movie.key", want: "key:movie.key"},
+		{name: "default key name", keyFunc: KeyByKeyName, url: "/", want: "key:stream.key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, tt.url, nil)
+			c.Request.RemoteAddr = "192.0.2.1:1234"
+			if tt.claims != nil {
+				c.Set(ClaimsKey, jwtClaims(tt.claims))
+			}
+
+			if got := tt.keyFunc(c); got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyFuncByName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"", "ip", "subject", "sub", "key"} {
+		if _, err := KeyFuncByName(name); err != nil {
+			t.Errorf("KeyFuncByName(%q) error = %v", name, err)
+		}
+	}
+	if _, err := KeyFuncByName("cookie"); err == nil {
+		t.Error("KeyFuncByName(\"cookie\") error = nil, want error")
+	}
+}
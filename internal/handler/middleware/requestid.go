@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"hls-key-server-go/internal/pkg/requestid"
+)
+
+// RequestID returns a middleware that accepts a valid X-Request-ID from the
+// client or generates one, stores it on the request context and echoes it in
+// the response. Place it first, after tracing, so every later log line and
+// error body carries it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		// Tag the request span so traces can be found by request ID
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("http.request_id", id))
+
+		c.Request = c.Request.WithContext(requestid.WithID(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+		c.Next()
+	}
+}
+
+// ErrorBody builds the JSON error response body, including the request ID
+// so clients can quote it to support
+func ErrorBody(c *gin.Context, message string) gin.H {
+	body := gin.H{"error": message}
+	if id := requestid.FromContext(c.Request.Context()); id != "" {
+		body["request_id"] = id
+	}
+	return body
+}
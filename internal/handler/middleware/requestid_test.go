@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "accepts client id", incoming: "player-7f3a:42", wantSame: true},
+		{name: "generates when missing", incoming: ""},
+		{name: "replaces unsafe id", incoming: "bad id\nwith newline"},
+		{name: "replaces oversized id", incoming: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			core, logs := observer.New(zap.InfoLevel)
+			base := zap.New(core)
+
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/test", func(c *gin.Context) {
+				logger.FromContext(c.Request.Context(), base).Info("handling")
+				c.JSON(http.StatusNotFound, ErrorBody(c, "Key not found"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestid.Header, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(requestid.Header)
+			if tt.wantSame && id != tt.incoming {
+				t.Errorf("echoed id = %q, want %q", id, tt.incoming)
+			}
+			if !tt.wantSame && (id == tt.incoming || !requestid.Valid(id)) {
+				t.Errorf("echoed id = %q, want a generated id", id)
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["request_id"] != id || body["error"] != "Key not found" {
+				t.Errorf("error body = %v, want request_id %q", body, id)
+			}
+
+			entries := logs.All()
+			if len(entries) != 1 || entries[0].ContextMap()["request_id"] != id {
+				t.Errorf("log entries = %+v, want request_id %q", entries, id)
+			}
+		})
+	}
+}
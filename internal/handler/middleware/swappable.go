@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Swappable is a middleware slot whose implementation can be replaced while
+// serving, so policies rebuilt on config reload apply to the next request
+// without re-registering routes. An empty slot passes requests through.
+type Swappable struct {
+	h atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewSwappable creates a slot holding h; h may be nil
+func NewSwappable(h gin.HandlerFunc) *Swappable {
+	s := &Swappable{}
+	s.Store(h)
+	return s
+}
+
+// Store replaces the middleware; nil empties the slot
+func (s *Swappable) Store(h gin.HandlerFunc) {
+	if h == nil {
+		s.h.Store(nil)
+		return
+	}
+	s.h.Store(&h)
+}
+
+// Handler returns the middleware to register on routes
+func (s *Swappable) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h := s.h.Load()
+		if h == nil {
+			c.Next()
+			return
+		}
+		(*h)(c)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSwappable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	slot := NewSwappable(nil)
+	router := gin.New()
+	router.GET("/", slot.Handler(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("empty slot: status = %d, want %d", code, http.StatusOK)
+	}
+
+	slot.Store(func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusForbidden)
+	})
+	if code := get(); code != http.StatusForbidden {
+		t.Fatalf("after Store: status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	slot.Store(nil)
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("after clearing: status = %d, want %d", code, http.StatusOK)
+	}
+}
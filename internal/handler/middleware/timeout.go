@@ -72,9 +72,7 @@ func TimeoutWithConfig(config *TimeoutConfig) gin.HandlerFunc {
 			return
 		case <-ctx.Done():
 			// Timeout occurred
-			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
-				"error": config.ErrorMessage,
-			})
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, ErrorBody(c, config.ErrorMessage))
 			return
 		}
 	}
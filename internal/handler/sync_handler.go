@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/handler/middleware"
+	"hls-key-server-go/internal/pkg/audit"
+	"hls-key-server-go/internal/pkg/keysync"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/service"
+)
+
+// SyncHandler serves encrypted key snapshots to pull-based replicas
+type SyncHandler struct {
+	exporter *service.ExportService
+	codec    *keysync.Codec
+	audit    *audit.Logger
+	logger   *zap.Logger
+}
+
+// NewSyncHandler creates a new sync handler; auditor may be nil
+func NewSyncHandler(exporter *service.ExportService, codec *keysync.Codec, auditor *audit.Logger, logger *zap.Logger) *SyncHandler {
+	return &SyncHandler{
+		exporter: exporter,
+		codec:    codec,
+		audit:    auditor,
+		logger:   logger,
+	}
+}
+
+// Auth admits requests bearing the token derived from sync.secret
+func (h *SyncHandler) Auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || !h.codec.VerifyToken(token) {
+			logger.FromContext(c.Request.Context(), h.logger).Warn("sync export accessed with invalid token",
+				zap.String("ip", c.ClientIP()),
+			)
+			e := middleware.AuditEvent(c, audit.TypeAccessDenied, audit.OutcomeDenied)
+			e.Reason = "invalid sync token"
+			e.Details = map[string]string{"path": c.FullPath()}
+			h.audit.Record(c.Request.Context(), e)
+
+			c.AbortWithStatusJSON(http.StatusUnauthorized, middleware.ErrorBody(c, "Unauthorized"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// Export handles a replica pulling keys
+// @Summary Export keys to a replica
+// @Description Returns an encrypted snapshot of every key, or of the changes since the given epoch and revision. Authenticated with the bearer token derived from sync.secret.
+// @Tags Sync
+// @Produce application/vnd.hls-key-sync.v2
+// @Security BearerAuth
+// @Param epoch query string false "Origin epoch of the replica's last sync"
+// @Param since query int false "Revision of the replica's last sync"
+// @Param nonce query string true "Random value bound into the sealed snapshot"
+// @Success 200 {string} string "Sealed snapshot"
+// @Success 304 "Replica is up to date"
+// @Failure 400 {object} map[string]string "Invalid revision or nonce"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Export failed"
+// @Router /api/v1/sync/export [get]
+func (h *SyncHandler) Export(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := logger.FromContext(ctx, h.logger)
+
+	var since uint64
+	if v := c.Query(keysync.ParamSince); v != "" {
+		var err error
+		if since, err = strconv.ParseUint(v, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid revision"))
+			return
+		}
+	}
+
+	nonce := c.Query(keysync.ParamNonce)
+	if nonce == "" || len(nonce) > keysync.MaxNonceLength {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "Invalid nonce"))
+		return
+	}
+	req := &keysync.Request{Epoch: c.Query(keysync.ParamEpoch), Since: since, Nonce: nonce}
+
+	snap, err := h.exporter.Export(ctx, req.Epoch, since)
+	if err == nil && snap == nil {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	var sealed []byte
+	if err == nil {
+		sealed, err = h.codec.Seal(snap, req)
+	}
+	if err != nil {
+		log.Error("key export failed", zap.Error(err))
+		e := middleware.AuditEvent(c, audit.TypeKeyExport, audit.OutcomeFailure)
+		e.Reason = err.Error()
+		h.audit.Record(ctx, e)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "Export failed"))
+		return
+	}
+
+	details := map[string]string{
+		"revision": strconv.FormatUint(snap.Revision, 10),
+		"since":    strconv.FormatUint(since, 10),
+		"full":     strconv.FormatBool(snap.Full),
+		"keys":     strconv.Itoa(len(snap.Keys)),
+		"deleted":  strconv.Itoa(len(snap.Deleted)),
+	}
+	log.Info("keys exported to replica",
+		zap.String("ip", c.ClientIP()),
+		zap.Uint64("revision", snap.Revision),
+		zap.Bool("full", snap.Full),
+		zap.Int("keys", len(snap.Keys)),
+		zap.Int("deleted", len(snap.Deleted)),
+	)
+	e := middleware.AuditEvent(c, audit.TypeKeyExport, audit.OutcomeSuccess)
+	e.Details = details
+	h.audit.Record(ctx, e)
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, keysync.ContentType, sealed)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/pkg/keysync"
+	"hls-key-server-go/internal/repository"
+	"hls-key-server-go/internal/service"
+)
+
+func newTestOrigin(t *testing.T) (*httptest.Server, *repository.FileKeyRepository, *keysync.Codec) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keys, err := repository.NewFileKeyRepository(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	codec, err := keysync.NewCodec("a-shared-secret-of-at-least-32-chars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewSyncHandler(service.NewExportService(keys, zap.NewNop()), codec, nil, zap.NewNop())
+
+	router := gin.New()
+	router.GET(repository.ExportPath, h.Auth(), h.Export)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv, keys, codec
+}
+
+func TestSyncHandler_Export(t *testing.T) {
+	srv, _, codec := newTestOrigin(t)
+
+	tests := []struct {
+		name  string
+		query string
+		token string
+		want  int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "raw secret is not the token", token: "Bearer a-shared-secret-of-at-least-32-chars", want: http.StatusUnauthorized},
+		{name: "invalid revision", token: "Bearer " + codec.Token(), query: "?since=-1&nonce=n1", want: http.StatusBadRequest},
+		{name: "missing nonce", token: "Bearer " + codec.Token(), want: http.StatusBadRequest},
+		{name: "full snapshot", token: "Bearer " + codec.Token(), query: "?nonce=n1", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+repository.ExportPath+tt.query, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusOK && resp.Header.Get("Content-Type") != keysync.ContentType {
+				t.Errorf("Content-Type = %q", resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// TestSyncHandler_Replica pulls from a real origin through RemoteKeyRepository
+func TestSyncHandler_Replica(t *testing.T) {
+	ctx := context.Background()
+	srv, origin, codec := newTestOrigin(t)
+	if err := origin.Put(ctx, "a.key", []byte("aaaa-0123456789ab")); err != nil {
+		t.Fatal(err)
+	}
+
+	replica, err := repository.NewRemoteKeyRepository(srv.URL, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replica.Reload(ctx); err != nil {
+		t.Fatalf("first Reload() error = %v", err)
+	}
+	if got, err := replica.Get(ctx, "a.key"); err != nil || string(got) != "aaaa-0123456789ab" {
+		t.Fatalf("Get(a.key) = %q, %v", got, err)
+	}
+
+	// Unchanged origin answers 304
+	if err := replica.Reload(ctx); err != nil {
+		t.Fatalf("unchanged Reload() error = %v", err)
+	}
+
+	if err := origin.Put(ctx, "b.key", []byte("bbbb-0123456789ab")); err != nil {
+		t.Fatal(err)
+	}
+	if err := origin.Delete(ctx, "a.key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := replica.Reload(ctx); err != nil {
+		t.Fatalf("delta Reload() error = %v", err)
+	}
+	if names := replica.List(ctx); len(names) != 1 || names[0] != "b.key" {
+		t.Errorf("replica keys after delta = %v, want [b.key]", names)
+	}
+}
@@ -0,0 +1,392 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the timestamp inserted into rotated file names
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// FileSink writes hash-chained events as JSON lines, rotating the file by
+// size. The chain continues across rotations: the first record of a new
+// file links to the last record of the previous one.
+type FileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file     *os.File
+	size     int64
+	seq      uint64
+	lastHash string
+	now      func() time.Time
+
+	signer          ed25519.PrivateKey
+	checkpointEvery int
+	sinceCheckpoint int
+}
+
+// FileSinkOption configures optional FileSink behavior
+type FileSinkOption func(*FileSink)
+
+// WithCheckpoints appends an Ed25519-signed checkpoint record after every
+// `every` records and when the sink is closed
+func WithCheckpoints(signer ed25519.PrivateKey, every int) FileSinkOption {
+	return func(s *FileSink) {
+		s.signer = signer
+		s.checkpointEvery = every
+	}
+}
+
+// NewFileSink opens (or creates) path and resumes the hash chain from its
+// last record. maxSize is in bytes (0 disables rotation); maxBackups limits
+// retained rotated files (0 keeps all).
+func NewFileSink(path string, maxSize int64, maxBackups int, opts ...FileSinkOption) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create audit directory: %w", err)
+	}
+
+	s := &FileSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	last, torn, err := s.lastEvent()
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		s.seq = last.Seq
+		s.lastHash = last.Hash
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	if torn > 0 {
+		// Record the repair in the chain so the dropped bytes are accounted for
+		err := s.append(Event{
+			Time:    s.now().UTC(),
+			Type:    TypeAuditRepair,
+			Outcome: OutcomeSuccess,
+			Reason:  "truncated torn final record",
+			Details: map[string]string{"bytes": strconv.FormatInt(torn, 10)},
+		})
+		if err != nil {
+			_ = s.file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Write chains e to the previous record and appends it
+func (s *FileSink) Write(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	if err := s.append(e); err != nil {
+		return err
+	}
+
+	s.sinceCheckpoint++
+	if s.signer != nil && s.checkpointEvery > 0 && s.sinceCheckpoint >= s.checkpointEvery {
+		return s.checkpoint()
+	}
+	return nil
+}
+
+// append assigns chain fields to e and writes it, rotating first if needed
+func (s *FileSink) append(e Event) error {
+	e.Seq = s.seq + 1
+	e.PrevHash = s.lastHash
+	hash, err := ComputeHash(e)
+	if err != nil {
+		return fmt.Errorf("hash audit event: %w", err)
+	}
+	e.Hash = hash
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode audit event: %w", err)
+	}
+	line = append(line, '\n')
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+		// A new file starts with a checkpoint so verification can tell
+		// pruned files from records deleted at the start of the chain
+		if s.signer != nil && e.Type != TypeCheckpoint {
+			if err := s.checkpoint(); err != nil {
+				return err
+			}
+			return s.append(e)
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write audit event: %w", err)
+	}
+
+	s.seq = e.Seq
+	s.lastHash = e.Hash
+	return nil
+}
+
+// checkpoint appends a record signing the current chain head
+func (s *FileSink) checkpoint() error {
+	seq := s.seq + 1
+	sig := ed25519.Sign(s.signer, CheckpointMessage(seq, s.lastHash))
+
+	err := s.append(Event{
+		Time:    s.now().UTC(),
+		Type:    TypeCheckpoint,
+		Outcome: OutcomeSuccess,
+		Details: map[string]string{
+			"key_id":    KeyID(s.signer.Public().(ed25519.PublicKey)),
+			"signature": base64.StdEncoding.EncodeToString(sig),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("write audit checkpoint: %w", err)
+	}
+	s.sinceCheckpoint = 0
+	return nil
+}
+
+// Close flushes and closes the current file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	var err error
+	if s.signer != nil && s.sinceCheckpoint > 0 {
+		err = s.checkpoint()
+	}
+	if serr := s.file.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("open audit file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("stat audit file: %w", err)
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("sync audit file: %w", err)
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("close audit file: %w", err)
+	}
+	s.file = nil
+
+	if err := os.Rename(s.path, s.rotatedName(s.now())); err != nil {
+		return fmt.Errorf("rotate audit file: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+
+	return s.prune()
+}
+
+// rotatedName inserts a timestamp before the extension: audit.jsonl → audit-<ts>.jsonl
+func (s *FileSink) rotatedName(t time.Time) string {
+	ext := filepath.Ext(s.path)
+	return strings.TrimSuffix(s.path, ext) + "-" + t.UTC().Format(rotatedTimeFormat) + ext
+}
+
+func (s *FileSink) prune() error {
+	if s.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := Backups(s.path)
+	if err != nil {
+		return err
+	}
+	for len(backups) > s.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("remove old audit file: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// lastEvent returns the newest record in the active file, falling back to
+// the newest rotated file when the active one is empty or missing. A torn
+// final line in the active file is truncated and its size returned.
+func (s *FileSink) lastEvent() (*Event, int64, error) {
+	e, torn, err := lastEventIn(s.path, true)
+	if err != nil || e != nil {
+		return e, torn, err
+	}
+
+	backups, err := Backups(s.path)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, file := range reverse(backups) {
+		e, _, err := lastEventIn(file, false)
+		if err != nil {
+			return nil, 0, err
+		}
+		if e != nil {
+			return e, torn, nil
+		}
+	}
+	return nil, torn, nil
+}
+
+// Files returns the rotated files of path followed by path itself, oldest first
+func Files(path string) ([]string, error) {
+	backups, err := Backups(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		backups = append(backups, path)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return backups, nil
+}
+
+// Backups returns the rotated files of path, oldest first
+func Backups(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	matches, err := filepath.Glob(strings.TrimSuffix(path, ext) + "-*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	// Fixed-width UTC timestamps sort lexically in time order
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// lastEventIn returns the last record in file. A final line without a
+// newline is a write torn by a crash: with repair, one that is not valid
+// JSON is truncated and its size returned, and one that is gets its newline.
+func lastEventIn(file string, repair bool) (*Event, int64, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("open audit file: %w", err)
+	}
+	defer f.Close()
+
+	var last, tail []byte
+	var end int64 // offset just past the last newline
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			tail = line
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("read audit file: %w", err)
+		}
+		end += int64(len(line))
+		if len(bytes.TrimSpace(line)) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+
+	var torn int64
+	if repair && len(bytes.TrimSpace(tail)) > 0 {
+		if json.Valid(tail) {
+			err = appendNewline(file)
+		} else {
+			torn, tail = int64(len(tail)), nil
+			err = os.Truncate(file, end)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("repair torn audit record in %s: %w", file, err)
+		}
+	}
+	if len(bytes.TrimSpace(tail)) > 0 {
+		last = tail
+	}
+	if last == nil {
+		return nil, torn, nil
+	}
+
+	var e Event
+	if err := json.Unmarshal(last, &e); err != nil {
+		return nil, 0, fmt.Errorf("decode last audit record of %s: %w", file, err)
+	}
+	return &e, torn, nil
+}
+
+// appendNewline terminates a record whose newline was not written
+func appendNewline(file string) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte{'\n'}); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func reverse(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
@@ -0,0 +1,247 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// readEvents returns every event in files, in order
+func readEvents(t *testing.T, files ...string) []Event {
+	t.Helper()
+
+	var events []Event
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var e Event
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				t.Fatalf("decode %s: %v", file, err)
+			}
+			events = append(events, e)
+		}
+		_ = f.Close()
+	}
+	return events
+}
+
+// checkChain asserts sequence numbers and hashes link up
+func checkChain(t *testing.T, events []Event) {
+	t.Helper()
+
+	prev := ""
+	for i, e := range events {
+		if e.Seq != uint64(i+1) {
+			t.Errorf("event %d seq = %d, want %d", i, e.Seq, i+1)
+		}
+		if e.PrevHash != prev {
+			t.Errorf("event %d prev_hash = %q, want %q", i, e.PrevHash, prev)
+		}
+		want, err := ComputeHash(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Hash != want {
+			t.Errorf("event %d hash = %q, want %q", i, e.Hash, want)
+		}
+		prev = e.Hash
+	}
+}
+
+func TestFileSink_ChainAndResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	sink, err := NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	l := New(zap.NewNop(), sink)
+	l.Record(t.Context(), Event{Type: TypeKeyFetch, Outcome: OutcomeSuccess, Subject: "alice", KeyID: "stream.key"})
+	l.Record(t.Context(), Event{Type: TypeKeyFetch, Outcome: OutcomeDenied, Subject: "bob", KeyID: "stream.key"})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening continues the chain
+	sink, err = NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatalf("NewFileSink() reopen error = %v", err)
+	}
+	l = New(zap.NewNop(), sink)
+	l.Record(t.Context(), Event{Type: TypeKeyReload, Outcome: OutcomeSuccess})
+	_ = l.Close()
+
+	events := readEvents(t, path)
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	checkChain(t, events)
+	if events[1].Subject != "bob" || events[1].Outcome != OutcomeDenied {
+		t.Errorf("event 2 = %+v", events[1])
+	}
+	if events[0].Time.Location() != time.UTC {
+		t.Errorf("event time not UTC: %v", events[0].Time)
+	}
+
+	// Tampering breaks the chain
+	events[0].Subject = "mallory"
+	if h, _ := ComputeHash(events[0]); h == events[0].Hash {
+		t.Error("hash unchanged after tampering")
+	}
+}
+
+func TestFileSink_TornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	sink, err := NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	l := New(zap.NewNop(), sink)
+	l.Record(t.Context(), Event{Type: TypeKeyFetch, Outcome: OutcomeSuccess, KeyID: "stream.key"})
+	l.Record(t.Context(), Event{Type: TypeKeyFetch, Outcome: OutcomeSuccess, KeyID: "stream.key"})
+	_ = l.Close()
+
+	// A crash mid-write leaves part of a record without its newline
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := `{"seq":3,"time":"2026-`
+	if _, err := f.WriteString(torn); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	sink, err = NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatalf("NewFileSink() after torn write error = %v", err)
+	}
+	l = New(zap.NewNop(), sink)
+	l.Record(t.Context(), Event{Type: TypeKeyReload, Outcome: OutcomeSuccess})
+	_ = l.Close()
+
+	events := readEvents(t, path)
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+	checkChain(t, events)
+	if e := events[2]; e.Type != TypeAuditRepair || e.Details["bytes"] != strconv.Itoa(len(torn)) {
+		t.Errorf("event 3 = %+v, want a repair of %d bytes", e, len(torn))
+	}
+	if report, err := Verify([]string{path}, VerifyOptions{}); err != nil || !report.OK() {
+		t.Errorf("Verify() = %+v, %v", report, err)
+	}
+
+	// A complete record missing only its newline is kept
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-1], 0o640); err != nil {
+		t.Fatal(err)
+	}
+	sink, err = NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatalf("NewFileSink() after missing newline error = %v", err)
+	}
+	l = New(zap.NewNop(), sink)
+	l.Record(t.Context(), Event{Type: TypeKeyReload, Outcome: OutcomeSuccess})
+	_ = l.Close()
+
+	events = readEvents(t, path)
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5", len(events))
+	}
+	checkChain(t, events)
+}
+
+func TestFileSink_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	sink, err := NewFileSink(path, 600, 0)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	tick := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+
+	l := New(zap.NewNop(), sink)
+	for i := 0; i < 10; i++ {
+		l.Record(t.Context(), Event{Type: TypeKeyFetch, Outcome: OutcomeSuccess, KeyID: "stream.key"})
+	}
+	_ = l.Close()
+
+	files, err := Files(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 3 {
+		t.Fatalf("expected rotation into several files, got %v", files)
+	}
+
+	events := readEvents(t, files...)
+	if len(events) != 10 {
+		t.Fatalf("got %d events across files, want 10", len(events))
+	}
+	checkChain(t, events)
+
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 600 {
+			t.Errorf("%s size %d exceeds max size", f, info.Size())
+		}
+	}
+}
+
+func TestFileSink_MaxBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	sink, err := NewFileSink(path, 300, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tick := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+
+	l := New(zap.NewNop(), sink)
+	for i := 0; i < 10; i++ {
+		l.Record(t.Context(), Event{Type: TypeKeyFetch, Outcome: OutcomeSuccess})
+	}
+	_ = l.Close()
+
+	backups, err := Backups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("kept %d backups, want 2", len(backups))
+	}
+}
+
+func TestLogger_Nil(t *testing.T) {
+	var l *Logger
+	l.Record(t.Context(), Event{Type: TypeKeyFetch})
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() on nil logger error = %v", err)
+	}
+}
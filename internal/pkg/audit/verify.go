@@ -0,0 +1,253 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// VerifyOptions controls audit chain verification
+type VerifyOptions struct {
+	// PublicKey verifies checkpoint signatures; nil skips signature checks.
+	// With a key the chain must also be sealed: it needs a checkpoint, must
+	// start at seq 1 or with a checkpoint, and may end with at most
+	// CheckpointEvery unsealed records.
+	PublicKey ed25519.PublicKey
+	// CheckpointEvery is the audit.checkpoint-every the files were written
+	// with; 0 skips the unsealed tail check
+	CheckpointEvery int
+	// From and To bound the disclosure summary; zero values are unbounded
+	From time.Time
+	To   time.Time
+}
+
+// Break describes the first record failing verification
+type Break struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Seq    uint64 `json:"seq"`
+	Reason string `json:"reason"`
+}
+
+// Disclosure summarizes successful key fetches of one key by one subject
+type Disclosure struct {
+	KeyID   string    `json:"key_id"`
+	Subject string    `json:"subject"`
+	Tenant  string    `json:"tenant,omitempty"`
+	Count   int       `json:"count"`
+	First   time.Time `json:"first"`
+	Last    time.Time `json:"last"`
+}
+
+// Report is the result of verifying a sequence of audit files
+type Report struct {
+	Files       []string `json:"files"`
+	Records     int      `json:"records"`
+	FirstSeq    uint64   `json:"first_seq"`
+	LastSeq     uint64   `json:"last_seq"`
+	Checkpoints int      `json:"checkpoints"`
+	// SignaturesChecked reports whether checkpoint signatures were verified
+	SignaturesChecked bool   `json:"signatures_checked"`
+	LastCheckpointSeq uint64 `json:"last_checkpoint_seq"`
+	// Unsealed counts records after the last checkpoint
+	Unsealed    int          `json:"unsealed"`
+	Broken      *Break       `json:"broken,omitempty"`
+	Disclosures []Disclosure `json:"disclosures"`
+}
+
+// OK reports whether the whole chain verified
+func (r *Report) OK() bool {
+	return r.Broken == nil
+}
+
+type disclosureKey struct {
+	keyID, subject, tenant string
+}
+
+// Verify walks files (oldest first) as one hash chain. Verification stops
+// at the first broken record; the disclosure summary covers records up to it.
+// Only I/O failures are returned as errors.
+func Verify(files []string, opts VerifyOptions) (*Report, error) {
+	r := &Report{
+		Files:             files,
+		SignaturesChecked: opts.PublicKey != nil,
+	}
+	v := &verifier{opts: opts, report: r, disclosures: make(map[disclosureKey]*Disclosure)}
+
+	for _, file := range files {
+		if err := v.file(file); err != nil {
+			return nil, err
+		}
+		if r.Broken != nil {
+			break
+		}
+	}
+	if r.Broken == nil && opts.PublicKey != nil {
+		v.sealed()
+	}
+
+	r.Disclosures = make([]Disclosure, 0, len(v.disclosures))
+	for _, d := range v.disclosures {
+		r.Disclosures = append(r.Disclosures, *d)
+	}
+	sort.Slice(r.Disclosures, func(i, j int) bool {
+		a, b := r.Disclosures[i], r.Disclosures[j]
+		if a.KeyID != b.KeyID {
+			return a.KeyID < b.KeyID
+		}
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		return a.Tenant < b.Tenant
+	})
+
+	return r, nil
+}
+
+type verifier struct {
+	opts        VerifyOptions
+	report      *Report
+	prev        *Event
+	disclosures map[disclosureKey]*Disclosure
+	// lastFile and lastLine locate the last accepted record
+	lastFile string
+	lastLine int
+}
+
+func (v *verifier) file(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			v.fail(file, line, 0, "malformed record: "+err.Error())
+			return nil
+		}
+		if reason := v.check(&e); reason != "" {
+			v.fail(file, line, e.Seq, reason)
+			return nil
+		}
+		v.accept(&e)
+		v.lastFile, v.lastLine = file, line
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", file, err)
+	}
+	return nil
+}
+
+// check returns why e does not extend the chain, or "" if it does
+func (v *verifier) check(e *Event) string {
+	if v.prev == nil {
+		// Older files may have been pruned, so the chain can start mid-way
+		if e.Seq == 1 && e.PrevHash != "" {
+			return "first record has a prev_hash"
+		}
+		// The sink opens each rotated file with a checkpoint, so a signed
+		// start is what distinguishes pruning from deleted records
+		if e.Seq != 1 && e.Type != TypeCheckpoint && v.opts.PublicKey != nil {
+			return fmt.Sprintf("chain starts at seq %d without a checkpoint", e.Seq)
+		}
+	} else {
+		if e.Seq != v.prev.Seq+1 {
+			return fmt.Sprintf("sequence gap: expected %d", v.prev.Seq+1)
+		}
+		if e.PrevHash != v.prev.Hash {
+			return "prev_hash does not match previous record"
+		}
+	}
+
+	hash, err := ComputeHash(*e)
+	if err != nil {
+		return "cannot hash record: " + err.Error()
+	}
+	if hash != e.Hash {
+		return "hash mismatch: record was modified"
+	}
+
+	if e.Type == TypeCheckpoint && v.opts.PublicKey != nil {
+		if e.Details["key_id"] != KeyID(v.opts.PublicKey) {
+			return "checkpoint signed by unknown key " + e.Details["key_id"]
+		}
+		sig, err := base64.StdEncoding.DecodeString(e.Details["signature"])
+		if err != nil || !ed25519.Verify(v.opts.PublicKey, CheckpointMessage(e.Seq, e.PrevHash), sig) {
+			return "invalid checkpoint signature"
+		}
+	}
+	return ""
+}
+
+func (v *verifier) accept(e *Event) {
+	r := v.report
+	if v.prev == nil {
+		r.FirstSeq = e.Seq
+	}
+	r.Records++
+	r.LastSeq = e.Seq
+	v.prev = e
+
+	if e.Type == TypeCheckpoint {
+		r.Checkpoints++
+		r.LastCheckpointSeq = e.Seq
+		r.Unsealed = 0
+		return
+	}
+	r.Unsealed++
+
+	if e.Type != TypeKeyFetch || e.Outcome != OutcomeSuccess {
+		return
+	}
+	if !v.opts.From.IsZero() && e.Time.Before(v.opts.From) {
+		return
+	}
+	if !v.opts.To.IsZero() && !e.Time.Before(v.opts.To) {
+		return
+	}
+
+	k := disclosureKey{keyID: e.KeyID, subject: e.Subject, tenant: e.Tenant}
+	d, ok := v.disclosures[k]
+	if !ok {
+		d = &Disclosure{KeyID: e.KeyID, Subject: e.Subject, Tenant: e.Tenant, First: e.Time}
+		v.disclosures[k] = d
+	}
+	d.Count++
+	if e.Time.Before(d.First) {
+		d.First = e.Time
+	}
+	if e.Time.After(d.Last) {
+		d.Last = e.Time
+	}
+}
+
+// sealed fails a chain that no checkpoint signs, or whose tail is longer
+// than the sink would have written without signing one
+func (v *verifier) sealed() {
+	r := v.report
+	switch {
+	case r.Checkpoints == 0:
+		v.fail(v.lastFile, v.lastLine, r.LastSeq, "no checkpoints")
+	case v.opts.CheckpointEvery > 0 && r.Unsealed > v.opts.CheckpointEvery:
+		v.fail(v.lastFile, v.lastLine, r.LastSeq, fmt.Sprintf("%d records after the last checkpoint exceed checkpoint interval %d", r.Unsealed, v.opts.CheckpointEvery))
+	}
+}
+
+func (v *verifier) fail(file string, line int, seq uint64, reason string) {
+	v.report.Broken = &Break{File: file, Line: line, Seq: seq, Reason: reason}
+}
@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// writeLog writes fetch events for subjects at hourly intervals, sealed by
+// checkpoints every two records, and returns the audit file path
+func writeLog(t *testing.T, priv ed25519.PrivateKey, subjects ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path, 0, 0, WithCheckpoints(priv, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(zap.NewNop(), sink)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, s := range subjects {
+		l.Record(t.Context(), Event{
+			Time:    start.Add(time.Duration(i) * time.Hour),
+			Type:    TypeKeyFetch,
+			Outcome: OutcomeSuccess,
+			Subject: s,
+			KeyID:   "stream.key",
+		})
+	}
+	l.Record(t.Context(), Event{Time: start, Type: TypeKeyFetch, Outcome: OutcomeDenied, Subject: "mallory", KeyID: "stream.key"})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerify_Intact(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	path := writeLog(t, priv, "alice", "bob", "alice")
+
+	r, err := Verify([]string{path}, VerifyOptions{PublicKey: pub})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !r.OK() {
+		t.Fatalf("Verify() broken = %+v", r.Broken)
+	}
+	// 4 events, a checkpoint after every 2 and a final one on close
+	if r.Records != 6 || r.Checkpoints != 2 || r.Unsealed != 0 {
+		t.Errorf("report = %+v", r)
+	}
+
+	if len(r.Disclosures) != 2 {
+		t.Fatalf("disclosures = %+v, want alice and bob", r.Disclosures)
+	}
+	alice := r.Disclosures[0]
+	if alice.Subject != "alice" || alice.Count != 2 || !alice.Last.After(alice.First) {
+		t.Errorf("alice disclosure = %+v", alice)
+	}
+
+	// Time range excludes the last fetch
+	r, _ = Verify([]string{path}, VerifyOptions{To: time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)})
+	if r.Disclosures[0].Count != 1 {
+		t.Errorf("ranged alice count = %d, want 1", r.Disclosures[0].Count)
+	}
+}
+
+func TestVerify_Broken(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name   string
+		edit   func(lines []string) []string
+		pub    ed25519.PublicKey
+		line   int
+		reason string
+	}{
+		{
+			name:   "modified record",
+			edit:   func(l []string) []string { l[1] = strings.Replace(l[1], `"bob"`, `"eve"`, 1); return l },
+			line:   2,
+			reason: "hash mismatch",
+		},
+		{
+			name:   "deleted record",
+			edit:   func(l []string) []string { return append(l[:1], l[2:]...) },
+			line:   2,
+			reason: "sequence gap",
+		},
+		{
+			name:   "malformed record",
+			edit:   func(l []string) []string { l[3] = "{"; return l },
+			line:   4,
+			reason: "malformed",
+		},
+		{
+			name:   "checkpoint from another key",
+			edit:   func(l []string) []string { return l },
+			pub:    otherPub,
+			line:   3,
+			reason: "unknown key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLog(t, priv, "alice", "bob", "alice")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.edit(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			key := pub
+			if tt.pub != nil {
+				key = tt.pub
+			}
+			r, err := Verify([]string{path}, VerifyOptions{PublicKey: key})
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if r.OK() {
+				t.Fatal("Verify() reported an intact chain")
+			}
+			if r.Broken.Line != tt.line || !strings.Contains(r.Broken.Reason, tt.reason) {
+				t.Errorf("broken = %+v, want line %d reason %q", r.Broken, tt.line, tt.reason)
+			}
+		})
+	}
+}
+
+func TestVerify_ForgedCheckpoint(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	path := writeLog(t, priv, "alice", "bob")
+
+	// Re-chaining a forged log without the signing key fails at the checkpoint
+	_, forger, _ := ed25519.GenerateKey(rand.Reader)
+	forged := writeLog(t, forger, "alice", "bob")
+	data, _ := os.ReadFile(forged)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Verify([]string{path}, VerifyOptions{PublicKey: pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.OK() || r.Broken.Seq != 3 {
+		t.Errorf("broken = %+v, want checkpoint at seq 3", r.Broken)
+	}
+}
+
+func TestVerify_Unsealed(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	rewrite := func(t *testing.T, path string, edit func([]string) []string) {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := edit(strings.Split(strings.TrimSpace(string(data)), "\n"))
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unsigned := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(unsigned, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(Event{Type: TypeKeyFetch, Outcome: OutcomeSuccess, Subject: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = sink.Close()
+
+	// Dropping the final checkpoint leaves the last two records unsealed
+	truncated := writeLog(t, priv, "alice", "bob", "alice")
+	rewrite(t, truncated, func(l []string) []string { return l[:len(l)-1] })
+
+	// Deleting the first record makes the chain start mid-way without a checkpoint
+	headless := writeLog(t, priv, "alice", "bob", "alice")
+	rewrite(t, headless, func(l []string) []string { return l[1:] })
+
+	tests := []struct {
+		name   string
+		path   string
+		every  int
+		reason string
+	}{
+		{name: "no checkpoints", path: unsigned, reason: "no checkpoints"},
+		{name: "tail within interval", path: truncated, every: 2},
+		{name: "tail beyond interval", path: truncated, every: 1, reason: "exceed checkpoint interval 1"},
+		{name: "start without checkpoint", path: headless, reason: "starts at seq 2 without a checkpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Verify([]string{tt.path}, VerifyOptions{PublicKey: pub, CheckpointEvery: tt.every})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.reason == "" {
+				if !r.OK() {
+					t.Errorf("broken = %+v, want intact", r.Broken)
+				}
+				return
+			}
+			if r.OK() || !strings.Contains(r.Broken.Reason, tt.reason) {
+				t.Errorf("broken = %+v, want reason %q", r.Broken, tt.reason)
+			}
+		})
+	}
+
+	// Without a public key only the chain itself is checked
+	if r, _ := Verify([]string{headless}, VerifyOptions{}); !r.OK() {
+		t.Errorf("unsigned verify broken = %+v", r.Broken)
+	}
+}
+
+func TestVerify_PrunedRotation(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	sink, err := NewFileSink(path, 1000, 2, WithCheckpoints(priv, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tick := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+	l := New(zap.NewNop(), sink)
+	for i := 0; i < 30; i++ {
+		l.Record(t.Context(), Event{Type: TypeKeyFetch, Outcome: OutcomeSuccess, Subject: "alice", KeyID: "stream.key"})
+	}
+	_ = l.Close()
+
+	files, err := Files(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Verify(files, VerifyOptions{PublicKey: pub, CheckpointEvery: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each rotated file opens with a checkpoint, so the pruned chain still verifies
+	if !r.OK() || r.FirstSeq == 1 {
+		t.Errorf("report = %+v, broken = %+v, want an intact chain starting after pruned files", r, r.Broken)
+	}
+}
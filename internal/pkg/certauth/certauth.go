@@ -0,0 +1,78 @@
+// Package certauth maps verified client certificate identities to principals
+package certauth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"path"
+
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/principal"
+)
+
+// Mapper resolves client certificates to principals using ordered rules
+type Mapper struct {
+	rules []configs.CertPrincipal
+}
+
+// New validates rules and returns a Mapper
+func New(rules []configs.CertPrincipal) (*Mapper, error) {
+	for i, r := range rules {
+		if r.Match == "" || r.Subject == "" {
+			return nil, fmt.Errorf("cert principal %d: match and subject are required", i)
+		}
+		if _, err := path.Match(r.Match, ""); err != nil {
+			return nil, fmt.Errorf("cert principal %d: invalid match %q: %w", i, r.Match, err)
+		}
+		for _, k := range r.Keys {
+			if _, err := path.Match(k, ""); err != nil {
+				return nil, fmt.Errorf("cert principal %d: invalid key pattern %q: %w", i, k, err)
+			}
+		}
+	}
+	return &Mapper{rules: rules}, nil
+}
+
+// Identities returns the names a certificate can be matched by:
+// DNS SANs, URI SANs, email SANs and finally the subject CN
+func Identities(cert *x509.Certificate) []string {
+	ids := make([]string, 0, len(cert.DNSNames)+len(cert.URIs)+len(cert.EmailAddresses)+1)
+	ids = append(ids, cert.DNSNames...)
+	for _, u := range cert.URIs {
+		ids = append(ids, u.String())
+	}
+	ids = append(ids, cert.EmailAddresses...)
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	return ids
+}
+
+// Resolve returns the principal of the first rule matching any certificate identity
+func (m *Mapper) Resolve(cert *x509.Certificate) (principal.Principal, bool) {
+	ids := Identities(cert)
+
+	for _, r := range m.rules {
+		for _, id := range ids {
+			if ok, _ := path.Match(r.Match, id); !ok {
+				continue
+			}
+
+			keys := r.Keys
+			if keys != nil && len(keys) == 0 {
+				keys = nil
+			}
+			return principal.Principal{
+				Subject: r.Subject,
+				Tenant:  r.Tenant,
+				Claims: map[string]any{
+					"auth_method": "mtls",
+					"cert_id":     id,
+				},
+				Keys: keys,
+			}, true
+		}
+	}
+
+	return principal.Principal{}, false
+}
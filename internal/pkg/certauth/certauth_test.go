@@ -0,0 +1,67 @@
+package certauth
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"hls-key-server-go/internal/configs"
+)
+
+func TestMapper_Resolve(t *testing.T) {
+	m, err := New([]configs.CertPrincipal{
+		{Match: "*.encoder.example.com", Subject: "encoder", Tenant: "acme", Keys: []string{"acme-*.key"}},
+		{Match: "spiffe://example.org/ns/*/sa/packager", Subject: "packager"},
+		{Match: "ops@example.com", Subject: "ops"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	spiffe, _ := url.Parse("spiffe://example.org/ns/media/sa/packager")
+
+	tests := []struct {
+		name    string
+		cert    *x509.Certificate
+		subject string
+		ok      bool
+	}{
+		{name: "dns san", cert: &x509.Certificate{DNSNames: []string{"eu1.encoder.example.com"}}, subject: "encoder", ok: true},
+		{name: "common name", cert: &x509.Certificate{Subject: pkix.Name{CommonName: "us.encoder.example.com"}}, subject: "encoder", ok: true},
+		{name: "uri san", cert: &x509.Certificate{URIs: []*url.URL{spiffe}}, subject: "packager", ok: true},
+		{name: "email san", cert: &x509.Certificate{EmailAddresses: []string{"ops@example.com"}}, subject: "ops", ok: true},
+		{name: "unmapped", cert: &x509.Certificate{DNSNames: []string{"other.example.com"}}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := m.Resolve(tt.cert)
+			if ok != tt.ok {
+				t.Fatalf("Resolve() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && p.Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", p.Subject, tt.subject)
+			}
+		})
+	}
+
+	p, _ := m.Resolve(&x509.Certificate{DNSNames: []string{"eu1.encoder.example.com"}})
+	if p.Tenant != "acme" || !p.AllowsKey("acme-live.key") || p.AllowsKey("other.key") {
+		t.Errorf("encoder principal = %+v, want tenant acme restricted to acme-*.key", p)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	cases := [][]configs.CertPrincipal{
+		{{Match: "", Subject: "x"}},
+		{{Match: "a", Subject: ""}},
+		{{Match: "[", Subject: "x"}},
+		{{Match: "a", Subject: "x", Keys: []string{"["}}},
+	}
+	for _, rules := range cases {
+		if _, err := New(rules); err == nil {
+			t.Errorf("New(%+v) expected error", rules)
+		}
+	}
+}
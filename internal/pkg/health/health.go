@@ -0,0 +1,167 @@
+// Package health provides liveness, readiness and startup probes built from
+// named dependency checks
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Probe results
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+var (
+	// ErrNotStarted is reported until startup completes
+	ErrNotStarted = errors.New("startup not complete")
+	// ErrDraining is reported once shutdown has begun
+	ErrDraining = errors.New("server is draining")
+)
+
+// Check reports the health of one dependency; a nil error is healthy
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of one named check
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the outcome of a probe with a per-check breakdown
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker holds the checks behind each probe and the server lifecycle state
+type Checker struct {
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+	timeout   time.Duration
+	started   atomic.Bool
+	draining  atomic.Bool
+}
+
+// New creates a checker; each probe's checks share a deadline of timeout
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// AddLiveness registers a check that fails /livez; keep these to conditions
+// a restart would fix
+func (c *Checker) AddLiveness(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.liveness = append(c.liveness, namedCheck{name: name, check: check})
+}
+
+// AddReadiness registers a check that takes the server out of rotation
+func (c *Checker) AddReadiness(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readiness = append(c.readiness, namedCheck{name: name, check: check})
+}
+
+// MarkStarted records that initialization finished and listeners are up
+func (c *Checker) MarkStarted() {
+	c.started.Store(true)
+}
+
+// SetDraining marks the server as shutting down so readiness fails
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+// Draining reports whether shutdown has begun
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Live runs the liveness checks
+func (c *Checker) Live(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.liveness...)
+	c.mu.RUnlock()
+	return c.run(ctx, checks)
+}
+
+// Started reports whether startup has completed
+func (c *Checker) Started(ctx context.Context) Report {
+	return c.run(ctx, []namedCheck{{name: "startup", check: c.checkStarted}})
+}
+
+// Ready runs the readiness checks; a server still starting or draining is
+// never ready
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make([]namedCheck, 0, len(c.readiness)+2)
+	checks = append(checks,
+		namedCheck{name: "startup", check: c.checkStarted},
+		namedCheck{name: "draining", check: c.checkDraining},
+	)
+	checks = append(checks, c.readiness...)
+	c.mu.RUnlock()
+	return c.run(ctx, checks)
+}
+
+func (c *Checker) checkStarted(context.Context) error {
+	if !c.started.Load() {
+		return ErrNotStarted
+	}
+	return nil
+}
+
+func (c *Checker) checkDraining(context.Context) error {
+	if c.draining.Load() {
+		return ErrDraining
+	}
+	return nil
+}
+
+// run executes checks concurrently under the checker's timeout
+func (c *Checker) run(ctx context.Context, checks []namedCheck) Report {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = CheckResult{Status: StatusOK}
+			if err := nc.check(ctx); err != nil {
+				results[i] = CheckResult{Status: StatusFail, Error: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i, nc := range checks {
+		report.Checks[nc.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker_Lifecycle(t *testing.T) {
+	c := New(time.Second)
+	c.AddReadiness("keys", func(context.Context) error { return nil })
+	ctx := context.Background()
+
+	if r := c.Started(ctx); r.OK() || r.Checks["startup"].Error != ErrNotStarted.Error() {
+		t.Errorf("Started() before MarkStarted = %+v", r)
+	}
+	if r := c.Ready(ctx); r.OK() {
+		t.Errorf("Ready() before MarkStarted = %+v", r)
+	}
+	if r := c.Live(ctx); !r.OK() {
+		t.Errorf("Live() = %+v, want ok", r)
+	}
+
+	c.MarkStarted()
+	if r := c.Ready(ctx); !r.OK() || len(r.Checks) != 3 {
+		t.Errorf("Ready() after MarkStarted = %+v", r)
+	}
+
+	c.SetDraining()
+	r := c.Ready(ctx)
+	if r.OK() || r.Checks["draining"].Status != StatusFail || r.Checks["keys"].Status != StatusOK {
+		t.Errorf("Ready() while draining = %+v", r)
+	}
+	if !c.Started(ctx).OK() || !c.Live(ctx).OK() {
+		t.Error("draining must not fail startup or liveness")
+	}
+}
+
+func TestChecker_FailingChecks(t *testing.T) {
+	c := New(20 * time.Millisecond)
+	c.MarkStarted()
+	c.AddReadiness("broken", func(context.Context) error { return errors.New("disk gone") })
+	c.AddReadiness("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	r := c.Ready(context.Background())
+	if r.Status != StatusFail {
+		t.Fatalf("Ready() status = %s, want fail", r.Status)
+	}
+	if got := r.Checks["broken"]; got.Status != StatusFail || got.Error != "disk gone" {
+		t.Errorf("broken check = %+v", got)
+	}
+	if got := r.Checks["slow"]; got.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("slow check = %+v", got)
+	}
+}
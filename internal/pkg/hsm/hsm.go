@@ -0,0 +1,13 @@
+// Package hsm provides key-encryption keys held in hardware security modules.
+//
+// PKCS#11 support needs cgo and the pkcs11 build tag:
+//
+//	CGO_ENABLED=1 go build -tags pkcs11 ./cmd/server
+//
+// Without them OpenPKCS11 returns ErrUnsupported.
+package hsm
+
+import "errors"
+
+// ErrUnsupported is returned by OpenPKCS11 in builds without PKCS#11 support
+var ErrUnsupported = errors.New("hsm: built without PKCS#11 support (rebuild with CGO_ENABLED=1 -tags pkcs11)")
@@ -0,0 +1,299 @@
+//go:build pkcs11 && cgo
+
+package hsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+)
+
+// PKCS11 is an AES key-encryption key held in a PKCS#11 token. Content keys
+// are unwrapped inside the token into short-lived session objects whose
+// value is read out and destroyed; the KEK itself never leaves the token.
+//
+// Operations share one logged-in session and are serialized. A session the
+// token dropped, e.g. after a device reset, is reopened on the next call.
+type PKCS11 struct {
+	ctx       *pkcs11.Ctx
+	cfg       configs.KEKPKCS11
+	pin       string
+	mechanism uint
+	logger    *zap.Logger
+
+	mu      sync.Mutex
+	slot    uint
+	session pkcs11.SessionHandle
+	kek     pkcs11.ObjectHandle
+	open    bool
+}
+
+// OpenPKCS11 loads cfg.Module, logs in to the token and finds the KEK
+func OpenPKCS11(cfg configs.KEKPKCS11, logger *zap.Logger) (*PKCS11, error) {
+	var mechanism uint
+	switch cfg.Mechanism {
+	case "", "aes-key-wrap-pad":
+		mechanism = pkcs11.CKM_AES_KEY_WRAP_PAD
+	case "aes-key-wrap":
+		mechanism = pkcs11.CKM_AES_KEY_WRAP
+	default:
+		return nil, fmt.Errorf("unsupported pkcs11 mechanism %q", cfg.Mechanism)
+	}
+
+	pin, err := readPIN(cfg.PINFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := pkcs11.New(cfg.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("load pkcs11 module %s", cfg.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("initialize pkcs11 module: %w", err)
+	}
+
+	p := &PKCS11{
+		ctx:       ctx,
+		cfg:       cfg,
+		pin:       pin,
+		mechanism: mechanism,
+		logger:    logger,
+	}
+	p.mu.Lock()
+	err = p.openSession()
+	p.mu.Unlock()
+	if err != nil {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	return p, nil
+}
+
+// readPIN reads a PIN file, ignoring a trailing newline
+func readPIN(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read pin file: %w", err)
+	}
+	pin := strings.TrimRight(string(data), "\r\n")
+	if pin == "" {
+		return "", fmt.Errorf("pin file %s is empty", file)
+	}
+	return pin, nil
+}
+
+// openSession opens and logs in a session and finds the KEK; p.mu is held
+func (p *PKCS11) openSession() error {
+	slot, err := p.findSlot()
+	if err != nil {
+		return err
+	}
+	session, err := p.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		return fmt.Errorf("open pkcs11 session on slot %d: %w", slot, err)
+	}
+	// Login state is shared by all sessions of the application, so a
+	// reopened session may already be logged in
+	if err := p.ctx.Login(session, pkcs11.CKU_USER, p.pin); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		_ = p.ctx.CloseSession(session)
+		return fmt.Errorf("pkcs11 login: %w", err)
+	}
+	kek, err := p.findKey(session)
+	if err != nil {
+		_ = p.ctx.CloseSession(session)
+		return err
+	}
+
+	p.slot, p.session, p.kek, p.open = slot, session, kek, true
+	return nil
+}
+
+// findSlot returns cfg.Slot, or the slot whose token has cfg.TokenLabel
+func (p *PKCS11) findSlot() (uint, error) {
+	if p.cfg.TokenLabel == "" {
+		return p.cfg.Slot, nil
+	}
+
+	slots, err := p.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("list pkcs11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := p.ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if strings.TrimRight(info.Label, " \x00") == p.cfg.TokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("no pkcs11 token labeled %q", p.cfg.TokenLabel)
+}
+
+// findKey finds the single AES key labeled cfg.KeyLabel
+func (p *PKCS11) findKey(session pkcs11.SessionHandle) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, p.cfg.KeyLabel),
+	}
+	if err := p.ctx.FindObjectsInit(session, template); err != nil {
+		return 0, fmt.Errorf("find kek: %w", err)
+	}
+	objects, _, err := p.ctx.FindObjects(session, 2)
+	if finalErr := p.ctx.FindObjectsFinal(session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("find kek: %w", err)
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("no AES key labeled %q in the token", p.cfg.KeyLabel)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("more than one AES key labeled %q in the token", p.cfg.KeyLabel)
+	}
+}
+
+// closeSession drops the current session; p.mu is held
+func (p *PKCS11) closeSession() {
+	if p.open {
+		_ = p.ctx.CloseSession(p.session)
+		p.open = false
+	}
+}
+
+// sessionLost reports errors after which the session must be reopened
+func sessionLost(err error) bool {
+	var code pkcs11.Error
+	if !errors.As(err, &code) {
+		return false
+	}
+	switch code {
+	case pkcs11.CKR_SESSION_HANDLE_INVALID, pkcs11.CKR_SESSION_CLOSED,
+		pkcs11.CKR_DEVICE_REMOVED, pkcs11.CKR_DEVICE_ERROR,
+		pkcs11.CKR_TOKEN_NOT_PRESENT, pkcs11.CKR_USER_NOT_LOGGED_IN,
+		pkcs11.CKR_OBJECT_HANDLE_INVALID, pkcs11.CKR_KEY_HANDLE_INVALID:
+		return true
+	}
+	return false
+}
+
+// do runs op on the session, reopening a lost session and retrying once
+func (p *PKCS11) do(op func(session pkcs11.SessionHandle, kek pkcs11.ObjectHandle) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.open {
+		if err := p.openSession(); err != nil {
+			return err
+		}
+	}
+	err := op(p.session, p.kek)
+	if !sessionLost(err) {
+		return err
+	}
+
+	p.logger.Warn("pkcs11 session lost, reopening", zap.Error(err))
+	p.closeSession()
+	if err := p.openSession(); err != nil {
+		return err
+	}
+	return op(p.session, p.kek)
+}
+
+// Unwrap unwraps a content key inside the token and returns its value
+func (p *PKCS11) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	var key []byte
+	err := p.do(func(session pkcs11.SessionHandle, kek pkcs11.ObjectHandle) error {
+		template := []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_GENERIC_SECRET),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, false),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, true),
+		}
+		obj, err := p.ctx.UnwrapKey(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(p.mechanism, nil)}, kek, wrapped, template)
+		if err != nil {
+			return fmt.Errorf("pkcs11 unwrap: %w", err)
+		}
+		defer p.ctx.DestroyObject(session, obj)
+
+		attrs, err := p.ctx.GetAttributeValue(session, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil)})
+		if err != nil {
+			return fmt.Errorf("read unwrapped key: %w", err)
+		}
+		key = attrs[0].Value
+		return nil
+	})
+	return key, err
+}
+
+// Wrap wraps a content key with the KEK, for provisioning stored keys
+func (p *PKCS11) Wrap(_ context.Context, key []byte) ([]byte, error) {
+	var wrapped []byte
+	err := p.do(func(session pkcs11.SessionHandle, kek pkcs11.ObjectHandle) error {
+		template := []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_GENERIC_SECRET),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, key),
+		}
+		obj, err := p.ctx.CreateObject(session, template)
+		if err != nil {
+			return fmt.Errorf("import key: %w", err)
+		}
+		defer p.ctx.DestroyObject(session, obj)
+
+		wrapped, err = p.ctx.WrapKey(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(p.mechanism, nil)}, kek, obj)
+		if err != nil {
+			return fmt.Errorf("pkcs11 wrap: %w", err)
+		}
+		return nil
+	})
+	return wrapped, err
+}
+
+// HealthCheck verifies the session is still logged in, reopening it if the
+// token dropped it
+func (p *PKCS11) HealthCheck(_ context.Context) error {
+	return p.do(func(session pkcs11.SessionHandle, _ pkcs11.ObjectHandle) error {
+		info, err := p.ctx.GetSessionInfo(session)
+		if err != nil {
+			return err
+		}
+		if info.State != pkcs11.CKS_RO_USER_FUNCTIONS && info.State != pkcs11.CKS_RW_USER_FUNCTIONS {
+			return pkcs11.Error(pkcs11.CKR_USER_NOT_LOGGED_IN)
+		}
+		return nil
+	})
+}
+
+// Close logs out and unloads the module
+func (p *PKCS11) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.open {
+		_ = p.ctx.Logout(p.session)
+		p.closeSession()
+	}
+	err := p.ctx.Finalize()
+	p.ctx.Destroy()
+	return err
+}
@@ -0,0 +1,40 @@
+//go:build !(pkcs11 && cgo)
+
+package hsm
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+)
+
+// PKCS11 is a key-encryption key held in a PKCS#11 token. This build has
+// no PKCS#11 support.
+type PKCS11 struct{}
+
+// OpenPKCS11 returns ErrUnsupported
+func OpenPKCS11(_ configs.KEKPKCS11, _ *zap.Logger) (*PKCS11, error) {
+	return nil, ErrUnsupported
+}
+
+// Unwrap returns ErrUnsupported
+func (p *PKCS11) Unwrap(context.Context, []byte) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// Wrap returns ErrUnsupported
+func (p *PKCS11) Wrap(context.Context, []byte) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// HealthCheck returns ErrUnsupported
+func (p *PKCS11) HealthCheck(context.Context) error {
+	return ErrUnsupported
+}
+
+// Close does nothing
+func (p *PKCS11) Close() error {
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !(pkcs11 && cgo)
+
+package hsm
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+)
+
+func TestOpenPKCS11_Unsupported(t *testing.T) {
+	_, err := OpenPKCS11(configs.KEKPKCS11{Module: "/usr/lib/softhsm/libsofthsm2.so"}, zap.NewNop())
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("OpenPKCS11() error = %v, want ErrUnsupported", err)
+	}
+}
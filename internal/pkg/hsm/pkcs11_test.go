@@ -0,0 +1,223 @@
+//go:build pkcs11 && cgo
+
+package hsm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+)
+
+const (
+	testTokenLabel = "hls-test"
+	testUserPIN    = "1234"
+	testSOPIN      = "5678"
+	testKEKLabel   = "hls-kek"
+)
+
+// softHSMModule returns the SoftHSM v2 library from SOFTHSM2_LIB or a
+// common install location, skipping the test when there is none
+func softHSMModule(t *testing.T) string {
+	t.Helper()
+	candidates := []string{
+		os.Getenv("SOFTHSM2_LIB"),
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+	}
+	for _, lib := range candidates {
+		if lib == "" {
+			continue
+		}
+		if _, err := os.Stat(lib); err == nil {
+			return lib
+		}
+	}
+	t.Skip("SoftHSM v2 not found; install softhsm2 or set SOFTHSM2_LIB")
+	return ""
+}
+
+// newSoftHSM initializes a token in a private SoftHSM token directory,
+// generates an AES KEK in it and returns a matching config
+func newSoftHSM(t *testing.T) configs.KEKPKCS11 {
+	t.Helper()
+	module := softHSMModule(t)
+
+	dir := t.TempDir()
+	tokens := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokens, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, "softhsm2.conf")
+	if err := os.WriteFile(conf, []byte("directories.tokendir = "+tokens+"\nobjectstore.backend = file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		t.Fatalf("load %s", module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+	}()
+
+	slots, err := ctx.GetSlotList(false)
+	if err != nil || len(slots) == 0 {
+		t.Fatalf("GetSlotList() = %v, %v", slots, err)
+	}
+	if err := ctx.InitToken(slots[0], testSOPIN, testTokenLabel); err != nil {
+		t.Fatalf("InitToken() error = %v", err)
+	}
+
+	// SoftHSM moves an initialized token to a new slot
+	var slot uint
+	slots, _ = ctx.GetSlotList(true)
+	for _, s := range slots {
+		if info, err := ctx.GetTokenInfo(s); err == nil && strings.TrimRight(info.Label, " \x00") == testTokenLabel {
+			slot = s
+		}
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Login(session, pkcs11.CKU_SO, testSOPIN); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.InitPIN(session, testUserPIN); err != nil {
+		t.Fatal(err)
+	}
+	_ = ctx.Logout(session)
+	if err := ctx.Login(session, pkcs11.CKU_USER, testUserPIN); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ctx.GenerateKey(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_KEY_GEN, nil)}, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, 32),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, testKEKLabel),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_WRAP, true),
+		pkcs11.NewAttribute(pkcs11.CKA_UNWRAP, true),
+	})
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	_ = ctx.Logout(session)
+	_ = ctx.CloseSession(session)
+
+	pinFile := filepath.Join(dir, "pin")
+	if err := os.WriteFile(pinFile, []byte(testUserPIN+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return configs.KEKPKCS11{
+		Module:     module,
+		TokenLabel: testTokenLabel,
+		PINFile:    pinFile,
+		KeyLabel:   testKEKLabel,
+		Mechanism:  "aes-key-wrap-pad",
+	}
+}
+
+func TestPKCS11_WrapUnwrap(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSoftHSM(t)
+
+	kek, err := OpenPKCS11(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("OpenPKCS11() error = %v", err)
+	}
+	defer kek.Close()
+
+	if err := kek.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+
+	key := []byte("0123456789abcdef")
+	wrapped, err := kek.Wrap(ctx, key)
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	if bytes.Contains(wrapped, key) || len(wrapped) != 24 {
+		t.Fatalf("Wrap() = %x, want a 24-byte RFC 5649 wrapping", wrapped)
+	}
+
+	got, err := kek.Unwrap(ctx, wrapped)
+	if err != nil {
+		t.Fatalf("Unwrap() error = %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("Unwrap() = %x, want %x", got, key)
+	}
+
+	wrapped[len(wrapped)-1] ^= 1
+	if _, err := kek.Unwrap(ctx, wrapped); err == nil {
+		t.Error("Unwrap() expected error for a tampered key")
+	}
+}
+
+func TestPKCS11_ReopensLostSession(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSoftHSM(t)
+
+	kek, err := OpenPKCS11(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kek.Close()
+
+	wrapped, err := kek.Wrap(ctx, []byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate the token dropping the session
+	kek.mu.Lock()
+	_ = kek.ctx.CloseSession(kek.session)
+	kek.mu.Unlock()
+
+	if err := kek.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck() after lost session error = %v", err)
+	}
+	if _, err := kek.Unwrap(ctx, wrapped); err != nil {
+		t.Errorf("Unwrap() after lost session error = %v", err)
+	}
+}
+
+func TestOpenPKCS11_Errors(t *testing.T) {
+	cfg := newSoftHSM(t)
+
+	wrongPIN := filepath.Join(t.TempDir(), "pin")
+	if err := os.WriteFile(wrongPIN, []byte("0000"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	bad := cfg
+	bad.PINFile = wrongPIN
+	if _, err := OpenPKCS11(bad, zap.NewNop()); err == nil {
+		t.Error("OpenPKCS11() expected error for a wrong PIN")
+	}
+
+	bad = cfg
+	bad.KeyLabel = "missing"
+	if _, err := OpenPKCS11(bad, zap.NewNop()); err == nil {
+		t.Error("OpenPKCS11() expected error for a missing KEK")
+	}
+}
@@ -0,0 +1,151 @@
+// Package ippolicy evaluates CIDR allow and deny lists per key group or tenant
+package ippolicy
+
+import (
+	"fmt"
+	"net/netip"
+	"path"
+	"strings"
+
+	"hls-key-server-go/internal/configs"
+)
+
+// Decision is the outcome of a policy evaluation
+type Decision struct {
+	Allowed bool
+	// Rule names the matching rule, empty when no rule matched
+	Rule string
+	// Reason explains a denial
+	Reason string
+}
+
+type rule struct {
+	name    string
+	keys    []string
+	tenants map[string]struct{}
+	allow   []netip.Prefix
+	deny    []netip.Prefix
+}
+
+// Engine evaluates ordered IP policy rules
+type Engine struct {
+	rules []rule
+}
+
+// New compiles configured rules into an Engine
+func New(rules []configs.IPPolicyRule) (*Engine, error) {
+	e := &Engine{rules: make([]rule, 0, len(rules))}
+
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("rule-%d", i)
+		}
+		if len(r.Keys) == 0 && len(r.Tenants) == 0 {
+			return nil, fmt.Errorf("ip policy %s: at least one key pattern or tenant is required", name)
+		}
+
+		for _, pattern := range r.Keys {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("ip policy %s: invalid key pattern %q: %w", name, pattern, err)
+			}
+		}
+
+		allow, err := parsePrefixes(r.Allow)
+		if err != nil {
+			return nil, fmt.Errorf("ip policy %s allow: %w", name, err)
+		}
+		deny, err := parsePrefixes(r.Deny)
+		if err != nil {
+			return nil, fmt.Errorf("ip policy %s deny: %w", name, err)
+		}
+
+		tenants := make(map[string]struct{}, len(r.Tenants))
+		for _, t := range r.Tenants {
+			tenants[t] = struct{}{}
+		}
+
+		e.rules = append(e.rules, rule{
+			name:    name,
+			keys:    r.Keys,
+			tenants: tenants,
+			allow:   allow,
+			deny:    deny,
+		})
+	}
+
+	return e, nil
+}
+
+// Evaluate decides whether clientIP may fetch keyName on behalf of tenant.
+// Keys matched by no rule are allowed.
+func (e *Engine) Evaluate(clientIP, keyName, tenant string) Decision {
+	r := e.match(keyName, tenant)
+	if r == nil {
+		return Decision{Allowed: true}
+	}
+
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return Decision{Rule: r.name, Reason: "unparseable client address"}
+	}
+	addr = addr.Unmap()
+
+	for _, p := range r.deny {
+		if p.Contains(addr) {
+			return Decision{Rule: r.name, Reason: "address in deny list " + p.String()}
+		}
+	}
+
+	if len(r.allow) == 0 {
+		return Decision{Allowed: true, Rule: r.name}
+	}
+	for _, p := range r.allow {
+		if p.Contains(addr) {
+			return Decision{Allowed: true, Rule: r.name}
+		}
+	}
+
+	return Decision{Rule: r.name, Reason: "address not in allow list"}
+}
+
+func (e *Engine) match(keyName, tenant string) *rule {
+	for i := range e.rules {
+		r := &e.rules[i]
+		if tenant != "" {
+			if _, ok := r.tenants[tenant]; ok {
+				return r
+			}
+		}
+		for _, pattern := range r.keys {
+			if ok, _ := path.Match(pattern, keyName); ok {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// parsePrefixes parses CIDRs, accepting bare addresses as single-host prefixes
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse address %q: %w", v, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse cidr %q: %w", v, err)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
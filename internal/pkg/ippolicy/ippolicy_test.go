@@ -0,0 +1,106 @@
+package ippolicy
+
+import (
+	"testing"
+
+	"hls-key-server-go/internal/configs"
+)
+
+func TestEngine_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	engine, err := New([]configs.IPPolicyRule{
+		{
+			Name:  "qa",
+			Keys:  []string{"qa-*.key"},
+			Allow: []string{"10.0.0.0/8", "2001:db8::/32"},
+			Deny:  []string{"10.66.0.0/16"},
+		},
+		{
+			Name:    "b2b",
+			Tenants: []string{"acme"},
+			Allow:   []string{"198.51.100.7"},
+		},
+		{
+			Name: "blocked",
+			Keys: []string{"*.key"},
+			Deny: []string{"203.0.113.0/24"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ip       string
+		key      string
+		tenant   string
+		allowed  bool
+		wantRule string
+	}{
+		{name: "allowed network", ip: "10.1.2.3", key: "qa-stream.key", allowed: true, wantRule: "qa"},
+		{name: "ipv6 allowed network", ip: "2001:db8::1", key: "qa-stream.key", allowed: true, wantRule: "qa"},
+		{name: "ipv4-mapped ipv6", ip: "::ffff:10.1.2.3", key: "qa-stream.key", allowed: true, wantRule: "qa"},
+		{name: "deny overrides allow", ip: "10.66.1.1", key: "qa-stream.key", allowed: false, wantRule: "qa"},
+		{name: "outside allow list", ip: "192.0.2.1", key: "qa-stream.key", allowed: false, wantRule: "qa"},
+		{name: "tenant match", ip: "198.51.100.7", key: "feed.key", tenant: "acme", allowed: true, wantRule: "b2b"},
+		{name: "tenant outside allow list", ip: "198.51.100.8", key: "feed.key", tenant: "acme", allowed: false, wantRule: "b2b"},
+		{name: "fallback rule deny", ip: "203.0.113.5", key: "stream.key", allowed: false, wantRule: "blocked"},
+		{name: "fallback rule allow", ip: "192.0.2.1", key: "stream.key", allowed: true, wantRule: "blocked"},
+		{name: "unparseable address", ip: "not-an-ip", key: "qa-stream.key", allowed: false, wantRule: "qa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := engine.Evaluate(tt.ip, tt.key, tt.tenant)
+			if d.Allowed != tt.allowed {
+				t.Errorf("Evaluate() allowed = %v, want %v (reason %q)", d.Allowed, tt.allowed, d.Reason)
+			}
+			if d.Rule != tt.wantRule {
+				t.Errorf("Evaluate() rule = %q, want %q", d.Rule, tt.wantRule)
+			}
+			if !d.Allowed && d.Reason == "" {
+				t.Error("Evaluate() denial without reason")
+			}
+		})
+	}
+}
+
+func TestEngine_NoMatch(t *testing.T) {
+	t.Parallel()
+
+	engine, err := New([]configs.IPPolicyRule{{Keys: []string{"qa-*.key"}, Allow: []string{"10.0.0.0/8"}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if d := engine.Evaluate("192.0.2.1", "stream.key", ""); !d.Allowed || d.Rule != "" {
+		t.Errorf("Evaluate() = %+v, want allowed without rule", d)
+	}
+}
+
+func TestNew_InvalidRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule configs.IPPolicyRule
+	}{
+		{name: "no selector", rule: configs.IPPolicyRule{Allow: []string{"10.0.0.0/8"}}},
+		{name: "bad cidr", rule: configs.IPPolicyRule{Keys: []string{"*.key"}, Allow: []string{"10.0.0.0/33"}}},
+		{name: "bad address", rule: configs.IPPolicyRule{Keys: []string{"*.key"}, Deny: []string{"example.com"}}},
+		{name: "bad pattern", rule: configs.IPPolicyRule{Keys: []string{"[.key"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := New([]configs.IPPolicyRule{tt.rule}); err == nil {
+				t.Error("New() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package keysync defines the encrypted format in which an origin server
+// exports keys to pull-based replicas.
+//
+// Origin and replicas share one secret. HKDF-SHA256 derives two values from
+// it: a bearer token the replica presents, so the secret itself never
+// crosses the network, and an AES-256-GCM key sealing every snapshot.
+//
+// A snapshot sent to a replica is bound to the pull it answers: the
+// replica's epoch, revision and a fresh nonce are authenticated with it,
+// so a recorded response cannot be replayed to roll a replica back. The
+// bearer token itself is not protected, so the export must be served over
+// HTTPS.
+package keysync
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Export endpoint query parameters
+const (
+	// ParamEpoch is the origin epoch the replica last synced from
+	ParamEpoch = "epoch"
+	// ParamSince is the revision the replica last applied
+	ParamSince = "since"
+	// ParamNonce is a random value the replica picks for each pull
+	ParamNonce = "nonce"
+)
+
+// ContentType is the media type of a sealed snapshot
+const ContentType = "application/vnd.hls-key-sync.v2"
+
+// Format versions prefixing every sealed snapshot
+const (
+	// restVersion seals snapshots at rest, such as the replica cache file
+	restVersion byte = 1
+	// pullVersion seals snapshots bound to a Request
+	pullVersion byte = 2
+)
+
+// MaxNonceLength bounds the nonce an origin accepts
+const MaxNonceLength = 64
+
+// ErrOpen is returned for snapshots that fail authentication, including
+// ones sealed with a different secret
+var ErrOpen = errors.New("keysync: cannot open snapshot")
+
+// Snapshot is the plaintext of an export: every key when Full is set,
+// otherwise the changes since the revision the replica asked for
+type Snapshot struct {
+	// Epoch identifies one run of the origin; revisions restart with it
+	Epoch string `json:"epoch"`
+	// Revision is the origin's revision after these changes
+	Revision uint64 `json:"revision"`
+	// Full replaces the replica's keys instead of updating them
+	Full bool `json:"full"`
+	// Keys are created or changed keys by name
+	Keys map[string][]byte `json:"keys,omitempty"`
+	// Deleted are keys removed since the requested revision
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+// Request identifies the pull a snapshot answers
+type Request struct {
+	Epoch string
+	Since uint64
+	Nonce string
+}
+
+// NewNonce returns a random nonce for one pull
+func NewNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// additionalData authenticates the format version and, for pulls, req
+func additionalData(version byte, req *Request) []byte {
+	ad := []byte{version}
+	if req == nil {
+		return ad
+	}
+	ad = binary.AppendUvarint(ad, uint64(len(req.Epoch)))
+	ad = append(ad, req.Epoch...)
+	ad = binary.BigEndian.AppendUint64(ad, req.Since)
+	ad = binary.AppendUvarint(ad, uint64(len(req.Nonce)))
+	return append(ad, req.Nonce...)
+}
+
+// Codec authenticates sync requests and seals snapshots
+type Codec struct {
+	token string
+	aead  cipher.AEAD
+}
+
+// NewCodec derives the request token and snapshot key from secret
+func NewCodec(secret string) (*Codec, error) {
+	if secret == "" {
+		return nil, errors.New("keysync: empty secret")
+	}
+
+	token, err := hkdf.Key(sha256.New, []byte(secret), nil, "hls-key-sync token", 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive sync token: %w", err)
+	}
+	key, err := hkdf.Key(sha256.New, []byte(secret), nil, "hls-key-sync encryption", 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive sync key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{token: hex.EncodeToString(token), aead: aead}, nil
+}
+
+// Token is the bearer token replicas send to the export endpoint
+func (c *Codec) Token() string {
+	return c.token
+}
+
+// VerifyToken reports whether token matches, in constant time
+func (c *Codec) VerifyToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(c.token)) == 1
+}
+
+// Seal encodes and encrypts a snapshot answering req; a nil req seals a
+// snapshot at rest, which Open accepts only with a nil req
+func (c *Codec) Seal(snap *Snapshot, req *Request) ([]byte, error) {
+	plain, err := json.Marshal(snap)
+	if err != nil {
+		return nil, fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	version := restVersion
+	if req != nil {
+		version = pullVersion
+	}
+	out := make([]byte, 1+c.aead.NonceSize(), 1+c.aead.NonceSize()+len(plain)+c.aead.Overhead())
+	out[0] = version
+	if _, err := rand.Read(out[1:]); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(out, out[1:], plain, additionalData(version, req)), nil
+}
+
+// Open decrypts and decodes a snapshot sealed for req
+func (c *Codec) Open(data []byte, req *Request) (*Snapshot, error) {
+	version := restVersion
+	if req != nil {
+		version = pullVersion
+	}
+	headerLen := 1 + c.aead.NonceSize()
+	if len(data) < headerLen+c.aead.Overhead() || data[0] != version {
+		return nil, ErrOpen
+	}
+	plain, err := c.aead.Open(nil, data[1:headerLen], data[headerLen:], additionalData(version, req))
+	if err != nil {
+		return nil, ErrOpen
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(plain, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	return &snap, nil
+}
@@ -0,0 +1,93 @@
+package keysync
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCodec_SealOpen(t *testing.T) {
+	codec, err := NewCodec("a-shared-secret-of-at-least-32-chars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := &Snapshot{
+		Epoch:    "e1",
+		Revision: 7,
+		Keys:     map[string][]byte{"stream.key": []byte("0123456789abcdef")},
+		Deleted:  []string{"old.key"},
+	}
+
+	req := &Request{Epoch: "e1", Since: 5, Nonce: "n1"}
+	sealed, err := codec.Seal(snap, req)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if bytes.Contains(sealed, []byte("0123456789abcdef")) || bytes.Contains(sealed, []byte("stream.key")) {
+		t.Fatal("sealed snapshot contains plaintext")
+	}
+
+	got, err := codec.Open(sealed, req)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got.Epoch != "e1" || got.Revision != 7 || string(got.Keys["stream.key"]) != "0123456789abcdef" || len(got.Deleted) != 1 {
+		t.Errorf("Open() = %+v", got)
+	}
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := codec.Open(tampered, req); !errors.Is(err, ErrOpen) {
+		t.Errorf("Open(tampered) error = %v, want ErrOpen", err)
+	}
+
+	other, err := NewCodec("a-different-secret-of-32-characters!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Open(sealed, req); !errors.Is(err, ErrOpen) {
+		t.Errorf("Open() with another secret error = %v, want ErrOpen", err)
+	}
+
+	// A snapshot answers only the pull it was sealed for
+	for _, replay := range []*Request{
+		{Epoch: "e0", Since: 5, Nonce: "n1"},
+		{Epoch: "e1", Since: 4, Nonce: "n1"},
+		{Epoch: "e1", Since: 5, Nonce: "n2"},
+		nil,
+	} {
+		if _, err := codec.Open(sealed, replay); !errors.Is(err, ErrOpen) {
+			t.Errorf("Open() for request %+v error = %v, want ErrOpen", replay, err)
+		}
+	}
+
+	// Snapshots at rest open only without a request
+	rest, err := codec.Seal(snap, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codec.Open(rest, nil); err != nil {
+		t.Errorf("Open(at rest) error = %v", err)
+	}
+	if _, err := codec.Open(rest, &Request{}); !errors.Is(err, ErrOpen) {
+		t.Errorf("Open(at rest) as a pull error = %v, want ErrOpen", err)
+	}
+}
+
+func TestCodec_Token(t *testing.T) {
+	a, _ := NewCodec("a-shared-secret-of-at-least-32-chars")
+	b, _ := NewCodec("a-shared-secret-of-at-least-32-chars")
+
+	if a.Token() == "" || a.Token() == "a-shared-secret-of-at-least-32-chars" {
+		t.Fatalf("Token() = %q, want a value derived from the secret", a.Token())
+	}
+	if !a.VerifyToken(b.Token()) {
+		t.Error("tokens derived from the same secret differ")
+	}
+	if a.VerifyToken("wrong") {
+		t.Error("VerifyToken accepted a wrong token")
+	}
+	if _, err := NewCodec(""); err == nil {
+		t.Error("NewCodec(\"\") succeeded")
+	}
+}
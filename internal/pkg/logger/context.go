@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/pkg/requestid"
+)
+
+// FromContext returns base annotated with the correlation fields carried by
+// ctx, so every line logged while serving a request can be traced back to it
+func FromContext(ctx context.Context, base *zap.Logger) *zap.Logger {
+	fields := make([]zap.Field, 0, 3)
+	if id := requestid.FromContext(ctx); id != "" {
+		fields = append(fields, zap.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+	if len(fields) == 0 {
+		return base
+	}
+	return base.With(fields...)
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"hls-key-server-go/internal/pkg/requestid"
+)
+
+func TestFromContext(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	base := zap.New(core)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = requestid.WithID(ctx, "req-1")
+
+	FromContext(ctx, base).Info("correlated")
+	FromContext(context.Background(), base).Info("plain")
+
+	entries := logs.All()
+	fields := entries[0].ContextMap()
+	if fields["request_id"] != "req-1" || fields["trace_id"] != traceID.String() || fields["span_id"] != spanID.String() {
+		t.Errorf("correlated fields = %v", fields)
+	}
+	if len(entries[1].Context) != 0 {
+		t.Errorf("plain entry has fields %v", entries[1].ContextMap())
+	}
+}
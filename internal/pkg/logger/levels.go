@@ -0,0 +1,244 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrUnknownComponent is returned when setting the level of a component
+// that has no logger
+var ErrUnknownComponent = errors.New("unknown log component")
+
+// Levels holds the root log level and optional per-component overrides.
+// All levels can be changed at runtime; a change can revert itself after a
+// duration, so debug logging left on by mistake does not persist.
+type Levels struct {
+	root       zap.AtomicLevel
+	mu         sync.Mutex
+	rootBase   zapcore.Level
+	rootTimer  *time.Timer
+	rootExpiry time.Time
+	// rootGen counts root level changes, so a revert timer that fired
+	// while a newer change held mu does nothing
+	rootGen    uint64
+	components map[string]*componentLevel
+}
+
+// componentLevel follows the root level unless an override is set
+type componentLevel struct {
+	root     zap.AtomicLevel
+	override atomic.Pointer[zapcore.Level]
+	timer    *time.Timer
+	expiry   time.Time
+	gen      uint64
+}
+
+// Enabled implements zapcore.LevelEnabler
+func (c *componentLevel) Enabled(lvl zapcore.Level) bool {
+	if o := c.override.Load(); o != nil {
+		return lvl >= *o
+	}
+	return c.root.Enabled(lvl)
+}
+
+// LevelStatus describes the effective level of the root logger or a component
+type LevelStatus struct {
+	Level string `json:"level"`
+	// Override is true when a component level differs from the root level
+	Override bool `json:"override,omitempty"`
+	// RevertAt is when a temporary level change is undone
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// NewLevels creates a level registry with the given root level
+func NewLevels(level zapcore.Level) *Levels {
+	return &Levels{
+		root:       zap.NewAtomicLevelAt(level),
+		rootBase:   level,
+		components: make(map[string]*componentLevel),
+	}
+}
+
+// Root returns the root level enabler
+func (l *Levels) Root() zap.AtomicLevel {
+	return l.root
+}
+
+// component returns the enabler for name, registering it on first use
+func (l *Levels) component(name string) *componentLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.components[name]
+	if !ok {
+		c = &componentLevel{root: l.root}
+		l.components[name] = c
+	}
+	return c
+}
+
+// Set changes the level of component, or the root level when component is
+// empty. A positive revertAfter restores the previous setting afterwards.
+func (l *Levels) Set(component string, level zapcore.Level, revertAfter time.Duration) error {
+	if component == "" {
+		l.SetRoot(level, revertAfter)
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.components[component]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownComponent, component)
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+		c.expiry = time.Time{}
+	}
+	c.gen++
+	c.override.Store(&level)
+	if revertAfter > 0 {
+		gen := c.gen
+		c.expiry = time.Now().Add(revertAfter)
+		c.timer = time.AfterFunc(revertAfter, func() { l.revertComponent(c, gen) })
+	}
+	return nil
+}
+
+// SetRoot changes the root level. A positive revertAfter restores the
+// previous setting afterwards.
+func (l *Levels) SetRoot(level zapcore.Level, revertAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rootTimer != nil {
+		l.rootTimer.Stop()
+		l.rootTimer = nil
+		l.rootExpiry = time.Time{}
+	}
+	l.rootGen++
+	l.root.SetLevel(level)
+	if revertAfter <= 0 {
+		l.rootBase = level
+		return
+	}
+	base, gen := l.rootBase, l.rootGen
+	l.rootExpiry = time.Now().Add(revertAfter)
+	l.rootTimer = time.AfterFunc(revertAfter, func() { l.revertRoot(gen, base) })
+}
+
+// revertRoot restores the root level to base unless the level was changed
+// again after the revert was scheduled at gen
+func (l *Levels) revertRoot(gen uint64, base zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if gen != l.rootGen {
+		return
+	}
+	l.root.SetLevel(base)
+	l.rootTimer = nil
+	l.rootExpiry = time.Time{}
+}
+
+// revertComponent removes the override of c unless it was changed again
+// after the revert was scheduled at gen
+func (l *Levels) revertComponent(c *componentLevel, gen uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	c.override.Store(nil)
+	c.timer = nil
+	c.expiry = time.Time{}
+}
+
+// Reset removes the override of component so it follows the root level again
+func (l *Levels) Reset(component string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.components[component]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownComponent, component)
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+		c.expiry = time.Time{}
+	}
+	c.gen++
+	c.override.Store(nil)
+	return nil
+}
+
+// Status returns the root level and the effective level of every component
+func (l *Levels) Status() (LevelStatus, map[string]LevelStatus) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	root := LevelStatus{Level: l.root.Level().String(), RevertAt: expiry(l.rootExpiry)}
+	components := make(map[string]LevelStatus, len(l.components))
+	for name, c := range l.components {
+		status := LevelStatus{Level: root.Level}
+		if o := c.override.Load(); o != nil {
+			status = LevelStatus{Level: o.String(), Override: true, RevertAt: expiry(c.expiry)}
+		}
+		components[name] = status
+	}
+	return root, components
+}
+
+// Components returns the registered component names in order
+func (l *Levels) Components() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	names := make([]string, 0, len(l.components))
+	for name := range l.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func expiry(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// levelCore filters entries by a level enabler independent of the wrapped
+// core, which is built at the lowest level
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl)
+}
+
+func (c levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
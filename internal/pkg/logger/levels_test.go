@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogger_ComponentLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := New(Config{Level: zapcore.InfoLevel, Encoding: "json", OutputPath: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	auth := l.Component("auth")
+	hls := l.Component("hls")
+
+	if err := l.Levels.Set("auth", zapcore.DebugLevel, 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	auth.Debug("auth debug")
+	hls.Debug("hls debug")
+	l.Debug("root debug")
+
+	if err := l.Levels.Set("", zapcore.ErrorLevel, 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	hls.Warn("hls warn after root raised")
+	auth.Info("auth info with override")
+
+	if err := l.Levels.Set("cdn", zapcore.DebugLevel, 0); !errors.Is(err, ErrUnknownComponent) {
+		t.Errorf("Set() unknown component error = %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for msg, want := range map[string]bool{
+		"auth debug":                 true,
+		"hls debug":                  false,
+		"root debug":                 false,
+		"hls warn after root raised": false,
+		"auth info with override":    true,
+	} {
+		if got := bytes.Contains(data, []byte(msg)); got != want {
+			t.Errorf("%q written = %v, want %v", msg, got, want)
+		}
+	}
+	if !bytes.Contains(data, []byte(`"logger":"auth"`)) {
+		t.Error("component entries are not named")
+	}
+}
+
+func TestLevels_Revert(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	repo := levels.component("repository")
+
+	if err := levels.Set("repository", zapcore.DebugLevel, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := levels.Set("", zapcore.DebugLevel, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	root, components := levels.Status()
+	if root.Level != "debug" || root.RevertAt == nil {
+		t.Errorf("root status = %+v", root)
+	}
+	if s := components["repository"]; !s.Override || s.Level != "debug" || s.RevertAt == nil {
+		t.Errorf("repository status = %+v", s)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for repo.Enabled(zapcore.DebugLevel) || levels.Root().Enabled(zapcore.DebugLevel) {
+		if time.Now().After(deadline) {
+			t.Fatal("levels were not reverted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	root, components = levels.Status()
+	if root.Level != "info" || root.RevertAt != nil || components["repository"].Override {
+		t.Errorf("status after revert = %+v %+v", root, components)
+	}
+}
+
+func TestLevels_StaleRevert(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	repo := levels.component("repository")
+
+	// A revert that fired but waited for mu while a newer Set ran must not
+	// undo that Set
+	if err := levels.Set("", zapcore.DebugLevel, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	rootGen := levels.rootGen
+	if err := levels.Set("", zapcore.WarnLevel, 0); err != nil {
+		t.Fatal(err)
+	}
+	levels.revertRoot(rootGen, zapcore.InfoLevel)
+	if got := levels.Root().Level(); got != zapcore.WarnLevel {
+		t.Errorf("root level after stale revert = %s, want warn", got)
+	}
+
+	if err := levels.Set("repository", zapcore.DebugLevel, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	componentGen := repo.gen
+	if err := levels.Set("repository", zapcore.ErrorLevel, 0); err != nil {
+		t.Fatal(err)
+	}
+	levels.revertComponent(repo, componentGen)
+	if repo.Enabled(zapcore.WarnLevel) {
+		t.Error("repository override removed by stale revert")
+	}
+}
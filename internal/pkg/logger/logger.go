@@ -2,15 +2,24 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Logger wraps zap.Logger to provide application-wide logging
 type Logger struct {
 	*zap.Logger
+	// Levels controls the root and per-component levels at runtime
+	Levels *Levels
+	core   zapcore.Core
+	closer io.Closer
 }
 
 // Config defines logger configuration
@@ -18,6 +27,16 @@ type Config struct {
 	Level      zapcore.Level
 	Encoding   string // json or console
 	OutputPath string // stdout, stderr, or file path
+
+	// File rotation, used when OutputPath is a file
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+	Compress   bool
+
+	// Sampling keeps the first Initial entries per second with the same
+	// level and message, then every Thereafter-th; nil disables sampling
+	Sampling *zap.SamplingConfig
 }
 
 // New creates a new Logger instance with given configuration
@@ -37,32 +56,74 @@ func New(cfg Config) (*Logger, error) {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	var encoder zapcore.Encoder
-	if cfg.Encoding == "json" {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	} else {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
-	}
-
 	var writeSyncer zapcore.WriteSyncer
+	var closer io.Closer
 	switch cfg.OutputPath {
 	case "", "stdout":
 		writeSyncer = zapcore.AddSync(os.Stdout)
 	case "stderr":
 		writeSyncer = zapcore.AddSync(os.Stderr)
 	default:
-		file, err := os.OpenFile(cfg.OutputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
-		if err != nil {
-			return nil, err
+		if err := os.MkdirAll(filepath.Dir(cfg.OutputPath), 0o755); err != nil {
+			return nil, fmt.Errorf("create log directory: %w", err)
+		}
+		file := &lumberjack.Logger{
+			Filename:   cfg.OutputPath,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxAge:     cfg.MaxAgeDays,
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
 		}
 		writeSyncer = zapcore.AddSync(file)
+		closer = file
 	}
 
-	core := zapcore.NewCore(encoder, writeSyncer, cfg.Level)
-	zapLogger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	var encoder zapcore.Encoder
+	switch cfg.Encoding {
+	case "json":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case "", "console":
+		// Colors only help on a terminal; keep files plain
+		if closer == nil {
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("unknown log encoding %q", cfg.Encoding)
+	}
+
+	// Levels are enforced per logger, so the shared core accepts everything
+	core := zapcore.NewCore(encoder, writeSyncer, zapcore.DebugLevel)
+	if cfg.Sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
+	levels := NewLevels(cfg.Level)
+	zapLogger := newZap(levelCore{Core: core, level: levels.Root()})
+
+	return &Logger{Logger: zapLogger, Levels: levels, core: core, closer: closer}, nil
+}
+
+// Component returns a named logger whose level can be overridden
+// independently of the root level through Levels
+func (l *Logger) Component(name string) *zap.Logger {
+	return newZap(levelCore{Core: l.core, level: l.Levels.component(name)}).Named(name)
+}
+
+func newZap(core zapcore.Core) *zap.Logger {
+	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+}
+
+// Close flushes buffered entries and releases the log file, if any
+func (l *Logger) Close() error {
+	// Sync fails on terminals; only file output has anything to flush
+	if l.closer == nil {
+		return nil
+	}
+	_ = l.Sync()
+	return l.closer.Close()
+}
 
-	return &Logger{Logger: zapLogger}, nil
-} // NewDevelopment creates a development logger with console output
+// NewDevelopment creates a development logger with console output
 func NewDevelopment() (*Logger, error) {
 	return New(Config{
 		Level:      zapcore.DebugLevel,
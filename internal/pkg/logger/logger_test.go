@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNew_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "server.log")
+
+	l, err := New(Config{
+		Level:      zapcore.InfoLevel,
+		Encoding:   "json",
+		OutputPath: path,
+		MaxSizeMB:  1,
+		Sampling:   &zap.SamplingConfig{Initial: 2, Thereafter: 100},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	l.Debug("below level")
+	for range 5 {
+		l.Info("repeated")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bytes.Count(data, []byte(`"msg":"repeated"`)); got != 2 {
+		t.Errorf("sampled entries = %d, want 2", got)
+	}
+	if bytes.Contains(data, []byte("below level")) {
+		t.Error("debug entry written at info level")
+	}
+}
+
+func TestNew_UnknownEncoding(t *testing.T) {
+	if _, err := New(Config{Encoding: "xml"}); err == nil {
+		t.Error("New() expected error for unknown encoding")
+	}
+}
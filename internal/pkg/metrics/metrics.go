@@ -35,7 +35,7 @@ var (
 		[]string{"key_name", "status"},
 	)
 
-	// KeyCacheHits tracks cache hit rate
+	// KeyCacheHits counts keys served from the lazy key cache
 	KeyCacheHits = promauto.NewCounter(
 		prometheus.CounterOpts{
 			Name: "hls_key_cache_hits_total",
@@ -43,7 +43,7 @@ var (
 		},
 	)
 
-	// KeyCacheMisses tracks cache misses
+	// KeyCacheMisses counts lazy key lookups that read the backing store
 	KeyCacheMisses = promauto.NewCounter(
 		prometheus.CounterOpts{
 			Name: "hls_key_cache_misses_total",
@@ -51,6 +51,22 @@ var (
 		},
 	)
 
+	// ActiveSessions tracks the number of active playback sessions
+	ActiveSessions = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_active_sessions",
+			Help: "Number of currently active playback sessions",
+		},
+	)
+
+	// SessionsDenied tracks key fetches denied by the concurrent session limit
+	SessionsDenied = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_sessions_denied_total",
+			Help: "Total number of key fetches denied by the concurrent session limit",
+		},
+	)
+
 	// ActiveKeys tracks the number of active keys
 	ActiveKeys = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -59,6 +75,14 @@ var (
 		},
 	)
 
+	// KeyFilesUnreadable tracks key files the last reload could not read
+	KeyFilesUnreadable = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_key_files_unreadable",
+			Help: "Number of key files the last reload could not read",
+		},
+	)
+
 	// AuthAttempts tracks authentication attempts
 	AuthAttempts = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -68,6 +92,15 @@ var (
 		[]string{"result"},
 	)
 
+	// AuthLockouts tracks lockouts triggered by repeated authentication failures
+	AuthLockouts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_auth_lockouts_total",
+			Help: "Total number of lockouts triggered by repeated authentication failures",
+		},
+		[]string{"scope"},
+	)
+
 	// TokenGenerations tracks JWT token generations
 	TokenGenerations = promauto.NewCounter(
 		prometheus.CounterOpts{
@@ -94,6 +127,49 @@ var (
 		[]string{"type"},
 	)
 
+	// RateLimited tracks requests rejected by rate limiting
+	RateLimited = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_rate_limited_total",
+			Help: "Total number of requests rejected by rate limiting",
+		},
+		[]string{"policy"},
+	)
+
+	// IPPolicyDenied tracks key requests denied by IP policy rules
+	IPPolicyDenied = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_ip_policy_denied_total",
+			Help: "Total number of key requests denied by IP policy",
+		},
+		[]string{"rule"},
+	)
+
+	// HotlinkDenied tracks key requests rejected by Origin/Referer validation
+	HotlinkDenied = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_hotlink_denied_total",
+			Help: "Total number of key requests rejected by Origin/Referer validation",
+		},
+	)
+
+	// AuditEvents tracks recorded audit events by type and outcome
+	AuditEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_audit_events_total",
+			Help: "Total number of audit events recorded",
+		},
+		[]string{"type", "outcome"},
+	)
+
+	// AuditWriteErrors tracks audit events a sink failed to persist
+	AuditWriteErrors = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_audit_write_errors_total",
+			Help: "Total number of audit events that failed to be written to a sink",
+		},
+	)
+
 	// KeyReloadDuration tracks key reload duration
 	KeyReloadDuration = promauto.NewHistogram(
 		prometheus.HistogramOpts{
@@ -103,6 +179,45 @@ var (
 		},
 	)
 
+	// KeySyncs tracks replica pulls from the upstream key server by result
+	// (full/delta/unchanged/failed)
+	KeySyncs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_key_syncs_total",
+			Help: "Total number of key pulls from the upstream server by result",
+		},
+		[]string{"result"},
+	)
+
+	// KeySyncLastSuccess is the time of the last successful pull, for
+	// alerting on replicas serving stale keys
+	KeySyncLastSuccess = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_key_sync_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful key pull from the upstream server",
+		},
+	)
+
+	// KEKUnwraps tracks content keys unwrapped with the key-encryption key
+	// by result (success/failure)
+	KEKUnwraps = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_kek_unwraps_total",
+			Help: "Total number of content keys unwrapped with the key-encryption key by result",
+		},
+		[]string{"result"},
+	)
+
+	// ConfigReloads tracks configuration reloads by result
+	// (applied/unchanged/rejected/failed)
+	ConfigReloads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_config_reloads_total",
+			Help: "Total number of configuration reloads by result",
+		},
+		[]string{"result"},
+	)
+
 	// ConcurrentConnections tracks current concurrent connections
 	ConcurrentConnections = promauto.NewGauge(
 		prometheus.GaugeOpts{
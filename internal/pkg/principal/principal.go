@@ -0,0 +1,47 @@
+// Package principal carries the authenticated caller identity through request contexts
+package principal
+
+import (
+	"context"
+	"path"
+)
+
+// Principal identifies the authenticated caller of a request
+type Principal struct {
+	// Subject is the JWT "sub" claim
+	Subject string
+	// SessionID is the JWT "sid" claim identifying one playback session
+	SessionID string
+	// Tenant is the JWT "tenant" claim, empty for single-tenant deployments
+	Tenant string
+	// Claims holds the remaining token claims
+	Claims map[string]any
+	// Keys restricts fetchable key names to these glob patterns; nil allows all
+	Keys []string
+}
+
+// AllowsKey reports whether the principal may fetch keyName
+func (p Principal) AllowsKey(keyName string) bool {
+	if p.Keys == nil {
+		return true
+	}
+	for _, pattern := range p.Keys {
+		if ok, _ := path.Match(pattern, keyName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, if any
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	policy := Policy{Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, err := store.Take(ctx, "client", policy)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if !res.Allowed {
+			t.Fatalf("Take() #%d denied, want allowed within burst", i+1)
+		}
+		if res.Remaining != 2-i {
+			t.Errorf("Take() #%d remaining = %d, want %d", i+1, res.Remaining, 2-i)
+		}
+	}
+
+	res, err := store.Take(ctx, "client", policy)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if res.Allowed {
+		t.Fatal("Take() allowed after burst exhausted")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("Take() RetryAfter = %v, want 1s", res.RetryAfter)
+	}
+
+	// Other keys have independent buckets
+	res, _ = store.Take(ctx, "other", policy)
+	if !res.Allowed {
+		t.Error("Take() denied independent key")
+	}
+
+	// Refill after waiting
+	now = now.Add(1500 * time.Millisecond)
+	res, _ = store.Take(ctx, "client", policy)
+	if !res.Allowed {
+		t.Error("Take() denied after refill")
+	}
+}
+
+func TestMemoryStore_Unlimited(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	for i := 0; i < 100; i++ {
+		res, err := store.Take(context.Background(), "client", Policy{})
+		if err != nil || !res.Allowed {
+			t.Fatalf("Take() = %+v, %v; want allowed", res, err)
+		}
+	}
+	if store.Len() != 0 {
+		t.Errorf("Len() = %d, want 0 for unlimited policy", store.Len())
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	store.lastSweep = now
+
+	policy := Policy{Rate: 10, Burst: 10}
+	_, _ = store.Take(context.Background(), "idle", policy)
+
+	now = now.Add(2 * sweepInterval)
+	_, _ = store.Take(context.Background(), "active", policy)
+
+	if store.Len() != 1 {
+		t.Errorf("Len() = %d, want 1 after sweeping idle bucket", store.Len())
+	}
+}
+
+func BenchmarkMemoryStore_Take(b *testing.B) {
+	store := NewMemoryStore()
+	policy := Policy{Rate: 1e9, Burst: 1 << 30}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = store.Take(ctx, "client", policy)
+		}
+	})
+}
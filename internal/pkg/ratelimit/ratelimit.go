@@ -0,0 +1,53 @@
+// Package ratelimit provides token bucket rate limiting with pluggable storage
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy defines a token bucket refilled at Rate tokens per second up to Burst tokens
+type Policy struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether the policy disables limiting
+func (p Policy) Unlimited() bool {
+	return p.Rate <= 0 || p.Burst <= 0
+}
+
+// Result describes the outcome of a single Take call
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Store consumes tokens from buckets identified by key
+type Store interface {
+	// Take consumes one token from the bucket for key under the given policy
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// refill computes the bucket state after consuming one token at now.
+// It returns the remaining tokens and the decision for this request.
+func refill(tokens float64, last, now time.Time, policy Policy) (float64, Result) {
+	elapsed := now.Sub(last).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	tokens = math.Min(float64(policy.Burst), tokens+elapsed*policy.Rate)
+
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+
+	wait := (1 - tokens) / policy.Rate
+	return tokens, Result{
+		Allowed:    false,
+		RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second))),
+	}
+}
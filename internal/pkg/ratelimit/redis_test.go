@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewRedisStore(client, "test:"), mr
+}
+
+func TestRedisStore_Take(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	policy := Policy{Rate: 2, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, err := store.Take(ctx, "client", policy)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if !res.Allowed {
+			t.Fatalf("Take() #%d denied, want allowed within burst", i+1)
+		}
+	}
+
+	res, err := store.Take(ctx, "client", policy)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if res.Allowed {
+		t.Fatal("Take() allowed after burst exhausted")
+	}
+	if res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("Take() RetryAfter = %v, want 500ms", res.RetryAfter)
+	}
+
+	if !mr.Exists("test:client") {
+		t.Error("expected bucket key with prefix to exist")
+	}
+	if ttl := mr.TTL("test:client"); ttl <= 0 {
+		t.Errorf("bucket TTL = %v, want positive", ttl)
+	}
+
+	now = now.Add(time.Second)
+	res, err = store.Take(ctx, "client", policy)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if !res.Allowed {
+		t.Error("Take() denied after refill")
+	}
+}
+
+func TestRedisStore_SharedAcrossClients(t *testing.T) {
+	first, mr := newTestRedisStore(t)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	second := NewRedisStore(client, "test:")
+
+	policy := Policy{Rate: 0.1, Burst: 1}
+	ctx := context.Background()
+
+	if res, err := first.Take(ctx, "client", policy); err != nil || !res.Allowed {
+		t.Fatalf("first Take() = %+v, %v; want allowed", res, err)
+	}
+	if res, err := second.Take(ctx, "client", policy); err != nil || res.Allowed {
+		t.Fatalf("second Take() = %+v, %v; want denied by shared bucket", res, err)
+	}
+}
+
+func TestRedisStore_Unavailable(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	mr.Close()
+
+	if _, err := store.Take(context.Background(), "client", Policy{Rate: 1, Burst: 1}); err == nil {
+		t.Error("Take() error = nil, want error when server is unavailable")
+	}
+}
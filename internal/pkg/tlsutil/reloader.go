@@ -0,0 +1,100 @@
+// Package tlsutil builds server TLS configuration with certificate hot reload
+package tlsutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CertReloader serves a certificate/key pair and swaps it when the files change
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	logger   *zap.Logger
+
+	cert        atomic.Pointer[tls.Certificate]
+	fingerprint [sha256.Size]byte
+}
+
+// NewCertReloader loads the certificate pair and returns a reloader serving it
+func NewCertReloader(certFile, keyFile string, logger *zap.Logger) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate; use it as tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Reload re-reads the certificate files and swaps the served pair if their
+// contents changed. It reports whether a new certificate was installed.
+// On error the previous certificate keeps being served.
+func (r *CertReloader) Reload() (bool, error) {
+	certPEM, err := os.ReadFile(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("read private key: %w", err)
+	}
+
+	fingerprint := sha256.Sum256(bytes.Join([][]byte{certPEM, keyPEM}, []byte{0}))
+	if r.cert.Load() != nil && fingerprint == r.fingerprint {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("parse key pair: %w", err)
+	}
+
+	r.fingerprint = fingerprint
+	r.cert.Store(&cert)
+	return true, nil
+}
+
+// Run checks the certificate files every interval until ctx is done
+func (r *CertReloader) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.Reload()
+			if err != nil {
+				r.logger.Error("failed to reload tls certificate, keeping previous one",
+					zap.String("cert_file", r.certFile),
+					zap.Error(err),
+				)
+				continue
+			}
+			if changed {
+				r.logger.Info("tls certificate reloaded", zap.String("cert_file", r.certFile))
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/configs"
+)
+
+// writeSelfSigned writes a self-signed certificate for cn into dir
+func writeSelfSigned(t *testing.T, dir, cn string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func leafCN(t *testing.T, r *CertReloader) string {
+	t.Helper()
+	cert, _ := r.GetCertificate(nil)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, "one.example.com")
+
+	r, err := NewCertReloader(certFile, keyFile, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+	if got := leafCN(t, r); got != "one.example.com" {
+		t.Fatalf("CN = %q, want one.example.com", got)
+	}
+
+	changed, err := r.Reload()
+	if err != nil || changed {
+		t.Fatalf("Reload() unchanged = %v, %v; want false, nil", changed, err)
+	}
+
+	writeSelfSigned(t, dir, "two.example.com")
+	changed, err = r.Reload()
+	if err != nil || !changed {
+		t.Fatalf("Reload() after rotation = %v, %v; want true, nil", changed, err)
+	}
+	if got := leafCN(t, r); got != "two.example.com" {
+		t.Errorf("CN after reload = %q, want two.example.com", got)
+	}
+
+	// A broken pair keeps the previous certificate
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reload(); err == nil {
+		t.Error("Reload() with broken key expected error")
+	}
+	if got := leafCN(t, r); got != "two.example.com" {
+		t.Errorf("CN after failed reload = %q, want two.example.com", got)
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, "server.example.com")
+	r, err := NewCertReloader(certFile, keyFile, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ServerConfig(configs.TLS{
+		MinVersion:   "1.3",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		ClientAuth:   "require",
+		ClientCAFile: certFile,
+	}, r)
+	if err != nil {
+		t.Fatalf("ServerConfig() error = %v", err)
+	}
+	if cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion = %x, want TLS 1.3", cfg.MinVersion)
+	}
+	if len(cfg.CipherSuites) != 1 || cfg.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("CipherSuites = %v", cfg.CipherSuites)
+	}
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("ClientAuth = %v, want RequireAndVerifyClientCert", cfg.ClientAuth)
+	}
+	if cfg.ClientCAs == nil {
+		t.Error("ClientCAs not loaded")
+	}
+
+	invalid := []configs.TLS{
+		{MinVersion: "1.0"},
+		{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+		{ClientAuth: "always"},
+		{ClientAuth: "require"},
+	}
+	for _, c := range invalid {
+		if _, err := ServerConfig(c, r); err == nil {
+			t.Errorf("ServerConfig(%+v) expected error", c)
+		}
+	}
+}
+
+func TestPeerConfig(t *testing.T) {
+	certFile, keyFile := writeSelfSigned(t, t.TempDir(), "localhost")
+	reloader, err := NewCertReloader(certFile, keyFile, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := PeerConfig(configs.ClusterTLS{CertFile: certFile, KeyFile: keyFile, CAFile: certFile}, reloader)
+	if err != nil {
+		t.Fatalf("PeerConfig() error = %v", err)
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	handshakes := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			handshakes <- conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	// A peer presenting a certificate from the cluster CA is accepted
+	conn, err := tls.Dial("tcp", "localhost:"+port, cfg)
+	if err != nil {
+		t.Fatalf("peer dial error = %v", err)
+	}
+	conn.Close()
+	if err := <-handshakes; err != nil {
+		t.Errorf("server handshake with peer error = %v", err)
+	}
+
+	// A client without a certificate is rejected
+	anonymous := &tls.Config{RootCAs: cfg.RootCAs, MinVersion: tls.VersionTLS13}
+	if conn, err := tls.Dial("tcp", "localhost:"+port, anonymous); err == nil {
+		// TLS 1.3 clients learn of the rejection on their first read
+		_, _ = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	if err := <-handshakes; err == nil {
+		t.Error("server accepted a client without a certificate")
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	cfg, err := ClientConfig("")
+	if err != nil || cfg.RootCAs != nil {
+		t.Fatalf("ClientConfig(\"\") = %v, %v; want system roots", cfg.RootCAs, err)
+	}
+
+	certFile, _ := writeSelfSigned(t, t.TempDir(), "vault.internal")
+	cfg, err = ClientConfig(certFile)
+	if err != nil {
+		t.Fatalf("ClientConfig() error = %v", err)
+	}
+	if cfg.RootCAs == nil {
+		t.Error("ClientConfig() did not load the CA file")
+	}
+
+	if _, err := ClientConfig(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("ClientConfig() expected error for a missing CA file")
+	}
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"hls-key-server-go/internal/configs"
+)
+
+func TestSetup_FileExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	shutdown, err := Setup(context.Background(), configs.Tracing{
+		Enable:      true,
+		Exporter:    "file",
+		File:        file,
+		SampleRatio: 1,
+		ServiceName: "hls-key-server-test",
+	}, "test")
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	t.Cleanup(func() { otel.SetTracerProvider(sdktrace.NewTracerProvider()) })
+
+	_, span := Tracer().Start(context.Background(), "HLSService.GetKey")
+	End(span, nil)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error = %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "HLSService.GetKey") || !strings.Contains(string(data), "hls-key-server-test") {
+		t.Errorf("trace file missing span or service name:\n%s", data)
+	}
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), configs.Tracing{Enable: true, Exporter: "zipkin"}, "test"); err == nil {
+		t.Error("Setup() expected error for unknown exporter")
+	}
+}
+
+func TestTraceparentPropagation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	if _, err := Setup(context.Background(), configs.Tracing{}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(sdktrace.NewTracerProvider()) })
+
+	router := gin.New()
+	router.Use(otelgin.Middleware("hls-key-server"))
+	router.GET("/key", func(c *gin.Context) {
+		_, span := Tracer().Start(c.Request.Context(), "HLSService.GetKey")
+		End(span, nil)
+		c.Status(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/key", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	for _, s := range spans {
+		if got := s.SpanContext().TraceID().String(); got != traceID {
+			t.Errorf("span %s trace id = %s, want %s", s.Name(), got, traceID)
+		}
+	}
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("service span is not a child of the request span")
+	}
+}
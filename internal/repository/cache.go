@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// keyCache is a size-bounded LRU cache of keys whose entries expire ttl
+// after they were added. A zero ttl keeps entries until they are evicted.
+type keyCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu    sync.Mutex
+	order *list.List // front is most recently used
+	items map[string]*list.Element
+	// gen changes on every write and removal, so a load that raced with
+	// one is not cached
+	gen uint64
+}
+
+type keyCacheEntry struct {
+	name    string
+	data    []byte
+	expires time.Time
+}
+
+func newKeyCache(size int, ttl time.Duration) *keyCache {
+	return &keyCache{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// get returns a cached key that has not expired
+func (c *keyCache) get(name string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[name]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*keyCacheEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.data, true
+}
+
+// generation returns the current generation for a later addIfCurrent
+func (c *keyCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// add caches a written key, evicting the least recently used key when full
+func (c *keyCache) add(name string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addLocked(name, data)
+	c.gen++
+}
+
+// addIfCurrent caches a loaded key unless the cache changed since gen was
+// taken, so a load that raced with a write, delete or reload cannot
+// resurrect old data. It reports whether the key was cached.
+func (c *keyCache) addIfCurrent(gen uint64, name string, data []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return false
+	}
+	c.addLocked(name, data)
+	return true
+}
+
+func (c *keyCache) addLocked(name string, data []byte) {
+	entry := &keyCacheEntry{name: name, data: data, expires: c.now().Add(c.ttl)}
+	if elem, ok := c.items[name]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[name] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// remove drops a key
+func (c *keyCache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[name]; ok {
+		c.removeElement(elem)
+	}
+	c.gen++
+}
+
+// purge drops every key
+func (c *keyCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.items)
+	c.gen++
+}
+
+// len returns the number of cached keys, including expired ones not yet
+// dropped
+func (c *keyCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *keyCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*keyCacheEntry).name)
+}
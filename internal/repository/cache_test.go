@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newKeyCache(2, 0)
+	c.add("a.key", []byte("a"))
+	c.add("b.key", []byte("b"))
+	if _, ok := c.get("a.key"); !ok {
+		t.Fatal("get(a.key) missed")
+	}
+	c.add("c.key", []byte("c"))
+
+	if _, ok := c.get("b.key"); ok {
+		t.Error("get(b.key) hit, want least recently used key evicted")
+	}
+	for _, name := range []string{"a.key", "c.key"} {
+		if _, ok := c.get(name); !ok {
+			t.Errorf("get(%s) missed", name)
+		}
+	}
+	if c.len() != 2 {
+		t.Errorf("len() = %d, want 2", c.len())
+	}
+}
+
+func TestKeyCache_TTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newKeyCache(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.add("a.key", []byte("a"))
+	now = now.Add(59 * time.Second)
+	if _, ok := c.get("a.key"); !ok {
+		t.Error("get() missed before ttl")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.get("a.key"); ok {
+		t.Error("get() hit at ttl, want expired")
+	}
+	if c.len() != 0 {
+		t.Errorf("len() = %d, want expired key dropped", c.len())
+	}
+}
+
+func TestKeyCache_AddIfCurrent(t *testing.T) {
+	c := newKeyCache(10, 0)
+
+	gen := c.generation()
+	c.remove("a.key")
+	if c.addIfCurrent(gen, "a.key", []byte("stale")) {
+		t.Error("addIfCurrent() cached a load that raced with remove")
+	}
+
+	gen = c.generation()
+	c.purge()
+	if c.addIfCurrent(gen, "a.key", []byte("stale")) {
+		t.Error("addIfCurrent() cached a load that raced with purge")
+	}
+
+	gen = c.generation()
+	if !c.addIfCurrent(gen, "a.key", []byte("a")) {
+		t.Error("addIfCurrent() = false without a racing change")
+	}
+	if got, ok := c.get("a.key"); !ok || string(got) != "a" {
+		t.Errorf("get() = %q, %v", got, ok)
+	}
+}
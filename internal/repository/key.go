@@ -3,13 +3,25 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 
 	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/tracing"
 )
 
 // KeyRepository defines the interface for key storage operations
@@ -22,11 +34,56 @@ type KeyRepository interface {
 	Reload(ctx context.Context) error
 }
 
-// FileKeyRepository implements KeyRepository using filesystem storage
+// KeyWriter is implemented by repositories that accept key changes
+type KeyWriter interface {
+	// Put creates or replaces a key
+	Put(ctx context.Context, name string, data []byte) error
+	// Delete removes a key; deleting a missing key is not an error
+	Delete(ctx context.Context, name string) error
+}
+
+// HealthChecker is implemented by repositories that can verify their
+// backing store is reachable
+type HealthChecker interface {
+	// HealthCheck returns an error when keys cannot currently be loaded
+	HealthCheck(ctx context.Context) error
+}
+
+// FileKeyRepository implements KeyRepository using filesystem storage.
+//
+// By default Reload reads every key into memory. With WithLazyLoading,
+// Reload only lists the directory and keys are read on first request
+// through an LRU cache; concurrent misses for one key share a single read.
 type FileKeyRepository struct {
 	keyDir string
 	cache  map[string][]byte
 	mu     sync.RWMutex
+	logger *zap.Logger
+
+	// Lazy mode: names lists the keys, lazy caches their contents
+	lazy  *keyCache
+	names map[string]struct{}
+	loads singleflight.Group
+}
+
+// FileKeyOption configures optional FileKeyRepository behavior
+type FileKeyOption func(*FileKeyRepository)
+
+// WithKeyLogger sets the logger for key loading and lookups
+func WithKeyLogger(logger *zap.Logger) FileKeyOption {
+	return func(r *FileKeyRepository) {
+		r.logger = logger
+	}
+}
+
+// WithLazyLoading reads keys on first request instead of on Reload,
+// caching up to size keys for ttl each (zero ttl: until evicted or
+// reloaded)
+func WithLazyLoading(size int, ttl time.Duration) FileKeyOption {
+	return func(r *FileKeyRepository) {
+		r.lazy = newKeyCache(size, ttl)
+		r.names = make(map[string]struct{})
+	}
 }
 
 // validateKeyName performs security validation on key filenames
@@ -78,7 +135,7 @@ func validateKeyName(name string) error {
 
 // NewFileKeyRepository creates a new file-based key repository
 // keyDir should be an absolute or relative path to the directory containing .key files
-func NewFileKeyRepository(keyDir string) (*FileKeyRepository, error) {
+func NewFileKeyRepository(keyDir string, opts ...FileKeyOption) (*FileKeyRepository, error) {
 	if keyDir == "" {
 		return nil, fmt.Errorf("keyDir cannot be empty")
 	}
@@ -86,6 +143,10 @@ func NewFileKeyRepository(keyDir string) (*FileKeyRepository, error) {
 	repo := &FileKeyRepository{
 		keyDir: keyDir,
 		cache:  make(map[string][]byte),
+		logger: zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(repo)
 	}
 
 	// Create directory if not exists
@@ -93,8 +154,9 @@ func NewFileKeyRepository(keyDir string) (*FileKeyRepository, error) {
 		return nil, fmt.Errorf("create key directory: %w", err)
 	}
 
-	// Load keys on initialization
-	if err := repo.Reload(context.Background()); err != nil {
+	// Load keys on initialization; unreadable key files are logged and
+	// left out rather than failing startup
+	if _, err := repo.load(context.Background()); err != nil {
 		return nil, fmt.Errorf("initial key load: %w", err)
 	}
 
@@ -102,17 +164,32 @@ func NewFileKeyRepository(keyDir string) (*FileKeyRepository, error) {
 }
 
 // Get retrieves a key by name from cache
-func (r *FileKeyRepository) Get(_ context.Context, name string) ([]byte, error) {
+func (r *FileKeyRepository) Get(ctx context.Context, name string) (_ []byte, err error) {
+	_, span := tracing.Tracer().Start(ctx, "KeyRepository.Get",
+		trace.WithAttributes(attribute.String("hls.key", name)),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	// Validate key name to prevent directory traversal
 	if err := validateKeyName(name); err != nil {
 		return nil, err
 	}
 
+	if r.lazy != nil {
+		key, hit, err := r.getLazy(name)
+		span.SetAttributes(attribute.Bool("hls.key_cache_hit", hit))
+		if errors.Is(err, apperrors.ErrKeyNotFound) {
+			logger.FromContext(ctx, r.logger).Debug("key file not found", zap.String("key_name", name))
+		}
+		return key, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	key, exists := r.cache[name]
 	if !exists {
+		logger.FromContext(ctx, r.logger).Debug("key not in cache", zap.String("key_name", name))
 		return nil, apperrors.ErrKeyNotFound
 	}
 
@@ -120,11 +197,51 @@ func (r *FileKeyRepository) Get(_ context.Context, name string) ([]byte, error)
 	return append([]byte(nil), key...), nil
 }
 
-// List returns all available key names
+// getLazy returns a key from the LRU cache, reading it from disk on a miss
+func (r *FileKeyRepository) getLazy(name string) (_ []byte, hit bool, err error) {
+	if key, ok := r.lazy.get(name); ok {
+		metrics.KeyCacheHits.Inc()
+		return append([]byte(nil), key...), true, nil
+	}
+	metrics.KeyCacheMisses.Inc()
+
+	v, err, _ := r.loads.Do(name, func() (any, error) {
+		gen := r.lazy.generation()
+		data, err := os.ReadFile(filepath.Join(r.keyDir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, apperrors.ErrKeyNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read key file %s: %w", name, err)
+		}
+		// Keys added since the last reload are listed once read
+		r.mu.Lock()
+		if r.lazy.addIfCurrent(gen, name, data) {
+			r.names[name] = struct{}{}
+		}
+		r.mu.Unlock()
+		return data, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return append([]byte(nil), v.([]byte)...), false, nil
+}
+
+// List returns all available key names. In lazy mode these are the files
+// found by the last Reload plus keys read or written since.
 func (r *FileKeyRepository) List(_ context.Context) []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	if r.lazy != nil {
+		names := make([]string, 0, len(r.names))
+		for name := range r.names {
+			names = append(names, name)
+		}
+		return names
+	}
+
 	names := make([]string, 0, len(r.cache))
 	for name := range r.cache {
 		names = append(names, name)
@@ -132,14 +249,170 @@ func (r *FileKeyRepository) List(_ context.Context) []string {
 	return names
 }
 
-// Reload reloads all keys from the filesystem
-func (r *FileKeyRepository) Reload(_ context.Context) error {
-	files, err := os.ReadDir(r.keyDir)
+// Put writes a key file atomically and updates the cache
+func (r *FileKeyRepository) Put(ctx context.Context, name string, data []byte) error {
+	if err := validateKeyName(name); err != nil {
+		return err
+	}
+
+	// Write beside the target and rename so readers never see a partial key
+	tmp, err := os.CreateTemp(r.keyDir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create key file %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write key file %s: %w", name, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync key file %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close key file %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(r.keyDir, name)); err != nil {
+		return fmt.Errorf("rename key file %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	if r.lazy != nil {
+		r.names[name] = struct{}{}
+		r.lazy.add(name, append([]byte(nil), data...))
+	} else {
+		r.cache[name] = append([]byte(nil), data...)
+	}
+	r.mu.Unlock()
+
+	logger.FromContext(ctx, r.logger).Debug("key written", zap.String("key_name", name), zap.Int("size", len(data)))
+	return nil
+}
+
+// Delete removes a key file and drops it from the cache
+func (r *FileKeyRepository) Delete(ctx context.Context, name string) error {
+	if err := validateKeyName(name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(r.keyDir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove key file %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	if r.lazy != nil {
+		delete(r.names, name)
+		r.lazy.remove(name)
+	} else {
+		delete(r.cache, name)
+	}
+	r.mu.Unlock()
+
+	logger.FromContext(ctx, r.logger).Debug("key deleted", zap.String("key_name", name))
+	return nil
+}
+
+// HealthCheck verifies the key directory can still be read
+func (r *FileKeyRepository) HealthCheck(_ context.Context) error {
+	dir, err := os.Open(r.keyDir)
 	if err != nil {
+		return fmt.Errorf("open key directory: %w", err)
+	}
+	defer dir.Close()
+
+	if _, err := dir.ReadDir(1); err != nil && err != io.EOF {
 		return fmt.Errorf("read key directory: %w", err)
 	}
+	return nil
+}
+
+// UnreadableKeysError is returned by Reload when some key files could not
+// be read. The other keys were reloaded and unreadable files keep their
+// previously loaded key, so the store is still usable.
+type UnreadableKeysError struct {
+	Errs []error
+}
+
+func (e *UnreadableKeysError) Error() string {
+	return errors.Join(e.Errs...).Error()
+}
+
+func (e *UnreadableKeysError) Unwrap() []error {
+	return e.Errs
+}
+
+// Reload reloads all keys from the filesystem. In lazy mode it only lists
+// the directory and drops cached keys, so they are read again on the next
+// request.
+//
+// Files that cannot be read keep their previously loaded key and are
+// reported as an *UnreadableKeysError; the other keys are still reloaded.
+func (r *FileKeyRepository) Reload(ctx context.Context) error {
+	unreadable, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+	if unreadable != nil {
+		return unreadable
+	}
+	return nil
+}
+
+// load reads the key directory. err reports a directory that cannot be
+// read, in which case nothing changes; unreadable lists the key files that
+// could not be read, which are also logged and counted in
+// hls_key_files_unreadable.
+func (r *FileKeyRepository) load(ctx context.Context) (unreadable *UnreadableKeysError, err error) {
+	_, span := tracing.Tracer().Start(ctx, "KeyRepository.Reload",
+		trace.WithAttributes(
+			attribute.String("hls.key_dir", r.keyDir),
+			attribute.Bool("hls.key_lazy", r.lazy != nil),
+		),
+	)
+	defer func() {
+		if unreadable != nil {
+			tracing.End(span, unreadable)
+			return
+		}
+		tracing.End(span, err)
+	}()
+
+	log := logger.FromContext(ctx, r.logger)
+
+	files, err := os.ReadDir(r.keyDir)
+	if err != nil {
+		return nil, fmt.Errorf("read key directory: %w", err)
+	}
+
+	if r.lazy != nil {
+		names := make(map[string]struct{})
+		for _, file := range files {
+			if !file.IsDir() && validateKeyName(file.Name()) == nil {
+				names[file.Name()] = struct{}{}
+			}
+		}
+
+		r.mu.Lock()
+		r.names = names
+		r.lazy.purge()
+		r.mu.Unlock()
+		metrics.KeyFilesUnreadable.Set(0)
+
+		span.SetAttributes(attribute.Int("hls.key_count", len(names)))
+		log.Debug("key directory listed",
+			zap.String("key_dir", r.keyDir),
+			zap.Int("count", len(names)),
+		)
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	oldCache := r.cache
+	r.mu.RUnlock()
 
 	newCache := make(map[string][]byte)
+	var readErrs []error
 
 	for _, file := range files {
 		if file.IsDir() {
@@ -151,13 +424,18 @@ func (r *FileKeyRepository) Reload(_ context.Context) error {
 		// Apply same validation to loaded files
 		if err := validateKeyName(fileName); err != nil {
 			// Skip invalid files but continue loading other keys
+			log.Debug("skipping file with invalid key name", zap.String("file", fileName))
 			continue
 		}
 
 		keyPath := filepath.Join(r.keyDir, fileName)
 		keyData, err := os.ReadFile(keyPath)
 		if err != nil {
-			return fmt.Errorf("read key file %s: %w", fileName, err)
+			readErrs = append(readErrs, fmt.Errorf("read key file %s: %w", fileName, err))
+			if old, ok := oldCache[fileName]; ok {
+				newCache[fileName] = old
+			}
+			continue
 		}
 
 		newCache[fileName] = keyData
@@ -167,5 +445,21 @@ func (r *FileKeyRepository) Reload(_ context.Context) error {
 	r.cache = newCache
 	r.mu.Unlock()
 
-	return nil
+	span.SetAttributes(attribute.Int("hls.key_count", len(newCache)))
+	log.Debug("key directory loaded",
+		zap.String("key_dir", r.keyDir),
+		zap.Int("count", len(newCache)),
+	)
+
+	metrics.KeyFilesUnreadable.Set(float64(len(readErrs)))
+	if len(readErrs) == 0 {
+		return nil, nil
+	}
+	unreadable = &UnreadableKeysError{Errs: readErrs}
+	log.Warn("some key files could not be read",
+		zap.String("key_dir", r.keyDir),
+		zap.Int("unreadable", len(readErrs)),
+		zap.Error(unreadable),
+	)
+	return unreadable, nil
 }
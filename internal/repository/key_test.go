@@ -5,9 +5,15 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/pkg/metrics"
 )
 
 func TestNewFileKeyRepository(t *testing.T) {
@@ -215,3 +221,191 @@ func BenchmarkFileKeyRepository_Get(b *testing.B) {
 		_, _ = repo.Get(ctx, "bench.key")
 	}
 }
+
+func TestFileKeyRepository_PutDelete(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := NewFileKeyRepository(tempDir)
+	if err != nil {
+		t.Fatalf("NewFileKeyRepository() error = %v", err)
+	}
+	ctx := context.Background()
+	content := []byte("0123456789abcdef")
+
+	if err := repo.Put(ctx, "new.key", content); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got, err := repo.Get(ctx, "new.key"); err != nil || string(got) != string(content) {
+		t.Errorf("Get() after Put = %q, %v", got, err)
+	}
+	if onDisk, err := os.ReadFile(filepath.Join(tempDir, "new.key")); err != nil || string(onDisk) != string(content) {
+		t.Errorf("key file = %q, %v", onDisk, err)
+	}
+	if err := repo.Put(ctx, "../escape.key", content); !errors.Is(err, apperrors.ErrInvalidKeyName) {
+		t.Errorf("Put(traversal) error = %v, want ErrInvalidKeyName", err)
+	}
+
+	if err := repo.Delete(ctx, "new.key"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.Get(ctx, "new.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get() after Delete error = %v, want ErrKeyNotFound", err)
+	}
+	if err := repo.Delete(ctx, "new.key"); err != nil {
+		t.Errorf("Delete() of missing key error = %v, want nil", err)
+	}
+}
+
+func TestFileKeyRepository_ReloadSkipsUnreadable(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "good.key"), []byte("good"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "flaky.key"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A link to a directory is listed as a file but cannot be read, even
+	// as root
+	if err := os.Symlink(t.TempDir(), filepath.Join(tempDir, "broken.key")); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewFileKeyRepository(tempDir)
+	if err != nil {
+		t.Fatalf("NewFileKeyRepository() error = %v, want unreadable files skipped", err)
+	}
+	ctx := context.Background()
+	if got := len(repo.List(ctx)); got != 2 {
+		t.Errorf("List() = %d keys, want 2", got)
+	}
+
+	// flaky.key becomes unreadable; its loaded key is kept
+	if err := os.Remove(filepath.Join(tempDir, "flaky.key")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(tempDir, "flaky.key")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "new.key"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.Reload(ctx)
+	var unreadable *UnreadableKeysError
+	if !errors.As(err, &unreadable) || len(unreadable.Errs) != 2 || !strings.Contains(err.Error(), "flaky.key") || !strings.Contains(err.Error(), "broken.key") {
+		t.Errorf("Reload() error = %v, want both unreadable files reported", err)
+	}
+	for name, want := range map[string]string{"good.key": "good", "flaky.key": "old", "new.key": "new"} {
+		if got, err := repo.Get(ctx, name); err != nil || string(got) != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestFileKeyRepository_Lazy(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "a.key"), []byte("a-v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(tempDir, "broken.key")); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewFileKeyRepository(tempDir, WithLazyLoading(10, 0))
+	if err != nil {
+		t.Fatalf("NewFileKeyRepository() error = %v", err)
+	}
+	ctx := context.Background()
+	if got := len(repo.List(ctx)); got != 2 {
+		t.Errorf("List() = %d keys, want 2", got)
+	}
+	if repo.lazy.len() != 0 {
+		t.Errorf("cached %d keys before any Get, want 0", repo.lazy.len())
+	}
+
+	hits, misses := testutil.ToFloat64(metrics.KeyCacheHits), testutil.ToFloat64(metrics.KeyCacheMisses)
+	for i := 0; i < 3; i++ {
+		if got, err := repo.Get(ctx, "a.key"); err != nil || string(got) != "a-v1" {
+			t.Fatalf("Get(a.key) = %q, %v", got, err)
+		}
+	}
+	if d := testutil.ToFloat64(metrics.KeyCacheHits) - hits; d != 2 {
+		t.Errorf("cache hits += %v, want 2", d)
+	}
+	if d := testutil.ToFloat64(metrics.KeyCacheMisses) - misses; d != 1 {
+		t.Errorf("cache misses += %v, want 1", d)
+	}
+
+	// Unreadable and missing files only fail their own key
+	if _, err := repo.Get(ctx, "broken.key"); err == nil || errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get(broken.key) error = %v, want a read error", err)
+	}
+	if _, err := repo.Get(ctx, "missing.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get(missing.key) error = %v, want ErrKeyNotFound", err)
+	}
+
+	// Files added without a reload are served and then listed
+	if err := os.WriteFile(filepath.Join(tempDir, "b.key"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.Get(ctx, "b.key"); err != nil || string(got) != "b" {
+		t.Errorf("Get(b.key) = %q, %v", got, err)
+	}
+	if got := len(repo.List(ctx)); got != 3 {
+		t.Errorf("List() = %d keys, want 3", got)
+	}
+
+	// Reload drops cached contents
+	if err := os.WriteFile(filepath.Join(tempDir, "a.key"), []byte("a-v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.Get(ctx, "a.key"); string(got) != "a-v1" {
+		t.Errorf("Get(a.key) before Reload = %q, want cached a-v1", got)
+	}
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got, _ := repo.Get(ctx, "a.key"); string(got) != "a-v2" {
+		t.Errorf("Get(a.key) after Reload = %q, want a-v2", got)
+	}
+
+	// Writes go to disk and the cache
+	if err := repo.Put(ctx, "c.key", []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.Get(ctx, "c.key"); err != nil || string(got) != "c" {
+		t.Errorf("Get(c.key) after Put = %q, %v", got, err)
+	}
+	if err := repo.Delete(ctx, "c.key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Get(ctx, "c.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get(c.key) after Delete error = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestFileKeyRepository_LazyConcurrentMisses(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "hot.key"), []byte("hot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewFileKeyRepository(tempDir, WithLazyLoading(10, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := repo.Get(context.Background(), "hot.key"); err != nil || string(got) != "hot" {
+				t.Errorf("Get(hot.key) = %q, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if repo.lazy.len() != 1 {
+		t.Errorf("cached %d keys, want 1", repo.lazy.len())
+	}
+}
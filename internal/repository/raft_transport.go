@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+)
+
+const (
+	raftMaxPool = 3
+	raftTimeout = 10 * time.Second
+)
+
+// newNetworkTransport listens on bind for peer traffic and advertises
+// advertise to the other nodes; traffic is mutual TLS when tlsConfig is set
+func newNetworkTransport(bind, advertise string, tlsConfig *tls.Config, logger hclog.Logger) (*raft.NetworkTransport, error) {
+	addr, err := net.ResolveTCPAddr("tcp", advertise)
+	if err != nil {
+		return nil, fmt.Errorf("resolve cluster advertise-addr %s: %w", advertise, err)
+	}
+
+	if tlsConfig == nil {
+		transport, err := raft.NewTCPTransportWithLogger(bind, addr, raftMaxPool, raftTimeout, logger)
+		if err != nil {
+			return nil, fmt.Errorf("listen for cluster peers on %s: %w", bind, err)
+		}
+		return transport, nil
+	}
+
+	ln, err := tls.Listen("tcp", bind, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("listen for cluster peers on %s: %w", bind, err)
+	}
+	layer := &tlsStreamLayer{Listener: ln, advertise: addr, config: tlsConfig}
+	return raft.NewNetworkTransportWithLogger(layer, raftMaxPool, raftTimeout, logger), nil
+}
+
+// tlsStreamLayer is a raft.StreamLayer over mutually authenticated TLS
+type tlsStreamLayer struct {
+	net.Listener
+	advertise net.Addr
+	config    *tls.Config
+}
+
+// Addr returns the advertised address, which may differ from the bind address
+func (l *tlsStreamLayer) Addr() net.Addr {
+	return l.advertise
+}
+
+// Dial connects to a peer, verifying its certificate against the cluster CA
+func (l *tlsStreamLayer) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	return tls.DialWithDialer(dialer, "tcp", string(address), l.config)
+}
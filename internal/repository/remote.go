@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/pkg/keysync"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/tracing"
+)
+
+// ExportPath is the origin endpoint replicas pull from
+const ExportPath = "/api/v1/sync/export"
+
+// maxSnapshotBytes bounds a sealed snapshot read from the upstream
+const maxSnapshotBytes = 256 << 20
+
+// errNoSnapshot fails health checks until the first snapshot is loaded
+var errNoSnapshot = errors.New("no key snapshot loaded from upstream")
+
+// RemoteKeyRepository implements KeyRepository for replicas that do not own
+// keys. Reload pulls the changes since the last pull from an upstream key
+// server's export endpoint; keys are served from memory, and a failed pull
+// keeps the last good snapshot.
+type RemoteKeyRepository struct {
+	exportURL string
+	codec     *keysync.Codec
+	client    *http.Client
+	cacheFile string
+	logger    *zap.Logger
+
+	// syncMu serializes pulls so revisions are applied in order
+	syncMu sync.Mutex
+
+	mu     sync.RWMutex
+	keys   map[string][]byte
+	epoch  string
+	rev    uint64
+	loaded bool
+}
+
+// RemoteKeyOption configures optional RemoteKeyRepository behavior
+type RemoteKeyOption func(*RemoteKeyRepository)
+
+// WithRemoteLogger sets the logger for pulls and lookups
+func WithRemoteLogger(logger *zap.Logger) RemoteKeyOption {
+	return func(r *RemoteKeyRepository) {
+		r.logger = logger
+	}
+}
+
+// WithRemoteHTTPClient sets the client used to reach the upstream
+func WithRemoteHTTPClient(client *http.Client) RemoteKeyOption {
+	return func(r *RemoteKeyRepository) {
+		r.client = client
+	}
+}
+
+// WithRemoteCacheFile keeps the last good snapshot in file, sealed with the
+// sync secret, and starts from it so keys survive a restart while the
+// upstream is unreachable
+func WithRemoteCacheFile(file string) RemoteKeyOption {
+	return func(r *RemoteKeyRepository) {
+		r.cacheFile = file
+	}
+}
+
+// NewRemoteKeyRepository creates a replica of the key server at upstream,
+// a base URL such as https://origin.example.com. It loads the cache file
+// if configured but does not contact the upstream; call Reload for that.
+func NewRemoteKeyRepository(upstream string, codec *keysync.Codec, opts ...RemoteKeyOption) (*RemoteKeyRepository, error) {
+	u, err := url.Parse(upstream)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid upstream url %q", upstream)
+	}
+
+	r := &RemoteKeyRepository{
+		exportURL: strings.TrimSuffix(upstream, "/") + ExportPath,
+		codec:     codec,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		keys:      make(map[string][]byte),
+		logger:    zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.cacheFile != "" {
+		if err := r.loadCache(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Get retrieves a key from the last applied snapshot
+func (r *RemoteKeyRepository) Get(ctx context.Context, name string) ([]byte, error) {
+	if err := validateKeyName(name); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[name]
+	if !ok {
+		logger.FromContext(ctx, r.logger).Debug("key not in replica", zap.String("key_name", name))
+		return nil, apperrors.ErrKeyNotFound
+	}
+	return append([]byte(nil), key...), nil
+}
+
+// List returns the key names in the last applied snapshot
+func (r *RemoteKeyRepository) List(_ context.Context) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.keys))
+	for name := range r.keys {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Reload pulls and applies the changes since the last pull. On error the
+// current keys are kept.
+func (r *RemoteKeyRepository) Reload(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "KeyRepository.Reload",
+		trace.WithAttributes(attribute.String("hls.upstream", r.exportURL)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	result, err := r.pull(ctx)
+	if err != nil {
+		metrics.KeySyncs.WithLabelValues("failed").Inc()
+		return err
+	}
+	metrics.KeySyncs.WithLabelValues(result).Inc()
+	metrics.KeySyncLastSuccess.SetToCurrentTime()
+	return nil
+}
+
+// pull fetches and applies one export, returning full, delta or unchanged
+func (r *RemoteKeyRepository) pull(ctx context.Context) (string, error) {
+	log := logger.FromContext(ctx, r.logger)
+
+	r.mu.RLock()
+	epoch, since := r.epoch, r.rev
+	r.mu.RUnlock()
+
+	nonce, err := keysync.NewNonce()
+	if err != nil {
+		return "", fmt.Errorf("generate sync nonce: %w", err)
+	}
+	syncReq := &keysync.Request{Epoch: epoch, Since: since, Nonce: nonce}
+
+	q := url.Values{}
+	q.Set(keysync.ParamEpoch, epoch)
+	q.Set(keysync.ParamSince, strconv.FormatUint(since, 10))
+	q.Set(keysync.ParamNonce, nonce)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.exportURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("build sync request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+r.codec.Token())
+	req.Header.Set("Accept", keysync.ContentType)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("pull keys from upstream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		log.Debug("keys up to date with upstream", zap.Uint64("revision", since))
+		return "unchanged", nil
+	case http.StatusOK:
+	default:
+		return "", fmt.Errorf("pull keys from upstream: unexpected status %d", resp.StatusCode)
+	}
+
+	sealed, err := io.ReadAll(io.LimitReader(resp.Body, maxSnapshotBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("read upstream snapshot: %w", err)
+	}
+	if len(sealed) > maxSnapshotBytes {
+		return "", fmt.Errorf("upstream snapshot exceeds %d bytes", maxSnapshotBytes)
+	}
+	// A response to another pull, such as a replayed one, fails to open
+	snap, err := r.codec.Open(sealed, syncReq)
+	if err != nil {
+		return "", fmt.Errorf("open upstream snapshot: %w", err)
+	}
+	if !snap.Full && snap.Epoch != epoch {
+		return "", fmt.Errorf("upstream sent a delta for epoch %s, have %s", snap.Epoch, epoch)
+	}
+	if !snap.Full && snap.Revision < since {
+		return "", fmt.Errorf("upstream sent a delta to revision %d, have %d", snap.Revision, since)
+	}
+
+	count := r.apply(snap, log)
+	log.Info("keys synced from upstream",
+		zap.Bool("full", snap.Full),
+		zap.Uint64("revision", snap.Revision),
+		zap.Int("changed", len(snap.Keys)),
+		zap.Int("deleted", len(snap.Deleted)),
+		zap.Int("count", count),
+	)
+
+	if r.cacheFile != "" {
+		if err := r.saveCache(); err != nil {
+			// The pull succeeded; only restarts without upstream are affected
+			log.Warn("failed to write key sync cache", zap.String("file", r.cacheFile), zap.Error(err))
+		}
+	}
+
+	if snap.Full {
+		return "full", nil
+	}
+	return "delta", nil
+}
+
+// apply installs a snapshot and returns the resulting key count
+func (r *RemoteKeyRepository) apply(snap *keysync.Snapshot, log *zap.Logger) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if snap.Full {
+		r.keys = make(map[string][]byte, len(snap.Keys))
+	}
+	for name, data := range snap.Keys {
+		if err := validateKeyName(name); err != nil {
+			log.Warn("skipping upstream key with invalid name", zap.String("key_name", name))
+			continue
+		}
+		r.keys[name] = data
+	}
+	for _, name := range snap.Deleted {
+		delete(r.keys, name)
+	}
+	r.epoch, r.rev, r.loaded = snap.Epoch, snap.Revision, true
+	return len(r.keys)
+}
+
+// Run pulls every interval until ctx is done
+func (r *RemoteKeyRepository) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := r.Reload(ctx)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				return
+			case err != nil:
+				// Warn once per outage rather than on every tick
+				log := r.logger.Warn
+				if failing {
+					log = r.logger.Debug
+				}
+				failing = true
+				log("key sync failed, serving last good snapshot",
+					zap.Int("count", len(r.List(ctx))),
+					zap.Error(err),
+				)
+			case failing:
+				failing = false
+				r.logger.Info("key sync recovered")
+			}
+		}
+	}
+}
+
+// HealthCheck fails until a snapshot has been loaded. An unreachable
+// upstream alone does not fail it, since the last good keys are served.
+func (r *RemoteKeyRepository) HealthCheck(_ context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.loaded {
+		return errNoSnapshot
+	}
+	return nil
+}
+
+// loadCache starts from the snapshot saved by a previous run, if any
+func (r *RemoteKeyRepository) loadCache() error {
+	sealed, err := os.ReadFile(r.cacheFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read key sync cache: %w", err)
+	}
+	snap, err := r.codec.Open(sealed, nil)
+	if err != nil {
+		return fmt.Errorf("open key sync cache %s: %w", r.cacheFile, err)
+	}
+
+	count := r.apply(snap, r.logger)
+	r.logger.Info("keys loaded from sync cache",
+		zap.String("file", r.cacheFile),
+		zap.Uint64("revision", snap.Revision),
+		zap.Int("count", count),
+	)
+	return nil
+}
+
+// saveCache writes the current keys as a full snapshot, atomically
+func (r *RemoteKeyRepository) saveCache() error {
+	r.mu.RLock()
+	snap := &keysync.Snapshot{Epoch: r.epoch, Revision: r.rev, Full: true, Keys: r.keys}
+	sealed, err := r.codec.Seal(snap, nil)
+	r.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(r.cacheFile)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".sync-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(sealed); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.cacheFile)
+}
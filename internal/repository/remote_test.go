@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/pkg/keysync"
+)
+
+const testSyncSecret = "a-shared-secret-of-at-least-32-chars"
+
+// fakeOrigin serves whatever snapshot the test sets, sealed with codec
+type fakeOrigin struct {
+	codec *keysync.Codec
+
+	mu     sync.Mutex
+	snap   *keysync.Snapshot
+	status int
+	// queries records the since parameter of every request
+	queries []string
+	// last is the last response sent; replay serves it again unchanged
+	last   []byte
+	replay bool
+}
+
+func (o *fakeOrigin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if r.URL.Path != ExportPath || r.Header.Get("Authorization") != "Bearer "+o.codec.Token() {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	o.queries = append(o.queries, r.URL.Query().Get(keysync.ParamSince))
+	if o.status != 0 {
+		w.WriteHeader(o.status)
+		return
+	}
+	if o.replay {
+		_, _ = w.Write(o.last)
+		return
+	}
+	q := r.URL.Query()
+	since, _ := strconv.ParseUint(q.Get(keysync.ParamSince), 10, 64)
+	sealed, err := o.codec.Seal(o.snap, &keysync.Request{Epoch: q.Get(keysync.ParamEpoch), Since: since, Nonce: q.Get(keysync.ParamNonce)})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	o.last = sealed
+	_, _ = w.Write(sealed)
+}
+
+func (o *fakeOrigin) set(snap *keysync.Snapshot, status int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.snap, o.status = snap, status
+}
+
+func newFakeOrigin(t *testing.T) (*fakeOrigin, *httptest.Server, *keysync.Codec) {
+	t.Helper()
+	codec, err := keysync.NewCodec(testSyncSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := &fakeOrigin{codec: codec}
+	srv := httptest.NewServer(origin)
+	t.Cleanup(srv.Close)
+	return origin, srv, codec
+}
+
+func TestRemoteKeyRepository_Sync(t *testing.T) {
+	ctx := context.Background()
+	origin, srv, codec := newFakeOrigin(t)
+	repo, err := NewRemoteKeyRepository(srv.URL+"/", codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.HealthCheck(ctx); err == nil {
+		t.Error("HealthCheck() passed before any snapshot was loaded")
+	}
+
+	origin.set(&keysync.Snapshot{Epoch: "e1", Revision: 2, Full: true, Keys: map[string][]byte{
+		"a.key": []byte("aaaa"),
+		"b.key": []byte("bbbb"),
+	}}, 0)
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() full error = %v", err)
+	}
+	if got, err := repo.Get(ctx, "a.key"); err != nil || string(got) != "aaaa" {
+		t.Errorf("Get(a.key) = %q, %v", got, err)
+	}
+	if err := repo.HealthCheck(ctx); err != nil {
+		t.Errorf("HealthCheck() error = %v after a snapshot", err)
+	}
+
+	origin.set(&keysync.Snapshot{Epoch: "e1", Revision: 4, Keys: map[string][]byte{
+		"a.key": []byte("AAAA"),
+	}, Deleted: []string{"b.key"}}, 0)
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() delta error = %v", err)
+	}
+	if got, _ := repo.Get(ctx, "a.key"); string(got) != "AAAA" {
+		t.Errorf("Get(a.key) after delta = %q", got)
+	}
+	if _, err := repo.Get(ctx, "b.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get(b.key) after delete error = %v", err)
+	}
+
+	origin.set(nil, http.StatusNotModified)
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() unchanged error = %v", err)
+	}
+	if got := origin.queries; len(got) != 3 || got[0] != "0" || got[1] != "2" || got[2] != "4" {
+		t.Errorf("since parameters = %v, want [0 2 4]", got)
+	}
+}
+
+func TestRemoteKeyRepository_KeepsLastGoodSnapshot(t *testing.T) {
+	ctx := context.Background()
+	origin, srv, codec := newFakeOrigin(t)
+	repo, err := NewRemoteKeyRepository(srv.URL, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin.set(&keysync.Snapshot{Epoch: "e1", Revision: 1, Full: true, Keys: map[string][]byte{"a.key": []byte("aaaa")}}, 0)
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := keysync.NewCodec("another-secret-of-at-least-32-chars!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures := map[string]func(){
+		"server error": func() { origin.set(nil, http.StatusInternalServerError) },
+		"wrong secret": func() {
+			origin.set(&keysync.Snapshot{Epoch: "e1", Revision: 2, Full: true}, 0)
+			origin.mu.Lock()
+			origin.codec = other
+			origin.mu.Unlock()
+		},
+		"delta from another epoch": func() {
+			origin.mu.Lock()
+			origin.codec = codec
+			origin.mu.Unlock()
+			origin.set(&keysync.Snapshot{Epoch: "e2", Revision: 2, Deleted: []string{"a.key"}}, 0)
+		},
+		"delta below since": func() {
+			origin.set(&keysync.Snapshot{Epoch: "e1", Revision: 0, Deleted: []string{"a.key"}}, 0)
+		},
+		"replayed response": func() {
+			origin.mu.Lock()
+			origin.replay = true
+			origin.mu.Unlock()
+		},
+		"upstream gone": srv.Close,
+	}
+	for _, name := range []string{"server error", "wrong secret", "delta from another epoch", "delta below since", "replayed response", "upstream gone"} {
+		failures[name]()
+		if err := repo.Reload(ctx); err == nil {
+			t.Errorf("%s: Reload() succeeded", name)
+		}
+		if got, err := repo.Get(ctx, "a.key"); err != nil || string(got) != "aaaa" {
+			t.Errorf("%s: Get(a.key) = %q, %v; want the last good key", name, got, err)
+		}
+		if err := repo.HealthCheck(ctx); err != nil {
+			t.Errorf("%s: HealthCheck() error = %v", name, err)
+		}
+	}
+}
+
+func TestRemoteKeyRepository_CacheFile(t *testing.T) {
+	ctx := context.Background()
+	origin, srv, codec := newFakeOrigin(t)
+	cacheFile := filepath.Join(t.TempDir(), "sync", "keys.sealed")
+
+	repo, err := NewRemoteKeyRepository(srv.URL, codec, WithRemoteCacheFile(cacheFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin.set(&keysync.Snapshot{Epoch: "e1", Revision: 3, Full: true, Keys: map[string][]byte{"a.key": []byte("aaaa")}}, 0)
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	// A restart while the upstream is down serves the cached snapshot
+	restarted, err := NewRemoteKeyRepository(srv.URL, codec, WithRemoteCacheFile(cacheFile))
+	if err != nil {
+		t.Fatalf("NewRemoteKeyRepository() with cache error = %v", err)
+	}
+	if got, err := restarted.Get(ctx, "a.key"); err != nil || string(got) != "aaaa" {
+		t.Errorf("Get(a.key) from cache = %q, %v", got, err)
+	}
+	if restarted.rev != 3 || restarted.epoch != "e1" {
+		t.Errorf("cached revision = %s/%d, want e1/3", restarted.epoch, restarted.rev)
+	}
+
+	other, _ := keysync.NewCodec("another-secret-of-at-least-32-chars!")
+	if _, err := NewRemoteKeyRepository(srv.URL, other, WithRemoteCacheFile(cacheFile)); !errors.Is(err, keysync.ErrOpen) {
+		t.Errorf("NewRemoteKeyRepository() with another secret error = %v, want ErrOpen", err)
+	}
+}
@@ -0,0 +1,497 @@
+package repository
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapio"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/logger"
+)
+
+// LocalKeyStore is the node-local store replicated writes are applied to
+type LocalKeyStore interface {
+	KeyRepository
+	KeyWriter
+}
+
+// NotLeaderError is returned for writes sent to a follower; it wraps
+// apperrors.ErrNotLeader and names the leader when one is known
+type NotLeaderError struct {
+	LeaderID   string
+	LeaderAddr string
+	// LeaderURL is the leader's HTTP base URL from the peer list, if configured
+	LeaderURL string
+}
+
+func (e *NotLeaderError) Error() string {
+	if e.LeaderID == "" {
+		return apperrors.ErrNotLeader.Error() + ": no leader elected"
+	}
+	return fmt.Sprintf("%s: leader is %s (%s)", apperrors.ErrNotLeader, e.LeaderID, e.LeaderAddr)
+}
+
+func (e *NotLeaderError) Unwrap() error {
+	return apperrors.ErrNotLeader
+}
+
+// ClusterMember is one server in the Raft configuration
+type ClusterMember struct {
+	ID       string `json:"id"`
+	Addr     string `json:"addr"`
+	Suffrage string `json:"suffrage"`
+	Leader   bool   `json:"leader"`
+}
+
+// ClusterStatus describes this node's view of the cluster
+type ClusterStatus struct {
+	NodeID       string          `json:"node_id"`
+	State        string          `json:"state"`
+	LeaderID     string          `json:"leader_id"`
+	LeaderAddr   string          `json:"leader_addr"`
+	Term         uint64          `json:"term"`
+	LastIndex    uint64          `json:"last_index"`
+	AppliedIndex uint64          `json:"applied_index"`
+	Members      []ClusterMember `json:"members"`
+}
+
+// ReplicatedKeyRepository replicates key writes through a Raft log and
+// applies them to a local store on every node. Reads are served from the
+// local store, so followers may briefly lag the leader.
+type ReplicatedKeyRepository struct {
+	local        LocalKeyStore
+	raft         *raft.Raft
+	nodeID       string
+	applyTimeout time.Duration
+	peerURLs     map[string]string
+	closers      []io.Closer
+	logger       *zap.Logger
+}
+
+// ReplicatedKeyOption configures optional ReplicatedKeyRepository behavior
+type ReplicatedKeyOption func(*replicatedOptions)
+
+type replicatedOptions struct {
+	logger    *zap.Logger
+	transport raft.Transport
+	logs      raft.LogStore
+	stable    raft.StableStore
+	snapshots raft.SnapshotStore
+	raftCfg   func(*raft.Config)
+}
+
+// WithReplicationLogger sets the logger for replication and Raft events
+func WithReplicationLogger(logger *zap.Logger) ReplicatedKeyOption {
+	return func(o *replicatedOptions) {
+		o.logger = logger
+	}
+}
+
+// WithRaftTransport replaces the TCP transport, e.g. with an in-memory one
+func WithRaftTransport(transport raft.Transport) ReplicatedKeyOption {
+	return func(o *replicatedOptions) {
+		o.transport = transport
+	}
+}
+
+// WithRaftStores replaces the on-disk log, stable and snapshot stores
+func WithRaftStores(logs raft.LogStore, stable raft.StableStore, snapshots raft.SnapshotStore) ReplicatedKeyOption {
+	return func(o *replicatedOptions) {
+		o.logs = logs
+		o.stable = stable
+		o.snapshots = snapshots
+	}
+}
+
+// WithRaftConfig adjusts the Raft configuration before the node starts,
+// e.g. to shorten timeouts in tests
+func WithRaftConfig(fn func(*raft.Config)) ReplicatedKeyOption {
+	return func(o *replicatedOptions) {
+		o.raftCfg = fn
+	}
+}
+
+// NewReplicatedKeyRepository starts a Raft node applying writes to local.
+// Unless replaced through options, the log lives in a BoltDB file and
+// snapshots under cfg.DataDir, and peers talk over TCP, with mutual TLS
+// when peerTLS is non-nil.
+func NewReplicatedKeyRepository(local LocalKeyStore, cfg configs.Cluster, peerTLS *tls.Config, opts ...ReplicatedKeyOption) (_ *ReplicatedKeyRepository, err error) {
+	o := replicatedOptions{logger: zap.NewNop()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r := &ReplicatedKeyRepository{
+		local:        local,
+		nodeID:       cfg.NodeID,
+		applyTimeout: cfg.ApplyTimeout,
+		peerURLs:     make(map[string]string, len(cfg.Peers)),
+		logger:       o.logger,
+	}
+	for _, p := range cfg.Peers {
+		if p.APIURL != "" {
+			r.peerURLs[p.ID] = p.APIURL
+		}
+	}
+	defer func() {
+		if err != nil {
+			if r.raft != nil {
+				_ = r.raft.Shutdown().Error()
+			}
+			_ = r.closeAll()
+		}
+	}()
+
+	rc := raft.DefaultConfig()
+	rc.LocalID = raft.ServerID(cfg.NodeID)
+	if cfg.SnapshotThreshold > 0 {
+		rc.SnapshotThreshold = cfg.SnapshotThreshold
+	}
+	rc.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:   "raft",
+		Level:  hclog.Info,
+		Output: &zapio.Writer{Log: o.logger, Level: zap.InfoLevel},
+		// zap adds its own timestamp
+		DisableTime: true,
+	})
+	if o.raftCfg != nil {
+		o.raftCfg(rc)
+	}
+
+	if o.logs == nil {
+		if err := os.MkdirAll(cfg.DataDir, 0o700); err != nil {
+			return nil, fmt.Errorf("create raft data dir: %w", err)
+		}
+		store, err := raftboltdb.NewBoltStore(filepath.Join(cfg.DataDir, "raft.db"))
+		if err != nil {
+			return nil, fmt.Errorf("open raft log: %w", err)
+		}
+		r.closers = append(r.closers, store)
+		o.logs, o.stable = store, store
+
+		snaps, err := raft.NewFileSnapshotStore(cfg.DataDir, 2, rc.Logger.StandardWriter(&hclog.StandardLoggerOptions{}))
+		if err != nil {
+			return nil, fmt.Errorf("open raft snapshots: %w", err)
+		}
+		o.snapshots = snaps
+	}
+
+	if o.transport == nil {
+		advertise := cfg.AdvertiseAddr
+		if advertise == "" {
+			advertise = cfg.BindAddr
+		}
+		transport, err := newNetworkTransport(cfg.BindAddr, advertise, peerTLS, rc.Logger)
+		if err != nil {
+			return nil, err
+		}
+		r.closers = append(r.closers, transport)
+		o.transport = transport
+	}
+
+	node, err := raft.NewRaft(rc, &keyFSM{store: local, logger: o.logger}, o.logs, o.stable, o.snapshots, o.transport)
+	if err != nil {
+		return nil, fmt.Errorf("start raft: %w", err)
+	}
+	r.raft = node
+
+	if cfg.Bootstrap {
+		existing, err := raft.HasExistingState(o.logs, o.stable, o.snapshots)
+		if err != nil {
+			return nil, fmt.Errorf("check raft state: %w", err)
+		}
+		if !existing {
+			servers := make([]raft.Server, 0, len(cfg.Peers))
+			for _, p := range cfg.Peers {
+				servers = append(servers, raft.Server{ID: raft.ServerID(p.ID), Address: raft.ServerAddress(p.Addr)})
+			}
+			// Peers bootstrapping the same configuration race harmlessly
+			if err := node.BootstrapCluster(raft.Configuration{Servers: servers}).Error(); err != nil && !errors.Is(err, raft.ErrCantBootstrap) {
+				return nil, fmt.Errorf("bootstrap cluster: %w", err)
+			}
+			o.logger.Info("bootstrapped cluster", zap.Int("peers", len(servers)))
+		}
+	}
+
+	return r, nil
+}
+
+// Get retrieves a key from the local store
+func (r *ReplicatedKeyRepository) Get(ctx context.Context, name string) ([]byte, error) {
+	return r.local.Get(ctx, name)
+}
+
+// List returns the key names in the local store
+func (r *ReplicatedKeyRepository) List(ctx context.Context) []string {
+	return r.local.List(ctx)
+}
+
+// Reload reloads the local store from its backing storage
+func (r *ReplicatedKeyRepository) Reload(ctx context.Context) error {
+	return r.local.Reload(ctx)
+}
+
+// Put replicates a key write; it returns once the write is committed and
+// applied on this node. Followers return a *NotLeaderError.
+func (r *ReplicatedKeyRepository) Put(ctx context.Context, name string, data []byte) error {
+	if err := validateKeyName(name); err != nil {
+		return err
+	}
+	return r.apply(ctx, command{Op: opPut, Name: name, Data: data})
+}
+
+// Delete replicates a key removal; see Put
+func (r *ReplicatedKeyRepository) Delete(ctx context.Context, name string) error {
+	if err := validateKeyName(name); err != nil {
+		return err
+	}
+	return r.apply(ctx, command{Op: opDelete, Name: name})
+}
+
+func (r *ReplicatedKeyRepository) apply(ctx context.Context, cmd command) error {
+	if r.raft.State() != raft.Leader {
+		return r.notLeader()
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("encode %s command: %w", cmd.Op, err)
+	}
+
+	timeout := r.applyTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	future := r.raft.Apply(data, timeout)
+	if err := future.Error(); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+			return r.notLeader()
+		}
+		return fmt.Errorf("replicate %s %s: %w", cmd.Op, cmd.Name, err)
+	}
+	if err, ok := future.Response().(error); ok && err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx, r.logger).Debug("key change replicated",
+		zap.String("op", cmd.Op),
+		zap.String("key_name", cmd.Name),
+		zap.Uint64("index", future.Index()),
+	)
+	return nil
+}
+
+func (r *ReplicatedKeyRepository) notLeader() error {
+	addr, id := r.raft.LeaderWithID()
+	return &NotLeaderError{
+		LeaderID:   string(id),
+		LeaderAddr: string(addr),
+		LeaderURL:  r.peerURLs[string(id)],
+	}
+}
+
+// AddVoter adds a server to the cluster; only the leader can change membership
+func (r *ReplicatedKeyRepository) AddVoter(_ context.Context, id, addr string) error {
+	if r.raft.State() != raft.Leader {
+		return r.notLeader()
+	}
+	if err := r.raft.AddVoter(raft.ServerID(id), raft.ServerAddress(addr), 0, r.applyTimeout).Error(); err != nil {
+		return fmt.Errorf("add voter %s: %w", id, err)
+	}
+	return nil
+}
+
+// RemoveServer removes a server from the cluster; only the leader can
+// change membership
+func (r *ReplicatedKeyRepository) RemoveServer(_ context.Context, id string) error {
+	if r.raft.State() != raft.Leader {
+		return r.notLeader()
+	}
+	if err := r.raft.RemoveServer(raft.ServerID(id), 0, r.applyTimeout).Error(); err != nil {
+		return fmt.Errorf("remove server %s: %w", id, err)
+	}
+	return nil
+}
+
+// Status reports this node's state, the leader and the members
+func (r *ReplicatedKeyRepository) Status() (ClusterStatus, error) {
+	leaderAddr, leaderID := r.raft.LeaderWithID()
+	status := ClusterStatus{
+		NodeID:       r.nodeID,
+		State:        r.raft.State().String(),
+		LeaderID:     string(leaderID),
+		LeaderAddr:   string(leaderAddr),
+		Term:         r.raft.CurrentTerm(),
+		LastIndex:    r.raft.LastIndex(),
+		AppliedIndex: r.raft.AppliedIndex(),
+	}
+
+	future := r.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return status, fmt.Errorf("read cluster configuration: %w", err)
+	}
+	for _, s := range future.Configuration().Servers {
+		status.Members = append(status.Members, ClusterMember{
+			ID:       string(s.ID),
+			Addr:     string(s.Address),
+			Suffrage: s.Suffrage.String(),
+			Leader:   s.ID == leaderID,
+		})
+	}
+	return status, nil
+}
+
+// HealthCheck fails while no leader is known or the local store is unhealthy
+func (r *ReplicatedKeyRepository) HealthCheck(ctx context.Context) error {
+	if _, id := r.raft.LeaderWithID(); id == "" {
+		return &NotLeaderError{}
+	}
+	if hc, ok := r.local.(HealthChecker); ok {
+		return hc.HealthCheck(ctx)
+	}
+	return nil
+}
+
+// Close stops the Raft node and releases its transport and log
+func (r *ReplicatedKeyRepository) Close() error {
+	err := r.raft.Shutdown().Error()
+	return errors.Join(err, r.closeAll())
+}
+
+func (r *ReplicatedKeyRepository) closeAll() error {
+	var errs []error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		errs = append(errs, r.closers[i].Close())
+	}
+	r.closers = nil
+	return errors.Join(errs...)
+}
+
+// Replicated log commands
+const (
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+// command is one replicated key change
+type command struct {
+	Op   string `json:"op"`
+	Name string `json:"name"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// keyFSM applies committed commands to the local store. Raft calls Apply,
+// Snapshot and Restore from a single goroutine.
+type keyFSM struct {
+	store  LocalKeyStore
+	logger *zap.Logger
+}
+
+// Apply returns nil or the error applying the command, which the leader
+// passes back to the writer
+func (f *keyFSM) Apply(l *raft.Log) interface{} {
+	var cmd command
+	if err := json.Unmarshal(l.Data, &cmd); err != nil {
+		f.logger.Error("undecodable replicated command", zap.Uint64("index", l.Index), zap.Error(err))
+		return fmt.Errorf("decode command at index %d: %w", l.Index, err)
+	}
+
+	ctx := context.Background()
+	var err error
+	switch cmd.Op {
+	case opPut:
+		err = f.store.Put(ctx, cmd.Name, cmd.Data)
+	case opDelete:
+		err = f.store.Delete(ctx, cmd.Name)
+	default:
+		err = fmt.Errorf("unknown command %q at index %d", cmd.Op, l.Index)
+	}
+	if err != nil {
+		f.logger.Error("failed to apply replicated key change",
+			zap.String("op", cmd.Op),
+			zap.String("key_name", cmd.Name),
+			zap.Uint64("index", l.Index),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// Snapshot captures every key in the local store
+func (f *keyFSM) Snapshot() (raft.FSMSnapshot, error) {
+	ctx := context.Background()
+	names := f.store.List(ctx)
+	sort.Strings(names)
+
+	keys := make(map[string][]byte, len(names))
+	for _, name := range names {
+		data, err := f.store.Get(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot key %s: %w", name, err)
+		}
+		keys[name] = data
+	}
+	return &keySnapshot{keys: keys}, nil
+}
+
+// Restore replaces the local store with a snapshot; keys missing from the
+// snapshot are deleted, since the replicated state is authoritative
+func (f *keyFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	var snap snapshotData
+	if err := json.NewDecoder(rc).Decode(&snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	ctx := context.Background()
+	for name, data := range snap.Keys {
+		if err := f.store.Put(ctx, name, data); err != nil {
+			return fmt.Errorf("restore key %s: %w", name, err)
+		}
+	}
+	for _, name := range f.store.List(ctx) {
+		if _, ok := snap.Keys[name]; ok {
+			continue
+		}
+		f.logger.Warn("deleting key absent from cluster snapshot", zap.String("key_name", name))
+		if err := f.store.Delete(ctx, name); err != nil {
+			return fmt.Errorf("restore: delete key %s: %w", name, err)
+		}
+	}
+	f.logger.Info("restored keys from cluster snapshot", zap.Int("count", len(snap.Keys)))
+	return nil
+}
+
+type snapshotData struct {
+	Keys map[string][]byte `json:"keys"`
+}
+
+type keySnapshot struct {
+	keys map[string][]byte
+}
+
+func (s *keySnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := json.NewEncoder(sink).Encode(snapshotData{Keys: s.keys}); err != nil {
+		_ = sink.Cancel()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return sink.Close()
+}
+
+func (s *keySnapshot) Release() {}
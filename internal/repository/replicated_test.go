@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+)
+
+type testNode struct {
+	repo  *ReplicatedKeyRepository
+	local *FileKeyRepository
+	trans *raft.InmemTransport
+}
+
+// newTestCluster starts n nodes connected through in-memory transports,
+// bootstrapped from node-0
+func newTestCluster(t *testing.T, n int) []*testNode {
+	t.Helper()
+
+	peers := make([]configs.ClusterPeer, n)
+	transports := make([]*raft.InmemTransport, n)
+	for i := range n {
+		id := fmt.Sprintf("node-%d", i)
+		addr, trans := raft.NewInmemTransport(raft.ServerAddress(id))
+		transports[i] = trans
+		peers[i] = configs.ClusterPeer{ID: id, Addr: string(addr), APIURL: "http://" + id + ":8080"}
+	}
+	for i := range transports {
+		for j := range transports {
+			if i != j {
+				transports[i].Connect(transports[j].LocalAddr(), transports[j])
+			}
+		}
+	}
+
+	nodes := make([]*testNode, n)
+	for i := range n {
+		nodes[i] = startTestNode(t, peers, i, transports[i])
+	}
+	return nodes
+}
+
+func startTestNode(t *testing.T, peers []configs.ClusterPeer, i int, trans *raft.InmemTransport) *testNode {
+	t.Helper()
+	local, err := NewFileKeyRepository(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := configs.Cluster{
+		Enable:       true,
+		NodeID:       peers[i].ID,
+		Bootstrap:    i == 0,
+		Peers:        peers,
+		ApplyTimeout: 5 * time.Second,
+	}
+	store := raft.NewInmemStore()
+	repo, err := NewReplicatedKeyRepository(local, cfg, nil,
+		WithRaftTransport(trans),
+		WithRaftStores(store, store, raft.NewInmemSnapshotStore()),
+		WithRaftConfig(fastRaft),
+	)
+	if err != nil {
+		t.Fatalf("start %s: %v", cfg.NodeID, err)
+	}
+	t.Cleanup(func() {
+		_ = repo.Close()
+	})
+	return &testNode{repo: repo, local: local, trans: trans}
+}
+
+func fastRaft(c *raft.Config) {
+	c.HeartbeatTimeout = 50 * time.Millisecond
+	c.ElectionTimeout = 50 * time.Millisecond
+	c.LeaderLeaseTimeout = 50 * time.Millisecond
+	c.CommitTimeout = 5 * time.Millisecond
+	// Compact the whole log on snapshot so late joiners restore from it
+	c.TrailingLogs = 0
+}
+
+func waitForLeader(t *testing.T, nodes []*testNode) (leader *testNode, followers []*testNode) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		leader, followers = nil, nil
+		for _, n := range nodes {
+			if n.repo.raft.State() == raft.Leader {
+				leader = n
+			} else {
+				followers = append(followers, n)
+			}
+		}
+		if leader != nil {
+			return leader, followers
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no leader elected")
+	return nil, nil
+}
+
+// eventually retries check until it passes or the deadline expires
+func eventually(t *testing.T, what string, check func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !check() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReplicatedKeyRepository_Replicates(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	leader, followers := waitForLeader(t, nodes)
+	ctx := context.Background()
+	content := []byte("0123456789abcdef")
+
+	if err := leader.repo.Put(ctx, "stream.key", content); err != nil {
+		t.Fatalf("Put() on leader error = %v", err)
+	}
+	// The leader applies before Put returns
+	if got, err := leader.local.Get(ctx, "stream.key"); err != nil || string(got) != string(content) {
+		t.Fatalf("leader Get() = %q, %v", got, err)
+	}
+	for _, f := range followers {
+		eventually(t, "key on "+f.repo.nodeID, func() bool {
+			got, err := f.repo.Get(ctx, "stream.key")
+			return err == nil && string(got) == string(content)
+		})
+	}
+
+	if err := leader.repo.Delete(ctx, "stream.key"); err != nil {
+		t.Fatalf("Delete() on leader error = %v", err)
+	}
+	for _, f := range followers {
+		eventually(t, "delete on "+f.repo.nodeID, func() bool {
+			_, err := f.repo.Get(ctx, "stream.key")
+			return errors.Is(err, apperrors.ErrKeyNotFound)
+		})
+	}
+}
+
+func TestReplicatedKeyRepository_FollowerRejectsWrites(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	leader, followers := waitForLeader(t, nodes)
+
+	err := followers[0].repo.Put(context.Background(), "stream.key", []byte("x"))
+	if !errors.Is(err, apperrors.ErrNotLeader) {
+		t.Fatalf("Put() on follower error = %v, want ErrNotLeader", err)
+	}
+	var nle *NotLeaderError
+	if !errors.As(err, &nle) {
+		t.Fatalf("error %T is not *NotLeaderError", err)
+	}
+	// Followers learn the leader from its first heartbeat
+	if nle.LeaderID != "" && nle.LeaderID != leader.repo.nodeID {
+		t.Errorf("LeaderID = %q, want %q", nle.LeaderID, leader.repo.nodeID)
+	}
+	if nle.LeaderID != "" && nle.LeaderURL != "http://"+leader.repo.nodeID+":8080" {
+		t.Errorf("LeaderURL = %q", nle.LeaderURL)
+	}
+
+	if err := followers[0].repo.Put(context.Background(), "../x.key", []byte("x")); !errors.Is(err, apperrors.ErrInvalidKeyName) {
+		t.Errorf("Put(invalid name) error = %v, want ErrInvalidKeyName", err)
+	}
+}
+
+func TestReplicatedKeyRepository_Status(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	leader, followers := waitForLeader(t, nodes)
+
+	// Followers may learn the leader before the membership entry reaches them
+	eventually(t, "followers to learn the leader and members", func() bool {
+		for _, f := range followers {
+			if s, err := f.repo.Status(); err != nil || s.LeaderID != leader.repo.nodeID || len(s.Members) != 3 {
+				return false
+			}
+		}
+		return true
+	})
+
+	status, err := followers[0].repo.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.State != raft.Follower.String() {
+		t.Errorf("State = %q, want %q", status.State, raft.Follower.String())
+	}
+	if len(status.Members) != 3 {
+		t.Fatalf("Members = %+v, want 3", status.Members)
+	}
+	leaders := 0
+	for _, m := range status.Members {
+		if m.Leader {
+			leaders++
+			if m.ID != leader.repo.nodeID {
+				t.Errorf("member %s marked leader, want %s", m.ID, leader.repo.nodeID)
+			}
+		}
+	}
+	if leaders != 1 {
+		t.Errorf("%d members marked leader, want 1", leaders)
+	}
+	if err := followers[0].repo.HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+}
+
+func TestReplicatedKeyRepository_AddVoterCatchesUp(t *testing.T) {
+	nodes := newTestCluster(t, 1)
+	leader, _ := waitForLeader(t, nodes)
+	ctx := context.Background()
+
+	if err := leader.repo.Put(ctx, "early.key", []byte("written-before-join")); err != nil {
+		t.Fatal(err)
+	}
+	// Force a snapshot so the new node restores from it rather than the log
+	if err := leader.repo.raft.Snapshot().Error(); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	addr, trans := raft.NewInmemTransport("node-1")
+	leader.trans.Connect(addr, trans)
+	trans.Connect(leader.trans.LocalAddr(), leader.trans)
+	peers := []configs.ClusterPeer{{ID: "node-0", Addr: string(leader.trans.LocalAddr())}, {ID: "node-1", Addr: string(addr)}}
+	joined := startTestNode(t, peers, 1, trans)
+	// A stale key on the joining node is removed by the snapshot restore
+	if err := joined.local.Put(ctx, "stale.key", []byte("stale")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := leader.repo.AddVoter(ctx, "node-1", string(addr)); err != nil {
+		t.Fatalf("AddVoter() error = %v", err)
+	}
+	eventually(t, "joined node to restore the snapshot", func() bool {
+		got, err := joined.repo.Get(ctx, "early.key")
+		return err == nil && string(got) == "written-before-join"
+	})
+	if _, err := joined.repo.Get(ctx, "stale.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("stale key survived restore: %v", err)
+	}
+
+	if err := leader.repo.RemoveServer(ctx, "node-1"); err != nil {
+		t.Fatalf("RemoveServer() error = %v", err)
+	}
+	status, err := leader.repo.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Members) != 1 {
+		t.Errorf("Members after RemoveServer = %+v, want 1", status.Members)
+	}
+}
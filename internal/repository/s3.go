@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/tracing"
+)
+
+// maxKeyObjectBytes bounds a key object read from the bucket; content keys
+// are a few bytes, so anything larger is not a key
+const maxKeyObjectBytes = 64 << 10
+
+// s3Key is a cached key object and the ETag it was read at
+type s3Key struct {
+	data []byte
+	etag string
+}
+
+// S3KeyRepository implements KeyRepository over an S3-compatible bucket.
+// Every object directly below the prefix whose remaining name is a valid key
+// name is a key. Keys are served from memory like FileKeyRepository; Reload
+// lists the prefix and only fetches objects whose ETag changed.
+type S3KeyRepository struct {
+	client  *minio.Core
+	bucket  string
+	prefix  string
+	timeout time.Duration
+	logger  *zap.Logger
+
+	// reloadMu serializes reloads so each one starts from the last cache
+	reloadMu sync.Mutex
+
+	mu    sync.RWMutex
+	cache map[string]s3Key
+}
+
+// S3KeyOption configures optional S3KeyRepository behavior
+type S3KeyOption func(*S3KeyRepository)
+
+// WithS3Logger sets the logger for key loading and lookups
+func WithS3Logger(logger *zap.Logger) S3KeyOption {
+	return func(r *S3KeyRepository) {
+		r.logger = logger
+	}
+}
+
+// NewS3KeyRepository creates a key repository over cfg.Bucket and loads
+// its keys
+func NewS3KeyRepository(cfg configs.KeyStoreS3, opts ...S3KeyOption) (*S3KeyRepository, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket cannot be empty")
+	}
+
+	creds := credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, "")
+	if cfg.AccessKey == "" {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}},
+		})
+	}
+
+	client, err := minio.NewCore(endpoint.Host, &minio.Options{
+		Creds:  creds,
+		Secure: endpoint.Scheme == "https",
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create S3 client: %w", err)
+	}
+
+	repo := &S3KeyRepository{
+		client:  client,
+		bucket:  cfg.Bucket,
+		prefix:  cfg.Prefix,
+		timeout: cfg.Timeout,
+		logger:  zap.NewNop(),
+		cache:   make(map[string]s3Key),
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
+
+	if err := repo.Reload(context.Background()); err != nil {
+		return nil, fmt.Errorf("initial key load: %w", err)
+	}
+	return repo, nil
+}
+
+// Get retrieves a key by name from cache
+func (r *S3KeyRepository) Get(ctx context.Context, name string) (_ []byte, err error) {
+	_, span := tracing.Tracer().Start(ctx, "KeyRepository.Get",
+		trace.WithAttributes(attribute.String("hls.key", name)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateKeyName(name); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, exists := r.cache[name]
+	if !exists {
+		logger.FromContext(ctx, r.logger).Debug("key not in cache", zap.String("key_name", name))
+		return nil, apperrors.ErrKeyNotFound
+	}
+
+	// Return a copy to prevent cache mutation
+	return append([]byte(nil), key.data...), nil
+}
+
+// List returns all available key names
+func (r *S3KeyRepository) List(_ context.Context) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.cache))
+	for name := range r.cache {
+		names = append(names, name)
+	}
+	return names
+}
+
+// HealthCheck verifies the bucket is still reachable
+func (r *S3KeyRepository) HealthCheck(ctx context.Context) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	exists, err := r.client.BucketExists(ctx, r.bucket)
+	if err != nil {
+		return fmt.Errorf("check bucket %s: %w", r.bucket, err)
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", r.bucket)
+	}
+	return nil
+}
+
+// Reload lists the prefix and swaps in the listed keys. Objects whose
+// listed ETag matches the cache are kept without a request; others are
+// fetched conditionally on the cached ETag. Any error keeps the old cache.
+func (r *S3KeyRepository) Reload(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "KeyRepository.Reload",
+		trace.WithAttributes(
+			attribute.String("hls.s3_bucket", r.bucket),
+			attribute.String("hls.s3_prefix", r.prefix),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, r.logger)
+
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	r.mu.RLock()
+	old := r.cache
+	r.mu.RUnlock()
+
+	objects, err := r.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	newCache := make(map[string]s3Key, len(objects))
+	fetched := 0
+	for _, obj := range objects {
+		name := strings.TrimPrefix(obj.Key, r.prefix)
+		// Apply same validation to listed objects; this also skips
+		// common prefixes, which end with the delimiter
+		if err := validateKeyName(name); err != nil {
+			log.Debug("skipping object with invalid key name", zap.String("object", obj.Key))
+			continue
+		}
+
+		cached, ok := old[name]
+		if ok && obj.ETag != "" && obj.ETag == cached.etag {
+			newCache[name] = cached
+			continue
+		}
+
+		key, err := r.fetch(ctx, obj.Key, cached)
+		if err != nil {
+			return err
+		}
+		newCache[name] = key
+		fetched++
+	}
+
+	r.mu.Lock()
+	r.cache = newCache
+	r.mu.Unlock()
+
+	span.SetAttributes(
+		attribute.Int("hls.key_count", len(newCache)),
+		attribute.Int("hls.keys_fetched", fetched),
+	)
+	log.Debug("key bucket loaded",
+		zap.String("bucket", r.bucket),
+		zap.String("prefix", r.prefix),
+		zap.Int("count", len(newCache)),
+		zap.Int("fetched", fetched),
+	)
+	return nil
+}
+
+// list returns the objects directly below the prefix
+func (r *S3KeyRepository) list(ctx context.Context) ([]minio.ObjectInfo, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var objects []minio.ObjectInfo
+	for obj := range r.client.Client.ListObjects(ctx, r.bucket, minio.ListObjectsOptions{Prefix: r.prefix}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("list bucket %s: %w", r.bucket, obj.Err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// fetch reads an object, sending If-None-Match when cached holds an ETag;
+// a 304 response returns cached unchanged
+func (r *S3KeyRepository) fetch(ctx context.Context, object string, cached s3Key) (s3Key, error) {
+	var opts minio.GetObjectOptions
+	if cached.etag != "" {
+		if err := opts.SetMatchETagExcept(cached.etag); err != nil {
+			return s3Key{}, err
+		}
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	body, info, _, err := r.client.GetObject(ctx, r.bucket, object, opts)
+	if err != nil {
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotModified {
+			return cached, nil
+		}
+		return s3Key{}, fmt.Errorf("get object %s: %w", object, err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxKeyObjectBytes+1))
+	if err != nil {
+		return s3Key{}, fmt.Errorf("read object %s: %w", object, err)
+	}
+	if len(data) > maxKeyObjectBytes {
+		return s3Key{}, fmt.Errorf("object %s: %w", object, errKeyObjectTooLarge)
+	}
+	return s3Key{data: data, etag: info.ETag}, nil
+}
+
+var errKeyObjectTooLarge = errors.New("key object too large")
+
+// withTimeout bounds a single bucket operation by the configured timeout
+func (r *S3KeyRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+)
+
+// fakeS3 implements the subset of the S3 API the repository uses:
+// ListObjectsV2 with a delimiter, conditional GetObject and HeadBucket
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	// listErr fails listings with this status when set
+	listErr int
+	// gets and notModified count object requests and 304 responses
+	gets        map[string]int
+	notModified int
+}
+
+func etagOf(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type fakeListResult struct {
+	XMLName        xml.Name         `xml:"ListBucketResult"`
+	Name           string           `xml:"Name"`
+	Prefix         string           `xml:"Prefix"`
+	Delimiter      string           `xml:"Delimiter"`
+	KeyCount       int              `xml:"KeyCount"`
+	MaxKeys        int              `xml:"MaxKeys"`
+	IsTruncated    bool             `xml:"IsTruncated"`
+	Contents       []fakeListObject `xml:"Contents"`
+	CommonPrefixes []fakeListPrefix `xml:"CommonPrefixes"`
+}
+
+type fakeListObject struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+}
+
+type fakeListPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, object, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodHead && object == "":
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && object == "":
+		if s.listErr != 0 {
+			w.WriteHeader(s.listErr)
+			return
+		}
+		s.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+	case r.Method == http.MethodGet:
+		s.gets[object]++
+		data, ok := s.objects[object]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		etag := `"` + etagOf(data) + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+		if r.Header.Get("If-None-Match") == etag {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	result := fakeListResult{Name: s.bucket, Prefix: prefix, Delimiter: delimiter, MaxKeys: 1000}
+	names := make([]string, 0, len(s.objects))
+	for name := range s.objects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	seen := make(map[string]bool)
+	for _, name := range names {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		if i := strings.Index(rest, delimiter); delimiter != "" && i >= 0 {
+			p := prefix + rest[:i+len(delimiter)]
+			if !seen[p] {
+				seen[p] = true
+				result.CommonPrefixes = append(result.CommonPrefixes, fakeListPrefix{Prefix: p})
+			}
+			continue
+		}
+		data := s.objects[name]
+		result.Contents = append(result.Contents, fakeListObject{
+			Key:          name,
+			LastModified: time.Unix(0, 0).UTC().Format(time.RFC3339),
+			ETag:         `"` + etagOf(data) + `"`,
+			Size:         len(data),
+		})
+	}
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func (s *fakeS3) put(name string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[name] = data
+}
+
+func (s *fakeS3) remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, name)
+}
+
+func (s *fakeS3) getCount(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gets[name]
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, configs.KeyStoreS3) {
+	t.Helper()
+	s3 := &fakeS3{bucket: "media", objects: make(map[string][]byte), gets: make(map[string]int)}
+	srv := httptest.NewServer(s3)
+	t.Cleanup(srv.Close)
+	return s3, configs.KeyStoreS3{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		Bucket:    "media",
+		Prefix:    "keys/",
+		AccessKey: "test-access-key",
+		SecretKey: "test-secret-key",
+		Timeout:   5 * time.Second,
+	}
+}
+
+func TestS3KeyRepository_Reload(t *testing.T) {
+	ctx := context.Background()
+	s3, cfg := newFakeS3(t)
+	s3.put("keys/stream1.key", []byte("0123456789abcdef"))
+	s3.put("keys/stream2.key", []byte("fedcba9876543210"))
+	s3.put("keys/readme.txt", []byte("not a key"))
+	s3.put("keys/nested/stream3.key", []byte("nested key bytes"))
+	s3.put("other/stream4.key", []byte("outside prefix.."))
+
+	repo, err := NewS3KeyRepository(cfg)
+	if err != nil {
+		t.Fatalf("NewS3KeyRepository() error = %v", err)
+	}
+
+	names := repo.List(ctx)
+	slices.Sort(names)
+	if want := []string{"stream1.key", "stream2.key"}; !slices.Equal(names, want) {
+		t.Fatalf("List() = %v, want %v", names, want)
+	}
+	key, err := repo.Get(ctx, "stream1.key")
+	if err != nil || string(key) != "0123456789abcdef" {
+		t.Fatalf("Get() = %q, %v", key, err)
+	}
+	// Returned keys are copies
+	key[0] = 'X'
+	if key, _ := repo.Get(ctx, "stream1.key"); string(key) != "0123456789abcdef" {
+		t.Errorf("cache mutated through Get() result: %q", key)
+	}
+	if _, err := repo.Get(ctx, "../stream1.key"); !errors.Is(err, apperrors.ErrInvalidKeyName) {
+		t.Errorf("Get(traversal) error = %v, want ErrInvalidKeyName", err)
+	}
+
+	// Unchanged objects are not fetched again; changed and new ones are,
+	// and removed ones disappear
+	s3.put("keys/stream2.key", []byte("0000000000000002"))
+	s3.put("keys/stream5.key", []byte("5555555555555555"))
+	s3.remove("keys/stream1.key")
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if n := s3.getCount("keys/stream2.key"); n != 2 {
+		t.Errorf("stream2.key fetched %d times, want 2", n)
+	}
+	if n := s3.getCount("keys/stream5.key"); n != 1 {
+		t.Errorf("stream5.key fetched %d times, want 1", n)
+	}
+	if _, err := repo.Get(ctx, "stream1.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get(removed) error = %v, want ErrKeyNotFound", err)
+	}
+	if key, _ := repo.Get(ctx, "stream2.key"); string(key) != "0000000000000002" {
+		t.Errorf("Get(changed) = %q", key)
+	}
+
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if n := s3.getCount("keys/stream2.key"); n != 2 {
+		t.Errorf("unchanged stream2.key fetched again: %d requests", n)
+	}
+	if err := repo.HealthCheck(ctx); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+}
+
+func TestS3KeyRepository_ConditionalFetch(t *testing.T) {
+	ctx := context.Background()
+	s3, cfg := newFakeS3(t)
+	s3.put("keys/stream1.key", []byte("0123456789abcdef"))
+
+	repo, err := NewS3KeyRepository(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Objects are fetched with If-None-Match on the cached ETag, so an
+	// unchanged one costs a 304 and keeps the cached bytes
+	repo.mu.Lock()
+	cached := repo.cache["stream1.key"]
+	repo.mu.Unlock()
+	got, err := repo.fetch(ctx, "keys/stream1.key", cached)
+	if err != nil {
+		t.Fatalf("fetch() error = %v", err)
+	}
+	if string(got.data) != "0123456789abcdef" || got.etag != cached.etag {
+		t.Errorf("fetch() = %+v, want cached %+v", got, cached)
+	}
+	s3.mu.Lock()
+	defer s3.mu.Unlock()
+	if s3.notModified != 1 {
+		t.Errorf("304 responses = %d, want 1", s3.notModified)
+	}
+}
+
+func TestS3KeyRepository_FailedReloadKeepsCache(t *testing.T) {
+	ctx := context.Background()
+	s3, cfg := newFakeS3(t)
+	s3.put("keys/stream1.key", []byte("0123456789abcdef"))
+
+	repo, err := NewS3KeyRepository(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s3.mu.Lock()
+	s3.listErr = http.StatusForbidden
+	s3.mu.Unlock()
+	if err := repo.Reload(ctx); err == nil {
+		t.Fatal("Reload() expected error when listing fails")
+	}
+	if key, err := repo.Get(ctx, "stream1.key"); err != nil || string(key) != "0123456789abcdef" {
+		t.Errorf("Get() after failed reload = %q, %v", key, err)
+	}
+
+	cfg.Bucket = "missing"
+	if _, err := NewS3KeyRepository(cfg); err == nil {
+		t.Error("NewS3KeyRepository() expected error for a missing bucket")
+	}
+}
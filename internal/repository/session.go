@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"hls-key-server-go/internal/apperrors"
+)
+
+// Session describes one active playback session of a subject
+type Session struct {
+	ID        string    `json:"id"`
+	Subject   string    `json:"subject"`
+	StartedAt time.Time `json:"started_at"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// SessionStore defines storage for active playback sessions.
+// Implementations must make Acquire atomic per subject so that limits hold
+// when the store is shared between replicas.
+type SessionStore interface {
+	// Acquire records a key fetch for sessionID. Sessions idle for longer than
+	// idle are expired first. A new session is admitted only while the subject
+	// has fewer than limit active sessions; otherwise ErrSessionLimitExceeded
+	// is returned. A limit of zero or less disables the check.
+	Acquire(ctx context.Context, subject, sessionID string, limit int, idle time.Duration) error
+	// List returns the active sessions of subject ordered by start time
+	List(ctx context.Context, subject string, idle time.Duration) ([]Session, error)
+	// Release ends a session immediately
+	Release(ctx context.Context, subject, sessionID string) error
+}
+
+// MemorySessionStore implements SessionStore in process memory
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]*Session
+	now      func() time.Time
+}
+
+// NewMemorySessionStore creates a new in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]map[string]*Session),
+		now:      time.Now,
+	}
+}
+
+// Acquire records activity for a session, admitting new sessions up to limit
+func (s *MemorySessionStore) Acquire(_ context.Context, subject, sessionID string, limit int, idle time.Duration) error {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := s.expire(subject, now, idle)
+
+	if sess, ok := active[sessionID]; ok {
+		sess.LastSeen = now
+		return nil
+	}
+
+	if limit > 0 && len(active) >= limit {
+		return apperrors.ErrSessionLimitExceeded
+	}
+
+	if active == nil {
+		active = make(map[string]*Session)
+		s.sessions[subject] = active
+	}
+	active[sessionID] = &Session{
+		ID:        sessionID,
+		Subject:   subject,
+		StartedAt: now,
+		LastSeen:  now,
+	}
+
+	return nil
+}
+
+// List returns the active sessions of subject
+func (s *MemorySessionStore) List(_ context.Context, subject string, idle time.Duration) ([]Session, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := s.expire(subject, now, idle)
+
+	sessions := make([]Session, 0, len(active))
+	for _, sess := range active {
+		sessions = append(sessions, *sess)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].StartedAt.Before(sessions[j].StartedAt) })
+
+	return sessions, nil
+}
+
+// Release ends a session
+func (s *MemorySessionStore) Release(_ context.Context, subject, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active, ok := s.sessions[subject]
+	if !ok {
+		return nil
+	}
+	delete(active, sessionID)
+	if len(active) == 0 {
+		delete(s.sessions, subject)
+	}
+	return nil
+}
+
+// Sweep removes idle sessions of every subject and returns the number of active sessions
+func (s *MemorySessionStore) Sweep(idle time.Duration) int {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0
+	for subject := range s.sessions {
+		total += len(s.expire(subject, now, idle))
+	}
+	return total
+}
+
+// expire drops idle sessions of subject and returns the remaining ones; callers must hold s.mu
+func (s *MemorySessionStore) expire(subject string, now time.Time, idle time.Duration) map[string]*Session {
+	active, ok := s.sessions[subject]
+	if !ok {
+		return nil
+	}
+
+	if idle > 0 {
+		for id, sess := range active {
+			if now.Sub(sess.LastSeen) > idle {
+				delete(active, id)
+			}
+		}
+	}
+
+	if len(active) == 0 {
+		delete(s.sessions, subject)
+		return nil
+	}
+	return active
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hls-key-server-go/internal/apperrors"
+)
+
+func TestMemorySessionStore_Acquire(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemorySessionStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	idle := time.Minute
+
+	if err := store.Acquire(ctx, "alice", "s1", 2, idle); err != nil {
+		t.Fatalf("Acquire(s1) error = %v", err)
+	}
+	if err := store.Acquire(ctx, "alice", "s2", 2, idle); err != nil {
+		t.Fatalf("Acquire(s2) error = %v", err)
+	}
+
+	// Existing sessions keep fetching keys at the limit
+	if err := store.Acquire(ctx, "alice", "s1", 2, idle); err != nil {
+		t.Errorf("Acquire(s1) again error = %v, want nil", err)
+	}
+
+	err := store.Acquire(ctx, "alice", "s3", 2, idle)
+	if !errors.Is(err, apperrors.ErrSessionLimitExceeded) {
+		t.Fatalf("Acquire(s3) error = %v, want ErrSessionLimitExceeded", err)
+	}
+
+	// Limits are per subject
+	if err := store.Acquire(ctx, "bob", "s3", 2, idle); err != nil {
+		t.Errorf("Acquire(bob/s3) error = %v, want nil", err)
+	}
+
+	// s2 goes idle and frees its slot; s1 stays active
+	now = now.Add(45 * time.Second)
+	_ = store.Acquire(ctx, "alice", "s1", 2, idle)
+	now = now.Add(30 * time.Second)
+	if err := store.Acquire(ctx, "alice", "s3", 2, idle); err != nil {
+		t.Errorf("Acquire(s3) after idle expiry error = %v, want nil", err)
+	}
+
+	sessions, err := store.List(ctx, "alice", idle)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "s1" || sessions[1].ID != "s3" {
+		t.Errorf("List() = %+v, want s1 and s3", sessions)
+	}
+}
+
+func TestMemorySessionStore_Unlimited(t *testing.T) {
+	store := NewMemorySessionStore()
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := store.Acquire(ctx, "alice", id, 0, time.Minute); err != nil {
+			t.Fatalf("Acquire(%s) error = %v, want nil with no limit", id, err)
+		}
+	}
+}
+
+func TestMemorySessionStore_ReleaseAndSweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemorySessionStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_ = store.Acquire(ctx, "alice", "s1", 1, time.Minute)
+	if err := store.Release(ctx, "alice", "s1"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := store.Acquire(ctx, "alice", "s2", 1, time.Minute); err != nil {
+		t.Errorf("Acquire(s2) after release error = %v, want nil", err)
+	}
+
+	_ = store.Acquire(ctx, "bob", "s1", 1, time.Minute)
+	if n := store.Sweep(time.Minute); n != 2 {
+		t.Errorf("Sweep() = %d, want 2 active sessions", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if n := store.Sweep(time.Minute); n != 0 {
+		t.Errorf("Sweep() = %d, want 0 after idle timeout", n)
+	}
+}
@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/tracing"
+)
+
+// vaultRetryInterval is the wait before retrying a failed token renewal
+const vaultRetryInterval = 10 * time.Second
+
+// errSecretDeleted reports a listed secret whose latest version is deleted
+// or destroyed. Only the KV read maps a 404 to it: a 404 from Transit means
+// a wrong mount or key and must fail the reload.
+var errSecretDeleted = errors.New("vault: secret deleted")
+
+// vaultKey is a cached key and the KV version it was read at
+type vaultKey struct {
+	data    []byte
+	version int
+}
+
+// VaultKeyRepository implements KeyRepository over a HashiCorp Vault KV v2
+// path holding one secret per key, named like a key file. The key is the
+// base64 value of the configured field or, with Transit, a ciphertext
+// Vault decrypts on each change. Keys only ever exist in memory; Reload
+// re-reads every secret but decrypts only new versions.
+type VaultKeyRepository struct {
+	client  *vaultClient
+	mount   string
+	path    string
+	field   string
+	transit configs.KeyStoreTransit
+	logger  *zap.Logger
+
+	// reloadMu serializes reloads so each one starts from the last cache
+	reloadMu sync.Mutex
+
+	mu    sync.RWMutex
+	cache map[string]vaultKey
+}
+
+// VaultKeyOption configures optional VaultKeyRepository behavior
+type VaultKeyOption func(*VaultKeyRepository)
+
+// WithVaultLogger sets the logger for key loading, lookups and token renewal
+func WithVaultLogger(logger *zap.Logger) VaultKeyOption {
+	return func(r *VaultKeyRepository) {
+		r.logger = logger
+	}
+}
+
+// WithVaultHTTPClient sets the client used to reach Vault
+func WithVaultHTTPClient(client *http.Client) VaultKeyOption {
+	return func(r *VaultKeyRepository) {
+		r.client.http = client
+	}
+}
+
+// NewVaultKeyRepository authenticates to Vault and loads the keys under
+// cfg.Path. Call Run to keep the token renewed.
+func NewVaultKeyRepository(cfg configs.KeyStoreVault, opts ...VaultKeyOption) (*VaultKeyRepository, error) {
+	u, err := url.Parse(cfg.Address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid vault address %q", cfg.Address)
+	}
+	if cfg.Mount == "" || cfg.Path == "" || cfg.Field == "" {
+		return nil, fmt.Errorf("vault mount, path and field cannot be empty")
+	}
+
+	repo := &VaultKeyRepository{
+		client:  newVaultClient(cfg, &http.Client{Timeout: cfg.Timeout}),
+		mount:   strings.Trim(cfg.Mount, "/"),
+		path:    strings.Trim(cfg.Path, "/"),
+		field:   cfg.Field,
+		transit: cfg.Transit,
+		logger:  zap.NewNop(),
+		cache:   make(map[string]vaultKey),
+	}
+	repo.transit.Mount = strings.Trim(repo.transit.Mount, "/")
+	for _, opt := range opts {
+		opt(repo)
+	}
+
+	ctx := context.Background()
+	if err := repo.client.login(ctx); err != nil {
+		return nil, err
+	}
+	if err := repo.Reload(ctx); err != nil {
+		return nil, fmt.Errorf("initial key load: %w", err)
+	}
+	return repo, nil
+}
+
+// Get retrieves a key by name from cache
+func (r *VaultKeyRepository) Get(ctx context.Context, name string) (_ []byte, err error) {
+	_, span := tracing.Tracer().Start(ctx, "KeyRepository.Get",
+		trace.WithAttributes(attribute.String("hls.key", name)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateKeyName(name); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, exists := r.cache[name]
+	if !exists {
+		logger.FromContext(ctx, r.logger).Debug("key not in cache", zap.String("key_name", name))
+		return nil, apperrors.ErrKeyNotFound
+	}
+
+	// Return a copy to prevent cache mutation
+	return append([]byte(nil), key.data...), nil
+}
+
+// List returns all available key names
+func (r *VaultKeyRepository) List(_ context.Context) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.cache))
+	for name := range r.cache {
+		names = append(names, name)
+	}
+	return names
+}
+
+// HealthCheck verifies Vault is reachable and still accepts the token
+func (r *VaultKeyRepository) HealthCheck(ctx context.Context) error {
+	if _, err := r.client.request(ctx, http.MethodGet, "auth/token/lookup-self", nil); err != nil {
+		return fmt.Errorf("vault token lookup: %w", err)
+	}
+	return nil
+}
+
+// Reload lists the KV path and swaps in the listed keys. Secrets whose
+// version matches the cache are not decrypted again. Any error keeps the
+// old cache.
+func (r *VaultKeyRepository) Reload(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "KeyRepository.Reload",
+		trace.WithAttributes(attribute.String("hls.vault_path", r.mount+"/"+r.path)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, r.logger)
+
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	r.mu.RLock()
+	old := r.cache
+	r.mu.RUnlock()
+
+	names, err := r.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	newCache := make(map[string]vaultKey, len(names))
+	decrypted := 0
+	for _, name := range names {
+		// Apply same validation to listed secrets; this also skips
+		// sub-paths, which end with a slash
+		if err := validateKeyName(name); err != nil {
+			log.Debug("skipping secret with invalid key name", zap.String("secret", name))
+			continue
+		}
+
+		key, changed, err := r.read(ctx, name, old[name])
+		if errors.Is(err, errSecretDeleted) {
+			log.Debug("skipping deleted secret", zap.String("secret", name))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		newCache[name] = key
+		if changed {
+			decrypted++
+		}
+	}
+
+	r.mu.Lock()
+	r.cache = newCache
+	r.mu.Unlock()
+
+	span.SetAttributes(attribute.Int("hls.key_count", len(newCache)))
+	log.Debug("vault keys loaded",
+		zap.String("path", r.mount+"/"+r.path),
+		zap.Int("count", len(newCache)),
+		zap.Int("changed", decrypted),
+	)
+	return nil
+}
+
+// list returns the secret names under the path
+func (r *VaultKeyRepository) list(ctx context.Context) ([]string, error) {
+	resp, err := r.client.request(ctx, http.MethodGet, r.mount+"/metadata/"+r.path+"?list=true", nil)
+	if errors.Is(err, errVaultNotFound) {
+		// Vault answers 404 for a path with no secrets
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list %s/%s: %w", r.mount, r.path, err)
+	}
+
+	var data struct {
+		Keys []string `json:"keys"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		return nil, fmt.Errorf("decode key list: %w", err)
+	}
+	return data.Keys, nil
+}
+
+// read fetches the latest version of a key secret, reusing cached when the
+// version is unchanged, and reports whether it decoded a new version
+func (r *VaultKeyRepository) read(ctx context.Context, name string, cached vaultKey) (vaultKey, bool, error) {
+	resp, err := r.client.request(ctx, http.MethodGet, r.mount+"/data/"+r.path+"/"+url.PathEscape(name), nil)
+	if errors.Is(err, errVaultNotFound) {
+		return vaultKey{}, false, errSecretDeleted
+	}
+	if err != nil {
+		return vaultKey{}, false, fmt.Errorf("read secret %s: %w", name, err)
+	}
+
+	var secret struct {
+		Data     map[string]any `json:"data"`
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Data, &secret); err != nil {
+		return vaultKey{}, false, fmt.Errorf("decode secret %s: %w", name, err)
+	}
+	if cached.data != nil && secret.Metadata.Version == cached.version {
+		return cached, false, nil
+	}
+
+	value, ok := secret.Data[r.field].(string)
+	if !ok || value == "" {
+		return vaultKey{}, false, fmt.Errorf("secret %s has no string field %q", name, r.field)
+	}
+	data, err := r.decode(ctx, value)
+	if err != nil {
+		return vaultKey{}, false, fmt.Errorf("secret %s: %w", name, err)
+	}
+	return vaultKey{data: data, version: secret.Metadata.Version}, true, nil
+}
+
+// decode returns the key held in a secret field: base64, or a Transit
+// ciphertext decrypted by Vault when a Transit key is configured
+func (r *VaultKeyRepository) decode(ctx context.Context, value string) ([]byte, error) {
+	if r.transit.Key == "" {
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("decode base64 key: %w", err)
+		}
+		return data, nil
+	}
+
+	path := r.transit.Mount + "/decrypt/" + url.PathEscape(r.transit.Key)
+	resp, err := r.client.request(ctx, http.MethodPost, path, map[string]string{"ciphertext": value})
+	if err != nil {
+		return nil, fmt.Errorf("transit decrypt: %w", err)
+	}
+	var out struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := json.Unmarshal(resp.Data, &out); err != nil {
+		return nil, fmt.Errorf("decode transit response: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(out.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("decode transit plaintext: %w", err)
+	}
+	return data, nil
+}
+
+// Run keeps the Vault token alive until ctx is done: renewable tokens are
+// renewed at two thirds of their TTL, and AppRole logs in again when its
+// token cannot be renewed. A static token without a TTL needs nothing.
+func (r *VaultKeyRepository) Run(ctx context.Context) {
+	approle := r.client.cfg.Auth == "approle"
+	for first := true; ; first = false {
+		ttl, renewable := r.client.lease()
+		if ttl <= 0 {
+			if first {
+				r.logger.Debug("vault token does not expire, not renewing")
+			} else {
+				r.logger.Warn("vault token reached its maximum TTL; keys stop loading")
+			}
+			return
+		}
+		if !renewable && !approle {
+			r.logger.Warn("vault token is not renewable; keys stop loading when it expires",
+				zap.Duration("ttl", ttl),
+			)
+			return
+		}
+
+		timer := time.NewTimer(ttl * 2 / 3)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		for {
+			err := r.refreshToken(ctx, ttl, renewable, approle)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			r.logger.Warn("vault token renewal failed", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(vaultRetryInterval):
+			}
+		}
+	}
+}
+
+// refreshToken renews the token. AppRole logs in again instead when the
+// renewal fails or is capped below ttl by the token's maximum TTL.
+func (r *VaultKeyRepository) refreshToken(ctx context.Context, ttl time.Duration, renewable, approle bool) error {
+	if renewable {
+		err := r.client.renew(ctx)
+		if !approle {
+			return err
+		}
+		if err == nil {
+			if renewed, _ := r.client.lease(); renewed >= ttl {
+				return nil
+			}
+		}
+		r.logger.Debug("vault token renewal insufficient, logging in again", zap.Error(err))
+	}
+	return r.client.login(ctx)
+}
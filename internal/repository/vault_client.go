@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"hls-key-server-go/internal/configs"
+)
+
+// maxVaultResponseBytes bounds a Vault response body
+const maxVaultResponseBytes = 4 << 20
+
+// errVaultNotFound reports a 404 from Vault: a missing path, or a KV
+// secret whose latest version is deleted
+var errVaultNotFound = errors.New("vault: not found")
+
+// vaultClient calls the subset of the Vault HTTP API used for keys and
+// holds the current auth token
+type vaultClient struct {
+	addr      string
+	namespace string
+	http      *http.Client
+	cfg       configs.KeyStoreVault
+
+	mu        sync.Mutex
+	token     string
+	ttl       time.Duration
+	renewable bool
+}
+
+// vaultAuthInfo is the auth block of login and renew responses
+type vaultAuthInfo struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// vaultResponse is the envelope of every Vault response
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Auth   *vaultAuthInfo  `json:"auth"`
+	Errors []string        `json:"errors"`
+}
+
+func newVaultClient(cfg configs.KeyStoreVault, client *http.Client) *vaultClient {
+	return &vaultClient{
+		addr:      strings.TrimSuffix(cfg.Address, "/"),
+		namespace: cfg.Namespace,
+		http:      client,
+		cfg:       cfg,
+	}
+}
+
+// login obtains a token: AppRole logs in with the role and secret IDs; a
+// static token is looked up to learn its TTL and check it is valid
+func (c *vaultClient) login(ctx context.Context) error {
+	if c.cfg.Auth == "approle" {
+		body := map[string]string{"role_id": c.cfg.RoleID, "secret_id": c.cfg.SecretID}
+		resp, err := c.do(ctx, http.MethodPost, "auth/"+c.cfg.AppRoleMount+"/login", body, "")
+		if err != nil {
+			return fmt.Errorf("approle login: %w", err)
+		}
+		if resp.Auth == nil || resp.Auth.ClientToken == "" {
+			return errors.New("approle login: no token in response")
+		}
+		c.setAuth(resp.Auth)
+		return nil
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, "auth/token/lookup-self", nil, c.cfg.Token)
+	if err != nil {
+		return fmt.Errorf("look up token: %w", err)
+	}
+	var info struct {
+		TTL       int  `json:"ttl"`
+		Renewable bool `json:"renewable"`
+	}
+	if err := json.Unmarshal(resp.Data, &info); err != nil {
+		return fmt.Errorf("decode token lookup: %w", err)
+	}
+	c.setAuth(&vaultAuthInfo{ClientToken: c.cfg.Token, LeaseDuration: info.TTL, Renewable: info.Renewable})
+	return nil
+}
+
+// renew extends the current token's lease
+func (c *vaultClient) renew(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodPost, "auth/token/renew-self", struct{}{}, c.currentToken())
+	if err != nil {
+		return fmt.Errorf("renew token: %w", err)
+	}
+	if resp.Auth == nil {
+		return errors.New("renew token: no auth in response")
+	}
+	c.setAuth(resp.Auth)
+	return nil
+}
+
+func (c *vaultClient) setAuth(auth *vaultAuthInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = auth.ClientToken
+	c.ttl = time.Duration(auth.LeaseDuration) * time.Second
+	c.renewable = auth.Renewable
+}
+
+func (c *vaultClient) currentToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// lease returns the token's TTL as of its last login or renewal and
+// whether it can be renewed; a zero TTL never expires
+func (c *vaultClient) lease() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttl, c.renewable
+}
+
+// request calls path with the current token. An AppRole token that Vault
+// no longer accepts is replaced by logging in again, once.
+func (c *vaultClient) request(ctx context.Context, method, path string, body any) (*vaultResponse, error) {
+	resp, err := c.do(ctx, method, path, body, c.currentToken())
+	var statusErr *vaultStatusError
+	if c.cfg.Auth == "approle" && errors.As(err, &statusErr) && statusErr.status == http.StatusForbidden {
+		if err := c.login(ctx); err != nil {
+			return nil, err
+		}
+		return c.do(ctx, method, path, body, c.currentToken())
+	}
+	return resp, err
+}
+
+// vaultStatusError is a non-2xx Vault response
+type vaultStatusError struct {
+	status int
+	errors []string
+}
+
+func (e *vaultStatusError) Error() string {
+	if len(e.errors) == 0 {
+		return fmt.Sprintf("vault: status %d", e.status)
+	}
+	return fmt.Sprintf("vault: status %d: %s", e.status, strings.Join(e.errors, "; "))
+}
+
+func (c *vaultClient) do(ctx context.Context, method, path string, body any, token string) (*vaultResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+"/v1/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxVaultResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read vault response: %w", err)
+	}
+	if len(data) > maxVaultResponseBytes {
+		return nil, fmt.Errorf("vault response exceeds %d bytes", maxVaultResponseBytes)
+	}
+
+	var out vaultResponse
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &out); err != nil && resp.StatusCode < 300 {
+			return nil, fmt.Errorf("decode vault response: %w", err)
+		}
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errVaultNotFound
+	case resp.StatusCode >= 300:
+		return nil, &vaultStatusError{status: resp.StatusCode, errors: out.Errors}
+	}
+	return &out, nil
+}
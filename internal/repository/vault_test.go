@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+)
+
+// fakeVault implements the subset of the Vault API the repository uses:
+// token lookup and renewal, AppRole login, KV v2 list and read under
+// secret/hls/keys, and Transit decrypt with the key "hls"
+type fakeVault struct {
+	mu sync.Mutex
+	// secrets maps a secret name to its versions; a nil latest version is
+	// a deleted one
+	secrets map[string][]map[string]any
+	tokens  map[string]bool
+	// ciphertexts maps Transit ciphertexts to base64 plaintexts
+	ciphertexts map[string]string
+	leaseTTL    int
+	logins      int
+	renewals    int
+	decrypts    int
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, string) {
+	t.Helper()
+	v := &fakeVault{
+		secrets:     make(map[string][]map[string]any),
+		tokens:      map[string]bool{"root-token": true},
+		ciphertexts: make(map[string]string),
+		leaseTTL:    3600,
+	}
+	srv := httptest.NewServer(v)
+	t.Cleanup(srv.Close)
+	return v, srv.URL
+}
+
+func (v *fakeVault) write(name string, data map[string]any) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.secrets[name] = append(v.secrets[name], data)
+}
+
+// encrypt registers a Transit ciphertext for key
+func (v *fakeVault) encrypt(key []byte) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	ct := fmt.Sprintf("vault:v1:%d", len(v.ciphertexts))
+	v.ciphertexts[ct] = base64.StdEncoding.EncodeToString(key)
+	return ct
+}
+
+func (v *fakeVault) revokeAll() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tokens = make(map[string]bool)
+}
+
+func (v *fakeVault) counts() (logins, renewals, decrypts int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.logins, v.renewals, v.decrypts
+}
+
+func (v *fakeVault) reply(w http.ResponseWriter, status int, body map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (v *fakeVault) issue() map[string]any {
+	v.logins++
+	token := fmt.Sprintf("approle-token-%d", v.logins)
+	v.tokens[token] = true
+	return map[string]any{"client_token": token, "lease_duration": v.leaseTTL, "renewable": true}
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if path == "auth/approle/login" {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "hls-role" || body["secret_id"] != "hls-secret" {
+			v.reply(w, http.StatusBadRequest, map[string]any{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		v.reply(w, http.StatusOK, map[string]any{"auth": v.issue()})
+		return
+	}
+
+	token := r.Header.Get("X-Vault-Token")
+	if !v.tokens[token] {
+		v.reply(w, http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+
+	switch {
+	case path == "auth/token/lookup-self":
+		ttl := 0
+		if token != "root-token" {
+			ttl = v.leaseTTL
+		}
+		v.reply(w, http.StatusOK, map[string]any{"data": map[string]any{"ttl": ttl, "renewable": ttl > 0}})
+	case path == "auth/token/renew-self":
+		v.renewals++
+		v.reply(w, http.StatusOK, map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": v.leaseTTL, "renewable": true}})
+	case path == "secret/metadata/hls/keys" && r.URL.Query().Get("list") == "true":
+		names := make([]string, 0, len(v.secrets)+1)
+		for name := range v.secrets {
+			names = append(names, name)
+		}
+		names = append(names, "archive/")
+		slices.Sort(names)
+		v.reply(w, http.StatusOK, map[string]any{"data": map[string]any{"keys": names}})
+	case strings.HasPrefix(path, "secret/data/hls/keys/"):
+		versions := v.secrets[strings.TrimPrefix(path, "secret/data/hls/keys/")]
+		if len(versions) == 0 || versions[len(versions)-1] == nil {
+			v.reply(w, http.StatusNotFound, map[string]any{"errors": []string{}})
+			return
+		}
+		v.reply(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"data":     versions[len(versions)-1],
+			"metadata": map[string]any{"version": len(versions)},
+		}})
+	case path == "transit/decrypt/hls" && r.Method == http.MethodPost:
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		plaintext, ok := v.ciphertexts[body["ciphertext"]]
+		if !ok {
+			v.reply(w, http.StatusBadRequest, map[string]any{"errors": []string{"invalid ciphertext"}})
+			return
+		}
+		v.decrypts++
+		v.reply(w, http.StatusOK, map[string]any{"data": map[string]any{"plaintext": plaintext}})
+	default:
+		v.reply(w, http.StatusNotFound, map[string]any{"errors": []string{}})
+	}
+}
+
+func vaultConfig(addr string) configs.KeyStoreVault {
+	return configs.KeyStoreVault{
+		Address:      addr,
+		Mount:        "secret",
+		Path:         "/hls/keys/",
+		Field:        "key",
+		Auth:         "token",
+		Token:        "root-token",
+		AppRoleMount: "approle",
+		Transit:      configs.KeyStoreTransit{Mount: "transit"},
+		Timeout:      5 * time.Second,
+	}
+}
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestVaultKeyRepository_KV(t *testing.T) {
+	ctx := context.Background()
+	vault, addr := newFakeVault(t)
+	vault.write("stream1.key", map[string]any{"key": b64("0123456789abcdef")})
+	vault.write("stream2.key", map[string]any{"key": b64("fedcba9876543210")})
+	vault.write("stream3.key", map[string]any{"key": b64("deleted.........")})
+	vault.write("stream3.key", nil)
+	vault.write("notes.txt", map[string]any{"key": b64("not a key")})
+
+	repo, err := NewVaultKeyRepository(vaultConfig(addr))
+	if err != nil {
+		t.Fatalf("NewVaultKeyRepository() error = %v", err)
+	}
+
+	names := repo.List(ctx)
+	slices.Sort(names)
+	if want := []string{"stream1.key", "stream2.key"}; !slices.Equal(names, want) {
+		t.Fatalf("List() = %v, want %v", names, want)
+	}
+	key, err := repo.Get(ctx, "stream1.key")
+	if err != nil || string(key) != "0123456789abcdef" {
+		t.Fatalf("Get() = %q, %v", key, err)
+	}
+	if _, err := repo.Get(ctx, "stream3.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get(deleted) error = %v, want ErrKeyNotFound", err)
+	}
+	if err := repo.HealthCheck(ctx); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+
+	// A secret without the field fails the reload and keeps the cache
+	vault.write("stream2.key", map[string]any{"other": "x"})
+	if err := repo.Reload(ctx); err == nil {
+		t.Fatal("Reload() expected error for a secret without the key field")
+	}
+	if key, _ := repo.Get(ctx, "stream2.key"); string(key) != "fedcba9876543210" {
+		t.Errorf("Get() after failed reload = %q", key)
+	}
+
+	vault.write("stream2.key", map[string]any{"key": b64("0000000000000002")})
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if key, _ := repo.Get(ctx, "stream2.key"); string(key) != "0000000000000002" {
+		t.Errorf("Get(new version) = %q", key)
+	}
+
+	vault.revokeAll()
+	if err := repo.HealthCheck(ctx); err == nil {
+		t.Error("HealthCheck() expected error for a revoked token")
+	}
+	cfg := vaultConfig(addr)
+	cfg.Token = "unknown"
+	if _, err := NewVaultKeyRepository(cfg); err == nil {
+		t.Error("NewVaultKeyRepository() expected error for an unknown token")
+	}
+}
+
+func TestVaultKeyRepository_AppRoleTransit(t *testing.T) {
+	ctx := context.Background()
+	vault, addr := newFakeVault(t)
+	vault.write("stream1.key", map[string]any{"key": vault.encrypt([]byte("0123456789abcdef"))})
+	vault.write("stream2.key", map[string]any{"key": vault.encrypt([]byte("fedcba9876543210"))})
+
+	cfg := vaultConfig(addr)
+	cfg.Auth, cfg.Token, cfg.RoleID, cfg.SecretID = "approle", "", "hls-role", "hls-secret"
+	cfg.Transit.Key = "hls"
+	repo, err := NewVaultKeyRepository(cfg)
+	if err != nil {
+		t.Fatalf("NewVaultKeyRepository() error = %v", err)
+	}
+	if key, err := repo.Get(ctx, "stream2.key"); err != nil || string(key) != "fedcba9876543210" {
+		t.Fatalf("Get() = %q, %v", key, err)
+	}
+
+	// Unchanged versions are not decrypted again
+	vault.write("stream1.key", map[string]any{"key": vault.encrypt([]byte("1111111111111111"))})
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if _, _, decrypts := vault.counts(); decrypts != 3 {
+		t.Errorf("decrypts = %d, want 3", decrypts)
+	}
+	if key, _ := repo.Get(ctx, "stream1.key"); string(key) != "1111111111111111" {
+		t.Errorf("Get(new version) = %q", key)
+	}
+
+	// A token Vault no longer accepts is replaced by a new login
+	vault.revokeAll()
+	if err := repo.Reload(ctx); err != nil {
+		t.Fatalf("Reload() after revocation error = %v", err)
+	}
+	if logins, _, _ := vault.counts(); logins != 2 {
+		t.Errorf("logins = %d, want 2", logins)
+	}
+
+	cfg.SecretID = "wrong"
+	if _, err := NewVaultKeyRepository(cfg); err == nil {
+		t.Error("NewVaultKeyRepository() expected error for a wrong secret ID")
+	}
+}
+
+func TestVaultKeyRepository_WrongTransitMount(t *testing.T) {
+	vault, addr := newFakeVault(t)
+	vault.write("stream1.key", map[string]any{"key": vault.encrypt([]byte("0123456789abcdef"))})
+
+	// Vault answers 404 for a wrong Transit mount; that must not read as a
+	// deleted secret and load an empty key set
+	cfg := vaultConfig(addr)
+	cfg.Transit.Mount, cfg.Transit.Key = "transit-typo", "hls"
+	if _, err := NewVaultKeyRepository(cfg); err == nil {
+		t.Fatal("NewVaultKeyRepository() expected error for a wrong transit mount")
+	}
+
+	cfg.Transit.Mount = "transit"
+	repo, err := NewVaultKeyRepository(cfg)
+	if err != nil {
+		t.Fatalf("NewVaultKeyRepository() error = %v", err)
+	}
+	repo.transit.Mount = "transit-typo"
+	vault.write("stream1.key", map[string]any{"key": vault.encrypt([]byte("1111111111111111"))})
+	if err := repo.Reload(context.Background()); err == nil {
+		t.Fatal("Reload() expected error for a wrong transit mount")
+	}
+	if key, _ := repo.Get(context.Background(), "stream1.key"); string(key) != "0123456789abcdef" {
+		t.Errorf("Get() after failed reload = %q", key)
+	}
+}
+
+func TestVaultKeyRepository_RunRenewsToken(t *testing.T) {
+	vault, addr := newFakeVault(t)
+	vault.leaseTTL = 1
+	vault.write("stream1.key", map[string]any{"key": b64("0123456789abcdef")})
+
+	cfg := vaultConfig(addr)
+	cfg.Auth, cfg.Token, cfg.RoleID, cfg.SecretID = "approle", "", "hls-role", "hls-secret"
+	repo, err := NewVaultKeyRepository(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		repo.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, renewals, _ := vault.counts(); renewals >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("token was not renewed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if err := repo.HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck() after renewals error = %v", err)
+	}
+}
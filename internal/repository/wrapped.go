@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/tracing"
+)
+
+// KEKProvider unwraps content keys stored encrypted under a key-encryption
+// key (KEK) that never leaves the provider
+type KEKProvider interface {
+	// Unwrap returns the content key wrapped in wrapped
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// WrappedKeyRepository serves content keys stored wrapped under a KEK in
+// another repository. By default every key is unwrapped on Reload and
+// served from memory; WithUnwrapOnGet unwraps on every Get instead, so
+// plaintext keys are never cached.
+type WrappedKeyRepository struct {
+	store       KeyRepository
+	kek         KEKProvider
+	unwrapOnGet bool
+	logger      *zap.Logger
+
+	// reloadMu serializes reloads so caches are swapped in order
+	reloadMu sync.Mutex
+
+	mu    sync.RWMutex
+	cache map[string][]byte
+}
+
+// WrappedKeyOption configures optional WrappedKeyRepository behavior
+type WrappedKeyOption func(*WrappedKeyRepository)
+
+// WithWrappedLogger sets the logger for unwrapping
+func WithWrappedLogger(logger *zap.Logger) WrappedKeyOption {
+	return func(r *WrappedKeyRepository) {
+		r.logger = logger
+	}
+}
+
+// WithUnwrapOnGet unwraps keys on every Get rather than on Reload
+func WithUnwrapOnGet() WrappedKeyOption {
+	return func(r *WrappedKeyRepository) {
+		r.unwrapOnGet = true
+	}
+}
+
+// NewWrappedKeyRepository serves the keys of store, which must already be
+// loaded, unwrapped with kek
+func NewWrappedKeyRepository(store KeyRepository, kek KEKProvider, opts ...WrappedKeyOption) (*WrappedKeyRepository, error) {
+	r := &WrappedKeyRepository{
+		store:  store,
+		kek:    kek,
+		logger: zap.NewNop(),
+		cache:  make(map[string][]byte),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if !r.unwrapOnGet {
+		if err := r.unwrapAll(context.Background()); err != nil {
+			return nil, fmt.Errorf("initial key unwrap: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// Get returns the unwrapped key
+func (r *WrappedKeyRepository) Get(ctx context.Context, name string) (_ []byte, err error) {
+	if !r.unwrapOnGet {
+		if err := validateKeyName(name); err != nil {
+			return nil, err
+		}
+
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		key, exists := r.cache[name]
+		if !exists {
+			logger.FromContext(ctx, r.logger).Debug("key not in cache", zap.String("key_name", name))
+			return nil, apperrors.ErrKeyNotFound
+		}
+		return append([]byte(nil), key...), nil
+	}
+
+	wrapped, err := r.store.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return r.unwrap(ctx, name, wrapped)
+}
+
+// List returns all available key names
+func (r *WrappedKeyRepository) List(ctx context.Context) []string {
+	if r.unwrapOnGet {
+		return r.store.List(ctx)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.cache))
+	for name := range r.cache {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Reload reloads the store and, unless unwrapping on Get, unwraps every
+// key. A key that fails to unwrap fails the reload and keeps the previous
+// keys. Unreadable key files in the store do not stop the unwrap; their
+// *UnreadableKeysError is returned afterwards.
+func (r *WrappedKeyRepository) Reload(ctx context.Context) error {
+	err := r.store.Reload(ctx)
+	var unreadable *UnreadableKeysError
+	if err != nil && !errors.As(err, &unreadable) {
+		return err
+	}
+	if !r.unwrapOnGet {
+		if uerr := r.unwrapAll(ctx); uerr != nil {
+			return uerr
+		}
+	}
+	return err
+}
+
+// HealthCheck checks the store when it supports health checks
+func (r *WrappedKeyRepository) HealthCheck(ctx context.Context) error {
+	if hc, ok := r.store.(HealthChecker); ok {
+		return hc.HealthCheck(ctx)
+	}
+	return nil
+}
+
+func (r *WrappedKeyRepository) unwrapAll(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "KeyRepository.UnwrapAll")
+	defer func() { tracing.End(span, err) }()
+
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	names := r.store.List(ctx)
+	newCache := make(map[string][]byte, len(names))
+	for _, name := range names {
+		wrapped, err := r.store.Get(ctx, name)
+		if err != nil {
+			return fmt.Errorf("get wrapped key %s: %w", name, err)
+		}
+		key, err := r.unwrap(ctx, name, wrapped)
+		if err != nil {
+			return err
+		}
+		newCache[name] = key
+	}
+
+	r.mu.Lock()
+	r.cache = newCache
+	r.mu.Unlock()
+
+	span.SetAttributes(attribute.Int("hls.key_count", len(newCache)))
+	logger.FromContext(ctx, r.logger).Debug("keys unwrapped", zap.Int("count", len(newCache)))
+	return nil
+}
+
+func (r *WrappedKeyRepository) unwrap(ctx context.Context, name string, wrapped []byte) (_ []byte, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "KEKProvider.Unwrap",
+		trace.WithAttributes(attribute.String("hls.key", name)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	key, err := r.kek.Unwrap(ctx, wrapped)
+	if err != nil {
+		metrics.KEKUnwraps.WithLabelValues("failure").Inc()
+		return nil, fmt.Errorf("unwrap key %s: %w", name, err)
+	}
+	metrics.KEKUnwraps.WithLabelValues("success").Inc()
+	return key, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"hls-key-server-go/internal/apperrors"
+)
+
+// fakeKEK "wraps" keys by prefixing a marker and XORing with 0x5a
+type fakeKEK struct {
+	mu    sync.Mutex
+	calls int
+}
+
+var fakeWrapMarker = []byte("wrapped:")
+
+func fakeWrap(key []byte) []byte {
+	out := append([]byte(nil), fakeWrapMarker...)
+	for _, b := range key {
+		out = append(out, b^0x5a)
+	}
+	return out
+}
+
+func (k *fakeKEK) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	k.mu.Lock()
+	k.calls++
+	k.mu.Unlock()
+
+	body, ok := bytes.CutPrefix(wrapped, fakeWrapMarker)
+	if !ok {
+		return nil, errors.New("integrity check failed")
+	}
+	key := make([]byte, len(body))
+	for i, b := range body {
+		key[i] = b ^ 0x5a
+	}
+	return key, nil
+}
+
+func (k *fakeKEK) count() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.calls
+}
+
+func newWrappedStore(t *testing.T, keys map[string][]byte) (string, *FileKeyRepository) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, key := range keys {
+		if err := os.WriteFile(filepath.Join(dir, name), fakeWrap(key), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := NewFileKeyRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, store
+}
+
+func TestWrappedKeyRepository_UnwrapOnReload(t *testing.T) {
+	ctx := context.Background()
+	dir, store := newWrappedStore(t, map[string][]byte{
+		"stream1.key": []byte("0123456789abcdef"),
+		"stream2.key": []byte("fedcba9876543210"),
+	})
+	kek := &fakeKEK{}
+	repo, err := NewWrappedKeyRepository(store, kek)
+	if err != nil {
+		t.Fatalf("NewWrappedKeyRepository() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		key, err := repo.Get(ctx, "stream1.key")
+		if err != nil || string(key) != "0123456789abcdef" {
+			t.Fatalf("Get() = %q, %v", key, err)
+		}
+	}
+	if n := kek.count(); n != 2 {
+		t.Errorf("unwraps = %d, want 2 (once per key on load)", n)
+	}
+	if got := len(repo.List(ctx)); got != 2 {
+		t.Errorf("List() returned %d keys, want 2", got)
+	}
+	if _, err := repo.Get(ctx, "missing.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrKeyNotFound", err)
+	}
+
+	// A key that fails to unwrap fails the reload and keeps the old keys
+	if err := os.WriteFile(filepath.Join(dir, "stream3.key"), []byte("plaintext-key..."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Reload(ctx); err == nil {
+		t.Fatal("Reload() expected error for a key that does not unwrap")
+	}
+	if _, err := repo.Get(ctx, "stream3.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Errorf("Get(unwrappable) error = %v, want ErrKeyNotFound", err)
+	}
+	if key, _ := repo.Get(ctx, "stream2.key"); string(key) != "fedcba9876543210" {
+		t.Errorf("Get() after failed reload = %q", key)
+	}
+
+	if _, err := NewWrappedKeyRepository(store, kek); err == nil {
+		t.Error("NewWrappedKeyRepository() expected error when a stored key does not unwrap")
+	}
+}
+
+func TestWrappedKeyRepository_ReloadWithUnreadableFiles(t *testing.T) {
+	ctx := context.Background()
+	dir, store := newWrappedStore(t, map[string][]byte{"stream1.key": []byte("0123456789abcdef")})
+	repo, err := NewWrappedKeyRepository(store, &fakeKEK{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An unreadable file does not stop new keys from being unwrapped
+	if err := os.Symlink(t.TempDir(), filepath.Join(dir, "broken.key")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stream2.key"), fakeWrap([]byte("fedcba9876543210")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var unreadable *UnreadableKeysError
+	if err := repo.Reload(ctx); !errors.As(err, &unreadable) {
+		t.Fatalf("Reload() error = %v, want *UnreadableKeysError", err)
+	}
+	if key, err := repo.Get(ctx, "stream2.key"); err != nil || string(key) != "fedcba9876543210" {
+		t.Errorf("Get(stream2.key) after partial reload = %q, %v", key, err)
+	}
+}
+
+func TestWrappedKeyRepository_UnwrapOnGet(t *testing.T) {
+	ctx := context.Background()
+	_, store := newWrappedStore(t, map[string][]byte{"stream1.key": []byte("0123456789abcdef")})
+	kek := &fakeKEK{}
+	repo, err := NewWrappedKeyRepository(store, kek, WithUnwrapOnGet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := kek.count(); n != 0 {
+		t.Errorf("unwraps before Get = %d, want 0", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		key, err := repo.Get(ctx, "stream1.key")
+		if err != nil || string(key) != "0123456789abcdef" {
+			t.Fatalf("Get() = %q, %v", key, err)
+		}
+	}
+	if n := kek.count(); n != 3 {
+		t.Errorf("unwraps = %d, want 3 (one per Get)", n)
+	}
+	if _, err := repo.Get(ctx, "../stream1.key"); !errors.Is(err, apperrors.ErrInvalidKeyName) {
+		t.Errorf("Get(traversal) error = %v, want ErrInvalidKeyName", err)
+	}
+	if err := repo.HealthCheck(ctx); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"hls-key-server-go/internal/handler"
+)
+
+// AdminRoute handles management route registration
+type AdminRoute struct {
+	adminHandler *handler.AdminHandler
+}
+
+// NewAdminRoute creates a new admin route
+func NewAdminRoute(adminHandler *handler.AdminHandler) *AdminRoute {
+	return &AdminRoute{
+		adminHandler: adminHandler,
+	}
+}
+
+// RegisterRoutes registers admin routes behind admin basic auth
+func (r *AdminRoute) RegisterRoutes(group *gin.RouterGroup) {
+	adminGroup := group.Group("/admin", r.adminHandler.BasicAuth())
+	{
+		adminGroup.GET("/lockouts", r.adminHandler.ListLockouts)
+		adminGroup.DELETE("/lockouts", r.adminHandler.ClearLockouts)
+		adminGroup.DELETE("/lockouts/:scope/:value", r.adminHandler.ClearLockout)
+		adminGroup.GET("/log-level", r.adminHandler.GetLogLevel)
+		adminGroup.PUT("/log-level", r.adminHandler.SetLogLevel)
+		adminGroup.DELETE("/log-level/:component", r.adminHandler.ResetLogLevel)
+		adminGroup.PUT("/keys/:name", r.adminHandler.PutKey)
+		adminGroup.DELETE("/keys/:name", r.adminHandler.DeleteKey)
+		adminGroup.GET("/cluster", r.adminHandler.GetCluster)
+		adminGroup.POST("/cluster/members", r.adminHandler.AddClusterMember)
+		adminGroup.DELETE("/cluster/members/:id", r.adminHandler.RemoveClusterMember)
+	}
+}
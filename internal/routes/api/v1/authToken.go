@@ -9,12 +9,14 @@ import (
 // AuthRoutes handles authentication routes
 type AuthRoutes struct {
 	authHandler *handler.AuthHandler
+	middlewares []gin.HandlerFunc
 }
 
 // NewAuthRoutes creates a new auth routes handler
-func NewAuthRoutes(authHandler *handler.AuthHandler) *AuthRoutes {
+func NewAuthRoutes(authHandler *handler.AuthHandler, middlewares ...gin.HandlerFunc) *AuthRoutes {
 	return &AuthRoutes{
 		authHandler: authHandler,
+		middlewares: middlewares,
 	}
 }
 
@@ -33,6 +35,7 @@ func NewAuthRoutes(authHandler *handler.AuthHandler) *AuthRoutes {
 func (a *AuthRoutes) RegisterRoutes(group *gin.RouterGroup) {
 	authGroup := group.Group("/auth")
 	{
-		authGroup.POST("/token", a.authHandler.GenerateToken)
+		handlers := append(append([]gin.HandlerFunc{}, a.middlewares...), a.authHandler.GenerateToken)
+		authGroup.POST("/token", handlers...)
 	}
 }
@@ -8,13 +8,18 @@ import (
 
 // HlsKeyRoute handles HLS key routes
 type HlsKeyRoute struct {
-	hlsHandler *handler.HLSHandler
+	hlsHandler  *handler.HLSHandler
+	middlewares []gin.HandlerFunc
+	keyChain    []gin.HandlerFunc
 }
 
 // NewHlsKeyRoute creates a new HLS key route
-func NewHlsKeyRoute(hlsHandler *handler.HLSHandler) *HlsKeyRoute {
+// middlewares apply to every HLS route; keyChain applies only to key retrieval
+func NewHlsKeyRoute(hlsHandler *handler.HLSHandler, middlewares, keyChain []gin.HandlerFunc) *HlsKeyRoute {
 	return &HlsKeyRoute{
-		hlsHandler: hlsHandler,
+		hlsHandler:  hlsHandler,
+		middlewares: middlewares,
+		keyChain:    keyChain,
 	}
 }
 
@@ -24,9 +29,10 @@ func NewHlsKeyRoute(hlsHandler *handler.HLSHandler) *HlsKeyRoute {
 // @Tags Hls
 // @Accept  json
 func (a *HlsKeyRoute) RegisterRoutes(group *gin.RouterGroup) {
-	hlsGroup := group.Group("/hls")
+	hlsGroup := group.Group("/hls", a.middlewares...)
 	{
-		hlsGroup.POST("/key", a.hlsHandler.GetKey)
+		keyHandlers := append(append([]gin.HandlerFunc{}, a.keyChain...), a.hlsHandler.GetKey)
+		hlsGroup.POST("/key", keyHandlers...)
 		hlsGroup.GET("/keys", a.hlsHandler.ListKeys)
 		hlsGroup.POST("/reload", a.hlsHandler.ReloadKeys)
 	}
@@ -7,14 +7,30 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// RouteMiddlewares holds per-group middleware chains applied during route registration
+type RouteMiddlewares struct {
+	// Auth is applied to token issuance routes
+	Auth []gin.HandlerFunc
+	// HLS is applied to every /hls route
+	HLS []gin.HandlerFunc
+	// Key is applied to the key retrieval route after HLS
+	Key []gin.HandlerFunc
+}
+
 // GetRouteGroups is a function that returns all route groups
 // @Summary Get all route groups
 // @Description Get all route groups
 // @Tags Route
-func GetRouteGroups(hlsHandler *handler.HLSHandler, authHandler *handler.AuthHandler, metricsHandler *handler.MetricsHandler) []interface{ RegisterRoutes(*gin.RouterGroup) } {
-	return []interface{ RegisterRoutes(*gin.RouterGroup) }{
-		NewHlsKeyRoute(hlsHandler),
-		NewAuthRoutes(authHandler),
+// The sync export route is registered only when syncHandler is non-nil.
+func GetRouteGroups(hlsHandler *handler.HLSHandler, authHandler *handler.AuthHandler, metricsHandler *handler.MetricsHandler, adminHandler *handler.AdminHandler, syncHandler *handler.SyncHandler, mw RouteMiddlewares) []interface{ RegisterRoutes(*gin.RouterGroup) } {
+	groups := []interface{ RegisterRoutes(*gin.RouterGroup) }{
+		NewHlsKeyRoute(hlsHandler, mw.HLS, mw.Key),
+		NewAuthRoutes(authHandler, mw.Auth...),
 		NewMetricsRoute(metricsHandler),
+		NewAdminRoute(adminHandler),
+	}
+	if syncHandler != nil {
+		groups = append(groups, NewSyncRoute(syncHandler))
 	}
+	return groups
 }
@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"hls-key-server-go/internal/handler"
+)
+
+// SyncRoute handles key export route registration
+type SyncRoute struct {
+	syncHandler *handler.SyncHandler
+}
+
+// NewSyncRoute creates a new sync route
+func NewSyncRoute(syncHandler *handler.SyncHandler) *SyncRoute {
+	return &SyncRoute{
+		syncHandler: syncHandler,
+	}
+}
+
+// RegisterRoutes registers the export endpoint behind the sync token
+func (r *SyncRoute) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/sync/export", r.syncHandler.Auth(), r.syncHandler.Export)
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,39 +11,89 @@ import (
 
 	"hls-key-server-go/internal/apperrors"
 	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/tracing"
 )
 
 // AuthService handles authentication logic
 type AuthService struct {
-	config *configs.JwtSecret
+	config *configs.Snapshot[configs.JwtSecret]
 	logger *zap.Logger
+	// renewWindow is how long after it expired a token still carries its
+	// session over to a new one
+	renewWindow time.Duration
 }
 
-// NewAuthService creates a new auth service
-func NewAuthService(config *configs.JwtSecret, logger *zap.Logger) *AuthService {
-	return &AuthService{
+// AuthOption configures optional AuthService behavior
+type AuthOption func(*AuthService)
+
+// WithSessionRenewal lets a token that expired less than window ago keep
+// its session when renewed; without it only unexpired tokens do. Match it
+// to the session idle timeout, after which the session is gone anyway.
+func WithSessionRenewal(window time.Duration) AuthOption {
+	return func(s *AuthService) {
+		s.renewWindow = window
+	}
+}
+
+// NewAuthService creates a new auth service; settings are read from config
+// on every call so a reload applies to the next token
+func NewAuthService(config *configs.Snapshot[configs.JwtSecret], logger *zap.Logger, opts ...AuthOption) *AuthService {
+	s := &AuthService{
 		config: config,
 		logger: logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GenerateToken generates a JWT token for the given username.
+// Each token carries a random "sid" claim identifying one playback session.
+func (s *AuthService) GenerateToken(ctx context.Context, username string) (string, error) {
+	return s.IssueToken(ctx, username, "")
 }
 
-// GenerateToken generates a JWT token for the given username
-func (s *AuthService) GenerateToken(_ context.Context, username string) (string, error) {
+// IssueToken generates a JWT token for username like GenerateToken. When
+// previous is a token this server issued to username, even one expired
+// within the renewal window, the new token keeps its "sid", so a player
+// renewing its token stays in the same playback session rather than taking
+// a new session slot.
+func (s *AuthService) IssueToken(ctx context.Context, username, previous string) (string, error) {
+	cfg := s.config.Load()
+
+	sid := sessionIDOf(previous, username, cfg, time.Now().Add(-s.renewWindow))
+	if sid == "" {
+		var err error
+		if sid, err = newSessionID(); err != nil {
+			return "", apperrors.Wrap(err, "generate session id")
+		}
+	} else {
+		logger.FromContext(ctx, s.logger).Debug("session carried over from previous token",
+			zap.String("username", username),
+		)
+	}
+
 	claims := jwt.MapClaims{
 		"sub": username,
-		"exp": time.Now().Add(time.Minute * time.Duration(s.config.Expire)).Unix(),
+		"sid": sid,
+		"exp": time.Now().Add(time.Minute * time.Duration(cfg.Expire)).Unix(),
 		"iat": time.Now().Unix(),
-		"iss": s.config.Iss,
-		"aud": s.config.Aud,
+		"iss": cfg.Iss,
+		"aud": cfg.Aud,
+	}
+	if cfg.Tenant != "" {
+		claims["tenant"] = cfg.Tenant
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(s.config.SecretKey))
+	signedToken, err := token.SignedString([]byte(cfg.SecretKey))
 	if err != nil {
 		return "", apperrors.Wrap(err, "sign token")
 	}
 
-	s.logger.Info("JWT token generated",
+	logger.FromContext(ctx, s.logger).Info("JWT token generated",
 		zap.String("username", username),
 	)
 
@@ -54,7 +106,8 @@ func (s *AuthService) ValidateCredentials(_ context.Context, username, headerVal
 		return apperrors.ErrInvalidCredentials
 	}
 
-	if username != s.config.User {
+	cfg := s.config.Load()
+	if username != cfg.User {
 		return apperrors.ErrInvalidCredentials
 	}
 
@@ -62,7 +115,7 @@ func (s *AuthService) ValidateCredentials(_ context.Context, username, headerVal
 		return apperrors.ErrMissingHeader
 	}
 
-	if headerValue != s.config.HeaderValue {
+	if headerValue != cfg.HeaderValue {
 		return apperrors.ErrMissingHeader
 	}
 
@@ -70,17 +123,21 @@ func (s *AuthService) ValidateCredentials(_ context.Context, username, headerVal
 }
 
 // ValidateToken validates a JWT token and returns the claims
-func (s *AuthService) ValidateToken(_ context.Context, tokenString string) (jwt.MapClaims, error) {
+func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (_ jwt.MapClaims, err error) {
+	_, span := tracing.Tracer().Start(ctx, "AuthService.ValidateToken")
+	defer func() { tracing.End(span, err) }()
+
 	if tokenString == "" {
 		return nil, apperrors.ErrTokenMissing
 	}
 
+	cfg := s.config.Load()
 	claims := jwt.MapClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, apperrors.ErrTokenInvalid
 		}
-		return []byte(s.config.SecretKey), nil
+		return []byte(cfg.SecretKey), nil
 	})
 
 	if err != nil || !token.Valid {
@@ -88,14 +145,14 @@ func (s *AuthService) ValidateToken(_ context.Context, tokenString string) (jwt.
 	}
 
 	// Validate required claims
-	if !s.validateClaims(claims) {
+	if !validateClaims(claims, cfg) {
 		return nil, apperrors.ErrTokenInvalid
 	}
 
 	return claims, nil
 }
 
-func (s *AuthService) validateClaims(claims jwt.MapClaims) bool {
+func validateClaims(claims jwt.MapClaims, cfg *configs.JwtSecret) bool {
 	_, subExists := claims["sub"].(string)
 	_, expExists := claims["exp"].(float64)
 	_, iatExists := claims["iat"].(float64)
@@ -106,9 +163,50 @@ func (s *AuthService) validateClaims(claims jwt.MapClaims) bool {
 		return false
 	}
 
-	if iss != s.config.Iss || aud != s.config.Aud {
+	if iss != cfg.Iss || aud != cfg.Aud {
 		return false
 	}
 
 	return true
 }
+
+// maxSessionIDLength bounds the "sid" carried over from a previous token
+const maxSessionIDLength = 64
+
+// sessionIDOf returns the "sid" of previous when it is a token signed with
+// the current secret for username that expired no earlier than notBefore
+func sessionIDOf(previous, username string, cfg *configs.JwtSecret, notBefore time.Time) string {
+	if previous == "" {
+		return ""
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(previous, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, apperrors.ErrTokenInvalid
+		}
+		return []byte(cfg.SecretKey), nil
+	}, jwt.WithoutClaimsValidation())
+	if err != nil || !token.Valid || !validateClaims(claims, cfg) {
+		return ""
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil || exp.Before(notBefore) {
+		return ""
+	}
+
+	sub, _ := claims["sub"].(string)
+	sid, _ := claims["sid"].(string)
+	if sub != username || len(sid) > maxSessionIDLength {
+		return ""
+	}
+	return sid
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 
 	"hls-key-server-go/internal/configs"
@@ -19,7 +21,7 @@ func TestNewAuthService(t *testing.T) {
 	}
 	logger := zap.NewNop()
 
-	service := NewAuthService(config, logger)
+	service := NewAuthService(configs.NewSnapshot(*config), logger)
 
 	if service == nil {
 		t.Fatal("NewAuthService() returned nil")
@@ -36,7 +38,7 @@ func TestAuthService_GenerateToken(t *testing.T) {
 	}
 	logger := zap.NewNop()
 
-	service := NewAuthService(config, logger)
+	service := NewAuthService(configs.NewSnapshot(*config), logger)
 
 	ctx := context.Background()
 	token, err := service.GenerateToken(ctx, "testuser")
@@ -49,6 +51,121 @@ func TestAuthService_GenerateToken(t *testing.T) {
 	}
 }
 
+func TestAuthService_IssueTokenKeepsSession(t *testing.T) {
+	config := configs.JwtSecret{
+		SecretKey: "test-secret-key-for-jwt",
+		Expire:    10,
+		User:      "testuser",
+		Iss:       "test-issuer",
+		Aud:       "test-audience",
+	}
+	service := NewAuthService(configs.NewSnapshot(config), zap.NewNop(), WithSessionRenewal(2*time.Minute))
+	ctx := context.Background()
+
+	sidOf := func(token string) string {
+		t.Helper()
+		claims := jwt.MapClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+			t.Fatal(err)
+		}
+		sid, _ := claims["sid"].(string)
+		return sid
+	}
+	sign := func(secret string, claims jwt.MapClaims) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	expiredAgo := func(sub, sid string, ago time.Duration) jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": sub, "sid": sid, "iss": config.Iss, "aud": config.Aud,
+			"iat": time.Now().Add(-2 * time.Hour).Unix(),
+			"exp": time.Now().Add(-ago).Unix(),
+		}
+	}
+	expired := func(sub, sid string) jwt.MapClaims {
+		return expiredAgo(sub, sid, time.Minute)
+	}
+
+	first, err := service.GenerateToken(ctx, "testuser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := service.IssueToken(ctx, "testuser", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sidOf(first) == "" || sidOf(renewed) != sidOf(first) {
+		t.Errorf("renewed sid = %q, want %q", sidOf(renewed), sidOf(first))
+	}
+
+	tests := []struct {
+		name     string
+		previous string
+		wantSID  string
+	}{
+		{name: "expired token", previous: sign(config.SecretKey, expired("testuser", "old-session")), wantSID: "old-session"},
+		{name: "expired before the renewal window", previous: sign(config.SecretKey, expiredAgo("testuser", "old-session", time.Hour))},
+		{name: "other subject", previous: sign(config.SecretKey, expired("mallory", "old-session"))},
+		{name: "wrong secret", previous: sign("another-secret", expired("testuser", "old-session"))},
+		{name: "not a token", previous: "garbage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := service.IssueToken(ctx, "testuser", tt.previous)
+			if err != nil {
+				t.Fatalf("IssueToken() error = %v", err)
+			}
+			sid := sidOf(token)
+			if tt.wantSID != "" && sid != tt.wantSID {
+				t.Errorf("sid = %q, want %q", sid, tt.wantSID)
+			}
+			if tt.wantSID == "" && (sid == "" || sid == "old-session") {
+				t.Errorf("sid = %q, want a new session", sid)
+			}
+		})
+	}
+
+	// Without a renewal window only unexpired tokens keep their session
+	token, err := NewAuthService(configs.NewSnapshot(config), zap.NewNop()).IssueToken(ctx, "testuser", sign(config.SecretKey, expired("testuser", "old-session")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sid := sidOf(token); sid == "old-session" {
+		t.Error("expired token kept its session without a renewal window")
+	}
+}
+
+func TestAuthService_IssueTokenTenant(t *testing.T) {
+	config := configs.JwtSecret{
+		SecretKey: "test-secret-key-for-jwt",
+		Expire:    10,
+		User:      "testuser",
+		Iss:       "test-issuer",
+		Aud:       "test-audience",
+	}
+	ctx := context.Background()
+
+	for _, tenant := range []string{"", "acme"} {
+		config.Tenant = tenant
+		service := NewAuthService(configs.NewSnapshot(config), zap.NewNop())
+		token, err := service.GenerateToken(ctx, "testuser")
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := service.ValidateToken(ctx, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := claims["tenant"].(string)
+		if got != tenant || ok != (tenant != "") {
+			t.Errorf("tenant claim = %q (present %v), want %q", got, ok, tenant)
+		}
+	}
+}
 func TestAuthService_ValidateCredentials(t *testing.T) {
 	config := &configs.JwtSecret{
 		SecretKey:   "test-secret",
@@ -57,7 +174,7 @@ func TestAuthService_ValidateCredentials(t *testing.T) {
 	}
 	logger := zap.NewNop()
 
-	service := NewAuthService(config, logger)
+	service := NewAuthService(configs.NewSnapshot(*config), logger)
 
 	tests := []struct {
 		name        string
@@ -126,7 +243,7 @@ func TestAuthService_ValidateToken(t *testing.T) {
 	}
 	logger := zap.NewNop()
 
-	service := NewAuthService(config, logger)
+	service := NewAuthService(configs.NewSnapshot(*config), logger)
 
 	// Generate a valid token
 	ctx := context.Background()
@@ -182,6 +299,9 @@ func TestAuthService_ValidateToken(t *testing.T) {
 			if sub, ok := claims["sub"].(string); !ok || sub != "testuser" {
 				t.Errorf("ValidateToken() sub claim = %v, want testuser", sub)
 			}
+			if sid, ok := claims["sid"].(string); !ok || len(sid) != 32 {
+				t.Errorf("ValidateToken() sid claim = %v, want 32 hex characters", sid)
+			}
 		})
 	}
 }
@@ -194,7 +314,7 @@ func BenchmarkAuthService_GenerateToken(b *testing.B) {
 		Aud:       "benchmark-audience",
 	}
 	logger := zap.NewNop()
-	service := NewAuthService(config, logger)
+	service := NewAuthService(configs.NewSnapshot(*config), logger)
 	ctx := context.Background()
 
 	b.ResetTimer()
@@ -211,7 +331,7 @@ func BenchmarkAuthService_ValidateToken(b *testing.B) {
 		Aud:       "benchmark-audience",
 	}
 	logger := zap.NewNop()
-	service := NewAuthService(config, logger)
+	service := NewAuthService(configs.NewSnapshot(*config), logger)
 	ctx := context.Background()
 
 	token, err := service.GenerateToken(ctx, "testuser")
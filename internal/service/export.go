@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/pkg/keysync"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/repository"
+)
+
+// maxTombstones bounds remembered deletions; replicas older than the oldest
+// forgotten one get a full snapshot
+const maxTombstones = 10000
+
+// ExportService exports keys to pull-based replicas. It numbers every key
+// change it observes with a revision so a replica can ask for only the
+// changes since the revision it last applied.
+type ExportService struct {
+	keyRepo repository.KeyRepository
+	logger  *zap.Logger
+
+	mu    sync.Mutex
+	epoch string
+	rev   uint64
+	// floor is the newest revision whose deletion was forgotten; deltas
+	// from before it would miss deletions
+	floor      uint64
+	keys       map[string]exportEntry
+	tombstones map[string]uint64
+}
+
+type exportEntry struct {
+	sum [sha256.Size]byte
+	rev uint64
+}
+
+// NewExportService creates an export service starting a new epoch
+func NewExportService(keyRepo repository.KeyRepository, logger *zap.Logger) *ExportService {
+	epoch := make([]byte, 8)
+	_, _ = rand.Read(epoch)
+	return &ExportService{
+		keyRepo:    keyRepo,
+		logger:     logger,
+		epoch:      hex.EncodeToString(epoch),
+		keys:       make(map[string]exportEntry),
+		tombstones: make(map[string]uint64),
+	}
+}
+
+// Export returns the changes since revision since of epoch, or every key
+// when the replica is from another epoch or too far behind. It returns nil
+// when the replica is up to date.
+func (s *ExportService) Export(ctx context.Context, epoch string, since uint64) (*keysync.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &keysync.Snapshot{Epoch: s.epoch, Revision: s.rev, Keys: make(map[string][]byte)}
+	if epoch != s.epoch || since < s.floor || since > s.rev {
+		snap.Full = true
+		for name, data := range current {
+			snap.Keys[name] = data
+		}
+		return snap, nil
+	}
+	if since == s.rev {
+		return nil, nil
+	}
+
+	for name, e := range s.keys {
+		if e.rev > since {
+			snap.Keys[name] = current[name]
+		}
+	}
+	for name, rev := range s.tombstones {
+		if rev > since {
+			snap.Deleted = append(snap.Deleted, name)
+		}
+	}
+	sort.Strings(snap.Deleted)
+	return snap, nil
+}
+
+// scan reads every key and assigns new revisions to changes since the last
+// scan, returning the current keys
+func (s *ExportService) scan(ctx context.Context) (map[string][]byte, error) {
+	log := logger.FromContext(ctx, s.logger)
+
+	names := s.keyRepo.List(ctx)
+	sort.Strings(names)
+	current := make(map[string][]byte, len(names))
+	for _, name := range names {
+		data, err := s.keyRepo.Get(ctx, name)
+		if errors.Is(err, apperrors.ErrKeyNotFound) {
+			// Deleted after List; the next scan records the deletion
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("export key %s: %w", name, err)
+		}
+		current[name] = data
+
+		sum := sha256.Sum256(data)
+		if e, ok := s.keys[name]; ok && e.sum == sum {
+			continue
+		}
+		s.rev++
+		s.keys[name] = exportEntry{sum: sum, rev: s.rev}
+		delete(s.tombstones, name)
+		log.Debug("export revision", zap.String("key_name", name), zap.Uint64("revision", s.rev))
+	}
+
+	for name := range s.keys {
+		if _, ok := current[name]; ok {
+			continue
+		}
+		s.rev++
+		delete(s.keys, name)
+		s.tombstones[name] = s.rev
+		log.Debug("export revision", zap.String("key_name", name), zap.Uint64("revision", s.rev), zap.Bool("deleted", true))
+	}
+	s.compact()
+
+	return current, nil
+}
+
+// compact forgets the oldest deletions beyond maxTombstones
+func (s *ExportService) compact() {
+	if len(s.tombstones) <= maxTombstones {
+		return
+	}
+	type tombstone struct {
+		name string
+		rev  uint64
+	}
+	all := make([]tombstone, 0, len(s.tombstones))
+	for name, rev := range s.tombstones {
+		all = append(all, tombstone{name, rev})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].rev < all[j].rev })
+	for _, t := range all[:len(all)-maxTombstones] {
+		delete(s.tombstones, t.name)
+		s.floor = max(s.floor, t.rev)
+	}
+}
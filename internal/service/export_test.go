@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/repository"
+)
+
+func TestExportService_Export(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewFileKeyRepository(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.key", "b.key"} {
+		if err := repo.Put(ctx, name, []byte(name+"-0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewExportService(repo, zap.NewNop())
+
+	full, err := s.Export(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !full.Full || len(full.Keys) != 2 || full.Revision != 2 {
+		t.Fatalf("first Export() = full %v, %d keys, revision %d; want full, 2 keys, revision 2", full.Full, len(full.Keys), full.Revision)
+	}
+
+	if snap, err := s.Export(ctx, full.Epoch, full.Revision); err != nil || snap != nil {
+		t.Fatalf("Export() when up to date = %+v, %v; want nil", snap, err)
+	}
+
+	if err := repo.Put(ctx, "a.key", []byte("changed-0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, "b.key"); err != nil {
+		t.Fatal(err)
+	}
+	delta, err := s.Export(ctx, full.Epoch, full.Revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delta.Full || len(delta.Keys) != 1 || string(delta.Keys["a.key"]) != "changed-0123456789" {
+		t.Errorf("delta keys = %v (full %v), want only the changed a.key", delta.Keys, delta.Full)
+	}
+	if len(delta.Deleted) != 1 || delta.Deleted[0] != "b.key" {
+		t.Errorf("delta deleted = %v, want [b.key]", delta.Deleted)
+	}
+	if delta.Revision != 4 {
+		t.Errorf("delta revision = %d, want 4", delta.Revision)
+	}
+
+	// A replica synced from an earlier run of the origin starts over
+	if snap, err := s.Export(ctx, "other-epoch", delta.Revision); err != nil || !snap.Full || len(snap.Keys) != 1 {
+		t.Errorf("Export() from another epoch = %+v, %v; want a full snapshot of 1 key", snap, err)
+	}
+	if snap, err := s.Export(ctx, full.Epoch, 99); err != nil || !snap.Full {
+		t.Errorf("Export() from a future revision = %+v, %v; want a full snapshot", snap, err)
+	}
+}
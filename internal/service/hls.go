@@ -3,37 +3,92 @@ package service
 
 import (
 	"context"
+	"errors"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/pkg/logger"
 	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/principal"
+	"hls-key-server-go/internal/pkg/tracing"
 	"hls-key-server-go/internal/repository"
 )
 
 // HLSService handles HLS key business logic
 type HLSService struct {
-	keyRepo repository.KeyRepository
-	logger  *zap.Logger
+	keyRepo  repository.KeyRepository
+	sessions *SessionService
+	logger   *zap.Logger
+
+	reloadMu      sync.RWMutex
+	lastReloadErr error
+}
+
+// HLSOption configures optional HLSService behavior
+type HLSOption func(*HLSService)
+
+// WithSessionLimits enforces concurrent session limits on key retrieval
+func WithSessionLimits(sessions *SessionService) HLSOption {
+	return func(s *HLSService) {
+		s.sessions = sessions
+	}
 }
 
 // NewHLSService creates a new HLS service instance
-func NewHLSService(keyRepo repository.KeyRepository, logger *zap.Logger) *HLSService {
-	return &HLSService{
+func NewHLSService(keyRepo repository.KeyRepository, logger *zap.Logger, opts ...HLSOption) *HLSService {
+	s := &HLSService{
 		keyRepo: keyRepo,
 		logger:  logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// GetKey retrieves an encryption key by name
-func (s *HLSService) GetKey(ctx context.Context, keyName string) ([]byte, error) {
-	key, err := s.keyRepo.Get(ctx, keyName)
+// GetKey retrieves an encryption key by name.
+// A request principal restricted to certain keys gets ErrForbidden for others.
+// When session limits are enabled, the request principal's session is
+// admitted once the key is found, so missing keys and invalid names do not
+// take a session slot, and ErrSessionLimitExceeded is returned beyond the
+// limit.
+func (s *HLSService) GetKey(ctx context.Context, keyName string) (key []byte, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HLSService.GetKey",
+		trace.WithAttributes(attribute.String("hls.key", keyName)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.logger)
+
+	p, hasPrincipal := principal.FromContext(ctx)
+	if hasPrincipal {
+		span.SetAttributes(attribute.String("enduser.id", p.Subject))
+		if !p.AllowsKey(keyName) {
+			log.Warn("key access forbidden for principal",
+				zap.String("key_name", keyName),
+				zap.String("subject", p.Subject),
+			)
+			return nil, apperrors.ErrForbidden
+		}
+	}
+
+	key, err = s.keyRepo.Get(ctx, keyName)
 	if err != nil {
 		return nil, apperrors.Wrap(err, "get key from repository")
 	}
 
-	s.logger.Info("key retrieved",
+	if hasPrincipal && s.sessions != nil {
+		if err := s.sessions.Admit(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info("key retrieved",
 		zap.String("key_name", keyName),
 		zap.Int("key_size", len(key)),
 	)
@@ -48,19 +103,39 @@ func (s *HLSService) ListKeys(ctx context.Context) []string {
 	return keys
 }
 
-// ReloadKeys reloads all keys from storage
-func (s *HLSService) ReloadKeys(ctx context.Context) error {
+// LastReloadError returns the error of the most recent ReloadKeys call,
+// or nil if it succeeded, only some key files were unreadable, or keys
+// were never reloaded
+func (s *HLSService) LastReloadError() error {
+	s.reloadMu.RLock()
+	defer s.reloadMu.RUnlock()
+	return s.lastReloadErr
+}
+
+// ReloadKeys reloads all keys from storage and returns how many key files
+// could not be read. Those keep their last key and do not fail the reload.
+func (s *HLSService) ReloadKeys(ctx context.Context) (unreadable int, err error) {
 	timer := prometheus.NewTimer(metrics.KeyReloadDuration)
 	defer timer.ObserveDuration()
 
-	if err := s.keyRepo.Reload(ctx); err != nil {
-		return apperrors.Wrap(err, "reload keys")
+	err = s.keyRepo.Reload(ctx)
+	// The repository logs and counts unreadable files, and readiness only
+	// tracks reloads that failed as a whole
+	var partial *repository.UnreadableKeysError
+	if errors.As(err, &partial) {
+		unreadable, err = len(partial.Errs), nil
+	}
+	s.reloadMu.Lock()
+	s.lastReloadErr = err
+	s.reloadMu.Unlock()
+	if err != nil {
+		return 0, apperrors.Wrap(err, "reload keys")
 	}
 
 	// Update active keys count
 	keys := s.keyRepo.List(ctx)
 	metrics.ActiveKeys.Set(float64(len(keys)))
 
-	s.logger.Info("keys reloaded successfully")
-	return nil
+	logger.FromContext(ctx, s.logger).Info("keys reloaded successfully", zap.Int("unreadable", unreadable))
+	return unreadable, nil
 }
@@ -8,6 +8,8 @@ import (
 	"go.uber.org/zap"
 
 	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/pkg/principal"
+	"hls-key-server-go/internal/repository"
 )
 
 // mockKeyRepository implements repository.KeyRepository for testing
@@ -161,13 +163,16 @@ func TestHLSService_ReloadKeys(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name      string
-		wantErr   bool
-		setupRepo func() *mockKeyRepository
+		name           string
+		wantErr        bool
+		wantReady      bool
+		wantUnreadable int
+		setupRepo      func() *mockKeyRepository
 	}{
 		{
-			name:    "successful reload",
-			wantErr: false,
+			name:      "successful reload",
+			wantErr:   false,
+			wantReady: true,
 			setupRepo: func() *mockKeyRepository {
 				return newMockKeyRepository()
 			},
@@ -181,6 +186,17 @@ func TestHLSService_ReloadKeys(t *testing.T) {
 				return repo
 			},
 		},
+		{
+			name:           "some key files unreadable",
+			wantErr:        false,
+			wantReady:      true,
+			wantUnreadable: 1,
+			setupRepo: func() *mockKeyRepository {
+				repo := newMockKeyRepository()
+				repo.reloadErr = &repository.UnreadableKeysError{Errs: []error{errors.New("read key file a.key: permission denied")}}
+				return repo
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -192,7 +208,10 @@ func TestHLSService_ReloadKeys(t *testing.T) {
 			service := NewHLSService(repo, logger)
 			ctx := context.Background()
 
-			err := service.ReloadKeys(ctx)
+			unreadable, err := service.ReloadKeys(ctx)
+			if ready := service.LastReloadError() == nil; ready != tt.wantReady {
+				t.Errorf("LastReloadError() = %v, want ready %v", service.LastReloadError(), tt.wantReady)
+			}
 
 			if tt.wantErr {
 				if err == nil {
@@ -204,6 +223,9 @@ func TestHLSService_ReloadKeys(t *testing.T) {
 			if err != nil {
 				t.Errorf("ReloadKeys() unexpected error = %v", err)
 			}
+			if unreadable != tt.wantUnreadable {
+				t.Errorf("ReloadKeys() unreadable = %d, want %d", unreadable, tt.wantUnreadable)
+			}
 		})
 	}
 }
@@ -234,3 +256,19 @@ func BenchmarkHLSService_ListKeys(b *testing.B) {
 		_ = service.ListKeys(ctx)
 	}
 }
+
+func TestHLSService_GetKey_PrincipalKeys(t *testing.T) {
+	service := NewHLSService(newMockKeyRepository(), zap.NewNop())
+
+	ctx := principal.WithPrincipal(context.Background(), principal.Principal{
+		Subject: "encoder",
+		Keys:    []string{"test*.key"},
+	})
+
+	if _, err := service.GetKey(ctx, "test.key"); err != nil {
+		t.Errorf("GetKey() allowed key error = %v", err)
+	}
+	if _, err := service.GetKey(ctx, "another.key"); !apperrors.IsForbidden(err) {
+		t.Errorf("GetKey() restricted key error = %v, want ErrForbidden", err)
+	}
+}
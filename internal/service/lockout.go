@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/metrics"
+)
+
+// Lockout describes an active lockout for an IP or username
+type Lockout struct {
+	Key      string    `json:"key"`
+	Scope    string    `json:"scope"`
+	Value    string    `json:"value"`
+	Count    int       `json:"count"`
+	LockedAt time.Time `json:"locked_at"`
+	Until    time.Time `json:"until"`
+}
+
+type attemptState struct {
+	failures     int
+	firstFailure time.Time
+	blockedUntil time.Time
+	locked       bool
+	lockedAt     time.Time
+	lockouts     int
+	lastSeen     time.Time
+}
+
+// LockoutService tracks failed token issuance attempts and applies
+// progressive backoff and temporary lockouts per IP and per username
+type LockoutService struct {
+	config  configs.Lockout
+	logger  *zap.Logger
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]*attemptState
+}
+
+// NewLockoutService creates a new lockout tracker
+func NewLockoutService(config configs.Lockout, logger *zap.Logger) *LockoutService {
+	return &LockoutService{
+		config:  config,
+		logger:  logger,
+		now:     time.Now,
+		entries: make(map[string]*attemptState),
+	}
+}
+
+// Check returns ErrTooManyAttempts and the remaining wait when either the IP
+// or the username is in backoff or locked out
+func (s *LockoutService) Check(_ context.Context, ip, username string) (time.Duration, error) {
+	if !s.config.Enable {
+		return 0, nil
+	}
+
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var wait time.Duration
+	for _, key := range lockoutKeys(ip, username) {
+		st, ok := s.entries[key]
+		if !ok {
+			continue
+		}
+		if remaining := st.blockedUntil.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	if wait > 0 {
+		return wait, apperrors.ErrTooManyAttempts
+	}
+	return 0, nil
+}
+
+// RecordFailure registers a failed attempt for the IP and username. Pass
+// an empty username for callers that have not presented the valid header,
+// so an anonymous client cannot put a shared username into backoff.
+func (s *LockoutService) RecordFailure(ctx context.Context, ip, username string) {
+	if !s.config.Enable {
+		return
+	}
+
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+
+	for _, key := range lockoutKeys(ip, username) {
+		st, ok := s.entries[key]
+		if !ok {
+			st = &attemptState{}
+			s.entries[key] = st
+		}
+		st.lastSeen = now
+
+		if st.failures == 0 || now.Sub(st.firstFailure) > s.config.Window {
+			st.failures = 0
+			st.firstFailure = now
+		}
+		st.failures++
+
+		if s.config.MaxFailures > 0 && st.failures >= s.config.MaxFailures {
+			st.lockouts++
+			duration := backoff(s.config.Duration, st.lockouts, s.config.MaxDuration)
+			st.locked = true
+			st.lockedAt = now
+			st.blockedUntil = now.Add(duration)
+			st.failures = 0
+
+			scope, value := splitLockoutKey(key)
+			metrics.AuthLockouts.WithLabelValues(scope).Inc()
+			logger.FromContext(ctx, s.logger).Warn("authentication lockout triggered",
+				zap.String("scope", scope),
+				zap.String("value", value),
+				zap.Int("lockout_count", st.lockouts),
+				zap.Duration("duration", duration),
+			)
+			continue
+		}
+
+		st.locked = false
+		st.blockedUntil = now.Add(backoff(s.config.BaseDelay, st.failures, s.config.MaxDelay))
+	}
+}
+
+// RecordSuccess clears failure state for the IP and username
+func (s *LockoutService) RecordSuccess(_ context.Context, ip, username string) {
+	if !s.config.Enable {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range lockoutKeys(ip, username) {
+		delete(s.entries, key)
+	}
+}
+
+// List returns all active lockouts ordered by key
+func (s *LockoutService) List(_ context.Context) []Lockout {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockouts := make([]Lockout, 0)
+	for key, st := range s.entries {
+		if !st.locked || !st.blockedUntil.After(now) {
+			continue
+		}
+		scope, value := splitLockoutKey(key)
+		lockouts = append(lockouts, Lockout{
+			Key:      key,
+			Scope:    scope,
+			Value:    value,
+			Count:    st.lockouts,
+			LockedAt: st.lockedAt,
+			Until:    st.blockedUntil,
+		})
+	}
+
+	sort.Slice(lockouts, func(i, j int) bool { return lockouts[i].Key < lockouts[j].Key })
+	return lockouts
+}
+
+// Clear removes failure and lockout state for scope ("ip" or "user") and value.
+// It reports whether any state existed.
+func (s *LockoutService) Clear(ctx context.Context, scope, value string) bool {
+	key := scope + ":" + value
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok {
+		return false
+	}
+	delete(s.entries, key)
+
+	logger.FromContext(ctx, s.logger).Info("authentication lockout cleared",
+		zap.String("scope", scope),
+		zap.String("value", value),
+	)
+	return true
+}
+
+// ClearAll removes all failure and lockout state and returns the number of entries removed
+func (s *LockoutService) ClearAll(ctx context.Context) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.entries)
+	s.entries = make(map[string]*attemptState)
+
+	logger.FromContext(ctx, s.logger).Info("all authentication lockouts cleared", zap.Int("count", n))
+	return n
+}
+
+// prune drops entries that are neither blocked nor within the failure window; callers must hold s.mu
+func (s *LockoutService) prune(now time.Time) {
+	retention := s.config.Window
+	if s.config.MaxDuration > retention {
+		retention = s.config.MaxDuration
+	}
+
+	for key, st := range s.entries {
+		if now.After(st.blockedUntil) && now.Sub(st.lastSeen) > retention {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func lockoutKeys(ip, username string) []string {
+	keys := make([]string, 0, 2)
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	if username != "" {
+		keys = append(keys, "user:"+username)
+	}
+	return keys
+}
+
+func splitLockoutKey(key string) (string, string) {
+	scope, value, _ := strings.Cut(key, ":")
+	return scope, value
+}
+
+// backoff returns base doubled (n-1) times, capped at limit when limit is positive
+func backoff(base time.Duration, n int, limit time.Duration) time.Duration {
+	d := base
+	for i := 1; i < n; i++ {
+		d *= 2
+		if limit > 0 && d >= limit {
+			return limit
+		}
+	}
+	if limit > 0 && d > limit {
+		return limit
+	}
+	return d
+}
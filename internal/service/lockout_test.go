@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+)
+
+func newTestLockoutService(now *time.Time) *LockoutService {
+	s := NewLockoutService(configs.Lockout{
+		Enable:      true,
+		MaxFailures: 3,
+		Window:      time.Minute,
+		BaseDelay:   time.Second,
+		MaxDelay:    4 * time.Second,
+		Duration:    10 * time.Minute,
+		MaxDuration: 30 * time.Minute,
+	}, zap.NewNop())
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestLockoutService_ProgressiveBackoff(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTestLockoutService(&now)
+	ctx := context.Background()
+
+	if _, err := s.Check(ctx, "192.0.2.1", "alice"); err != nil {
+		t.Fatalf("Check() error = %v before any failure", err)
+	}
+
+	s.RecordFailure(ctx, "192.0.2.1", "alice")
+	wait, err := s.Check(ctx, "192.0.2.1", "alice")
+	if !apperrors.IsTooManyAttempts(err) {
+		t.Fatalf("Check() error = %v, want ErrTooManyAttempts", err)
+	}
+	if wait != time.Second {
+		t.Errorf("Check() wait = %v, want 1s", wait)
+	}
+
+	now = now.Add(time.Second)
+	s.RecordFailure(ctx, "192.0.2.1", "alice")
+	if wait, _ := s.Check(ctx, "192.0.2.1", "alice"); wait != 2*time.Second {
+		t.Errorf("Check() wait = %v, want 2s after second failure", wait)
+	}
+
+	// The username is blocked from a different IP too
+	if _, err := s.Check(ctx, "192.0.2.99", "alice"); err == nil {
+		t.Error("Check() from other IP error = nil, want username backoff")
+	}
+}
+
+func TestLockoutService_Lockout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTestLockoutService(&now)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		s.RecordFailure(ctx, "192.0.2.1", "alice")
+		now = now.Add(time.Millisecond)
+	}
+
+	wait, err := s.Check(ctx, "192.0.2.1", "")
+	if err == nil {
+		t.Fatal("Check() error = nil, want lockout")
+	}
+	if wait < 9*time.Minute {
+		t.Errorf("Check() wait = %v, want about 10m lockout", wait)
+	}
+
+	lockouts := s.List(ctx)
+	if len(lockouts) != 2 {
+		t.Fatalf("List() returned %d lockouts, want 2 (ip and user)", len(lockouts))
+	}
+	if lockouts[0].Key != "ip:192.0.2.1" || lockouts[1].Key != "user:alice" {
+		t.Errorf("List() keys = %q, %q", lockouts[0].Key, lockouts[1].Key)
+	}
+
+	// Repeat offenders are locked out for longer
+	now = now.Add(11 * time.Minute)
+	for i := 0; i < 3; i++ {
+		s.RecordFailure(ctx, "192.0.2.1", "")
+	}
+	if wait, _ := s.Check(ctx, "192.0.2.1", ""); wait != 20*time.Minute {
+		t.Errorf("Check() wait = %v, want 20m for second lockout", wait)
+	}
+}
+
+func TestLockoutService_WindowExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTestLockoutService(&now)
+	ctx := context.Background()
+
+	s.RecordFailure(ctx, "192.0.2.1", "")
+	s.RecordFailure(ctx, "192.0.2.1", "")
+	now = now.Add(2 * time.Minute)
+	s.RecordFailure(ctx, "192.0.2.1", "")
+
+	if len(s.List(ctx)) != 0 {
+		t.Error("List() non-empty, want failures outside window not to trigger lockout")
+	}
+}
+
+func TestLockoutService_SuccessAndClear(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTestLockoutService(&now)
+	ctx := context.Background()
+
+	s.RecordFailure(ctx, "192.0.2.1", "alice")
+	s.RecordSuccess(ctx, "192.0.2.1", "alice")
+	if _, err := s.Check(ctx, "192.0.2.1", "alice"); err != nil {
+		t.Errorf("Check() error = %v after success, want nil", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		s.RecordFailure(ctx, "192.0.2.1", "alice")
+	}
+	if !s.Clear(ctx, "user", "alice") {
+		t.Error("Clear() = false, want true for existing lockout")
+	}
+	if s.Clear(ctx, "user", "alice") {
+		t.Error("Clear() = true, want false for already cleared lockout")
+	}
+	if n := s.ClearAll(ctx); n != 1 {
+		t.Errorf("ClearAll() = %d, want 1", n)
+	}
+	if _, err := s.Check(ctx, "192.0.2.1", "alice"); err != nil {
+		t.Errorf("Check() error = %v after clear, want nil", err)
+	}
+}
+
+func TestLockoutService_Disabled(t *testing.T) {
+	s := NewLockoutService(configs.Lockout{Enable: false, MaxFailures: 1}, zap.NewNop())
+	ctx := context.Background()
+
+	s.RecordFailure(ctx, "192.0.2.1", "alice")
+	if _, err := s.Check(ctx, "192.0.2.1", "alice"); err != nil {
+		t.Errorf("Check() error = %v, want nil when disabled", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{n: 1, want: time.Second},
+		{n: 2, want: 2 * time.Second},
+		{n: 3, want: 4 * time.Second},
+		{n: 10, want: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(time.Second, tt.n, 5*time.Second); got != tt.want {
+			t.Errorf("backoff(1s, %d, 5s) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/logger"
+	"hls-key-server-go/internal/pkg/metrics"
+	"hls-key-server-go/internal/pkg/principal"
+	"hls-key-server-go/internal/repository"
+)
+
+// sessionSweeper is implemented by stores that need periodic eviction of idle sessions
+type sessionSweeper interface {
+	Sweep(idle time.Duration) int
+}
+
+// SessionService enforces concurrent playback session limits per subject
+type SessionService struct {
+	store     repository.SessionStore
+	config    configs.Session
+	overrides map[string]int
+	logger    *zap.Logger
+}
+
+// NewSessionService creates a new session limiter backed by store
+func NewSessionService(store repository.SessionStore, config configs.Session, logger *zap.Logger) *SessionService {
+	overrides := make(map[string]int, len(config.Overrides))
+	for _, o := range config.Overrides {
+		overrides[o.Subject] = o.Max
+	}
+
+	return &SessionService{
+		store:     store,
+		config:    config,
+		overrides: overrides,
+		logger:    logger,
+	}
+}
+
+// Admit records a key fetch for the principal's session and rejects new
+// sessions beyond the subject's limit with ErrSessionLimitExceeded
+func (s *SessionService) Admit(ctx context.Context, p principal.Principal) error {
+	log := logger.FromContext(ctx, s.logger)
+
+	if p.Subject == "" || p.SessionID == "" {
+		log.Debug("session tracking skipped for principal without session id",
+			zap.String("subject", p.Subject),
+		)
+		return nil
+	}
+
+	limit := s.Limit(p)
+	err := s.store.Acquire(ctx, p.Subject, p.SessionID, limit, s.config.IdleTimeout)
+	if apperrors.IsSessionLimitExceeded(err) {
+		metrics.SessionsDenied.Inc()
+		log.Warn("concurrent session limit exceeded",
+			zap.String("subject", p.Subject),
+			zap.String("session_id", p.SessionID),
+			zap.Int("limit", limit),
+		)
+		return err
+	}
+	if err != nil {
+		return apperrors.Wrap(err, "acquire session")
+	}
+
+	return nil
+}
+
+// Limit returns the session limit for the principal: a per-subject
+// override, or the default
+func (s *SessionService) Limit(p principal.Principal) int {
+	if limit, ok := s.overrides[p.Subject]; ok {
+		return limit
+	}
+	return s.config.MaxPerSubject
+}
+
+// List returns the active sessions of subject
+func (s *SessionService) List(ctx context.Context, subject string) ([]repository.Session, error) {
+	sessions, err := s.store.List(ctx, subject, s.config.IdleTimeout)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "list sessions")
+	}
+	return sessions, nil
+}
+
+// Release ends a session so that its slot is freed immediately
+func (s *SessionService) Release(ctx context.Context, subject, sessionID string) error {
+	return apperrors.Wrap(s.store.Release(ctx, subject, sessionID), "release session")
+}
+
+// Run periodically evicts idle sessions until ctx is done
+func (s *SessionService) Run(ctx context.Context, interval time.Duration) {
+	sweeper, ok := s.store.(sessionSweeper)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			metrics.ActiveSessions.Set(float64(sweeper.Sweep(s.config.IdleTimeout)))
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hls-key-server-go/internal/apperrors"
+	"hls-key-server-go/internal/configs"
+	"hls-key-server-go/internal/pkg/principal"
+	"hls-key-server-go/internal/repository"
+)
+
+func newTestSessionService() *SessionService {
+	return NewSessionService(repository.NewMemorySessionStore(), configs.Session{
+		Enable:        true,
+		MaxPerSubject: 1,
+		IdleTimeout:   time.Minute,
+		Overrides:     []configs.SessionOverride{{Subject: "vip", Max: 3}},
+	}, zap.NewNop())
+}
+
+func TestSessionService_Limit(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSessionService()
+
+	tests := []struct {
+		name string
+		p    principal.Principal
+		want int
+	}{
+		{name: "default", p: principal.Principal{Subject: "alice"}, want: 1},
+		{name: "override", p: principal.Principal{Subject: "vip"}, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := s.Limit(tt.p); got != tt.want {
+				t.Errorf("Limit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionService_Admit(t *testing.T) {
+	s := newTestSessionService()
+	ctx := context.Background()
+
+	if err := s.Admit(ctx, principal.Principal{Subject: "alice", SessionID: "s1"}); err != nil {
+		t.Fatalf("Admit(s1) error = %v", err)
+	}
+	err := s.Admit(ctx, principal.Principal{Subject: "alice", SessionID: "s2"})
+	if !apperrors.IsSessionLimitExceeded(err) {
+		t.Fatalf("Admit(s2) error = %v, want ErrSessionLimitExceeded", err)
+	}
+
+	// Principals without a session id are not tracked
+	if err := s.Admit(ctx, principal.Principal{Subject: "alice"}); err != nil {
+		t.Errorf("Admit(no sid) error = %v, want nil", err)
+	}
+
+	if err := s.Release(ctx, "alice", "s1"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := s.Admit(ctx, principal.Principal{Subject: "alice", SessionID: "s2"}); err != nil {
+		t.Errorf("Admit(s2) after release error = %v", err)
+	}
+
+	sessions, err := s.List(ctx, "alice")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != "s2" {
+		t.Errorf("List() = %+v, want only s2", sessions)
+	}
+}
+
+func TestHLSService_GetKey_SessionLimit(t *testing.T) {
+	repo := newMockKeyRepository()
+	service := NewHLSService(repo, zap.NewNop(), WithSessionLimits(newTestSessionService()))
+
+	first := principal.WithPrincipal(context.Background(), principal.Principal{Subject: "alice", SessionID: "s1"})
+	second := principal.WithPrincipal(context.Background(), principal.Principal{Subject: "alice", SessionID: "s2"})
+
+	if _, err := service.GetKey(first, "test.key"); err != nil {
+		t.Fatalf("GetKey() first session error = %v", err)
+	}
+	if _, err := service.GetKey(second, "test.key"); !apperrors.IsSessionLimitExceeded(err) {
+		t.Errorf("GetKey() second session error = %v, want ErrSessionLimitExceeded", err)
+	}
+
+	// Requests without a principal are not subject to session limits
+	if _, err := service.GetKey(context.Background(), "test.key"); err != nil {
+		t.Errorf("GetKey() without principal error = %v", err)
+	}
+}
+
+func TestHLSService_GetKey_SessionAdmittedAfterLookup(t *testing.T) {
+	repo := newMockKeyRepository()
+	service := NewHLSService(repo, zap.NewNop(), WithSessionLimits(newTestSessionService()))
+
+	probe := principal.WithPrincipal(context.Background(), principal.Principal{Subject: "alice", SessionID: "s1"})
+	player := principal.WithPrincipal(context.Background(), principal.Principal{Subject: "alice", SessionID: "s2"})
+
+	// Missing keys and invalid names must not take alice's only slot
+	if _, err := service.GetKey(probe, "missing.key"); !errors.Is(err, apperrors.ErrKeyNotFound) {
+		t.Fatalf("GetKey(missing) error = %v, want ErrKeyNotFound", err)
+	}
+	repo.getErr = apperrors.ErrInvalidKeyName
+	if _, err := service.GetKey(probe, "../x.key"); !errors.Is(err, apperrors.ErrInvalidKeyName) {
+		t.Fatalf("GetKey(invalid) error = %v, want ErrInvalidKeyName", err)
+	}
+	repo.getErr = nil
+
+	if _, err := service.GetKey(player, "test.key"); err != nil {
+		t.Errorf("GetKey() error = %v, want the slot still free", err)
+	}
+}